@@ -0,0 +1,17 @@
+package main
+
+import (
+	"os"
+
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/cmd/server"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/component-base/cli"
+)
+
+func main() {
+	ctx := genericapiserver.SetupSignalContext()
+	options := server.NewGreetingServerOptions(os.Stdout, os.Stderr)
+	cmd := server.NewCommandStartGreetingServer(ctx, options)
+	code := cli.Run(cmd)
+	os.Exit(code)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	clientset "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/clientset_generated/clientset"
+	informers "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/informers/externalversions"
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/controller/foo"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+)
+
+func main() {
+	var masterURL, kubeconfig string
+	var workers int
+	flag.StringVar(&kubeconfig, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
+	flag.StringVar(&masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
+	flag.IntVar(&workers, "workers", 2, "The number of foos synced concurrently.")
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	ctx := genericapiserver.SetupSignalContext()
+	logger := klog.FromContext(ctx)
+
+	config, err := clientcmd.BuildConfigFromFlags(masterURL, kubeconfig)
+	if err != nil {
+		logger.Error(err, "Error building kubeconfig")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		logger.Error(err, "Error building kubernetes clientset")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+	greetingClient, err := clientset.NewForConfig(config)
+	if err != nil {
+		logger.Error(err, "Error building greeting clientset")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 30*time.Second)
+	greetingInformerFactory := informers.NewSharedInformerFactory(greetingClient, 30*time.Second)
+
+	controller, err := foo.NewController(ctx, kubeClient, greetingClient,
+		greetingInformerFactory.Greeting().V2().Foos(),
+		kubeInformerFactory.Core().V1().ConfigMaps(),
+		kubeInformerFactory.Apps().V1().Deployments())
+	if err != nil {
+		logger.Error(err, "Error building foo controller")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
+	kubeInformerFactory.Start(ctx.Done())
+	greetingInformerFactory.Start(ctx.Done())
+
+	if err := controller.Run(ctx, workers); err != nil {
+		logger.Error(err, "Error running foo controller")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+}
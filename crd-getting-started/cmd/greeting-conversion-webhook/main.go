@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/install"
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/webhook/conversion"
+	"k8s.io/apimachinery/pkg/runtime"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/klog/v2"
+)
+
+func main() {
+	var bindAddress, certFile, keyFile string
+	flag.StringVar(&bindAddress, "bind-address", ":9443", "The address the conversion webhook listens on.")
+	flag.StringVar(&certFile, "tls-cert-file", "/etc/webhook/certs/tls.crt", "File containing the x509 certificate for HTTPS.")
+	flag.StringVar(&keyFile, "tls-private-key-file", "/etc/webhook/certs/tls.key", "File containing the x509 private key matching --tls-cert-file.")
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	scheme := runtime.NewScheme()
+	install.Install(scheme)
+
+	mux := http.NewServeMux()
+	mux.Handle("/convert", conversion.NewWebhook(scheme))
+	mux.HandleFunc("/healthz", func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+	server := &http.Server{Addr: bindAddress, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx := genericapiserver.SetupSignalContext()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			klog.ErrorS(err, "Failed to shutdown conversion webhook")
+		}
+	}()
+
+	klog.InfoS("Starting conversion webhook", "address", bindAddress)
+	if err := server.ListenAndServeTLS(certFile, keyFile); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		klog.ErrorS(err, "Conversion webhook stopped")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,87 @@
+package defaultimage
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/admission"
+	"sigs.k8s.io/yaml"
+)
+
+// PluginName is the name of the plugin, used in --enable-admission-plugins and
+// the admission config file.
+const PluginName = "DefaultFooImage"
+
+// DefaultImage is set on foos without an image when the plugin is not configured.
+const DefaultImage = "busybox:1.36"
+
+// Configuration configures the DefaultFooImage plugin.
+type Configuration struct {
+	// DefaultImage is set on foos created or updated without an image.
+	DefaultImage string `json:"defaultImage"`
+}
+
+// Register registers the plugin.
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName, func(config io.Reader) (admission.Interface, error) {
+		return New(config)
+	})
+}
+
+// DefaultFooImage sets the image of foos that do not specify one, without
+// touching images set by users. It admits the internal version, so the
+// configured image applies to foos written through any version.
+type DefaultFooImage struct {
+	*admission.Handler
+	defaultImage string
+}
+
+var _ admission.MutationInterface = &DefaultFooImage{}
+
+// New creates a DefaultFooImage plugin from its optional configuration.
+func New(config io.Reader) (*DefaultFooImage, error) {
+	configuration, err := loadConfiguration(config)
+	if err != nil {
+		return nil, err
+	}
+	return &DefaultFooImage{
+		Handler:      admission.NewHandler(admission.Create, admission.Update),
+		defaultImage: configuration.DefaultImage,
+	}, nil
+}
+
+func loadConfiguration(config io.Reader) (*Configuration, error) {
+	configuration := &Configuration{DefaultImage: DefaultImage}
+	if config == nil {
+		return configuration, nil
+	}
+	data, err := io.ReadAll(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(data, configuration); err != nil {
+		return nil, fmt.Errorf("failed to decode %s configuration: %w", PluginName, err)
+	}
+	if len(configuration.DefaultImage) == 0 {
+		return nil, fmt.Errorf("%s configuration: defaultImage must not be empty", PluginName)
+	}
+	return configuration, nil
+}
+
+// Admit sets the default image on foos without one.
+func (d *DefaultFooImage) Admit(_ context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetKind().GroupKind() != greeting.Kind("Foo") || len(a.GetSubresource()) != 0 {
+		return nil
+	}
+	foo, ok := a.GetObject().(*greeting.Foo)
+	if !ok {
+		return errors.NewBadRequest(fmt.Sprintf("unexpected object type %T", a.GetObject()))
+	}
+	if len(foo.Spec.Image) == 0 {
+		foo.Spec.Image = d.defaultImage
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package defaultimage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+)
+
+func attributes(obj runtime.Object, operation admission.Operation, subresource string) admission.Attributes {
+	return admission.NewAttributesRecord(obj, nil, greeting.Kind("Foo").WithVersion("version"),
+		metav1.NamespaceDefault, "foo", greeting.Resource("foos").WithVersion("version"),
+		subresource, operation, nil, false, nil)
+}
+
+func TestAdmit(t *testing.T) {
+	testCases := map[string]struct {
+		config      string
+		image       string
+		operation   admission.Operation
+		subresource string
+		expected    string
+	}{
+		"defaults missing image on create": {
+			operation: admission.Create,
+			expected:  DefaultImage,
+		},
+		"defaults missing image on update": {
+			operation: admission.Update,
+			expected:  DefaultImage,
+		},
+		"keeps image set by user": {
+			image:     "nginx:1.27",
+			operation: admission.Create,
+			expected:  "nginx:1.27",
+		},
+		"uses configured default": {
+			config:    "defaultImage: registry.local:5000/busybox:1.37",
+			operation: admission.Create,
+			expected:  "registry.local:5000/busybox:1.37",
+		},
+		"ignores status updates": {
+			operation:   admission.Update,
+			subresource: "status",
+			expected:    "",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var config io.Reader
+			if len(tc.config) > 0 {
+				config = strings.NewReader(tc.config)
+			}
+			plugin, err := New(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			foo := &greeting.Foo{Spec: greeting.FooSpec{Image: tc.image}}
+			if err := plugin.Admit(context.TODO(), attributes(foo, tc.operation, tc.subresource), nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if foo.Spec.Image != tc.expected {
+				t.Errorf("expected image %q, got %q", tc.expected, foo.Spec.Image)
+			}
+		})
+	}
+}
+
+func TestNewInvalidConfiguration(t *testing.T) {
+	for name, config := range map[string]string{
+		"unknown field": "image: busybox",
+		"empty image":   `defaultImage: ""`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := New(strings.NewReader(config)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package imagepolicy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/admission"
+	"sigs.k8s.io/yaml"
+)
+
+// PluginName is the name of the plugin, used in --enable-admission-plugins and
+// the admission config file.
+const PluginName = "FooImagePolicy"
+
+const (
+	// defaultRegistry is the registry of image references without a registry host.
+	defaultRegistry = "docker.io"
+	// officialRepositoryPrefix is the namespace of single-component repositories
+	// of defaultRegistry, such as "busybox".
+	officialRepositoryPrefix = "library/"
+)
+
+// Configuration configures the FooImagePolicy plugin. Entries are registry
+// hosts such as "registry.local:5000", optionally followed by a repository
+// path prefix such as "ghcr.io/foenye". Images without a registry host belong
+// to docker.io.
+type Configuration struct {
+	// AllowedRegistries applies to namespaces without an entry in Namespaces.
+	// Every registry is allowed when empty.
+	// +optional
+	AllowedRegistries []string `json:"allowedRegistries,omitempty"`
+	// Namespaces overrides AllowedRegistries for the given namespaces, an empty
+	// list allows no registry at all.
+	// +optional
+	Namespaces map[string][]string `json:"namespaces,omitempty"`
+}
+
+// Register registers the plugin.
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName, func(config io.Reader) (admission.Interface, error) {
+		return New(config)
+	})
+}
+
+// FooImagePolicy rejects foos whose image is not pulled from one of the
+// registries allowed in their namespace.
+type FooImagePolicy struct {
+	*admission.Handler
+	configuration *Configuration
+}
+
+var _ admission.ValidationInterface = &FooImagePolicy{}
+
+// New creates a FooImagePolicy plugin from its optional configuration. Without
+// configuration every registry is allowed.
+func New(config io.Reader) (*FooImagePolicy, error) {
+	configuration := &Configuration{}
+	if config != nil {
+		data, err := io.ReadAll(config)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.UnmarshalStrict(data, configuration); err != nil {
+			return nil, fmt.Errorf("failed to decode %s configuration: %w", PluginName, err)
+		}
+	}
+	return &FooImagePolicy{
+		Handler:       admission.NewHandler(admission.Create, admission.Update),
+		configuration: configuration,
+	}, nil
+}
+
+// Validate rejects foos with an image from a registry not allowed in their
+// namespace. Updates are only checked when they change the image, so that
+// tightening the policy does not block unrelated updates of existing foos.
+func (p *FooImagePolicy) Validate(_ context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetKind().GroupKind() != greeting.Kind("Foo") || len(a.GetSubresource()) != 0 {
+		return nil
+	}
+	foo, ok := a.GetObject().(*greeting.Foo)
+	if !ok {
+		return errors.NewBadRequest(fmt.Sprintf("unexpected object type %T", a.GetObject()))
+	}
+	if a.GetOperation() == admission.Update {
+		if oldFoo, ok := a.GetOldObject().(*greeting.Foo); ok && oldFoo.Spec.Image == foo.Spec.Image {
+			return nil
+		}
+	}
+
+	allowed, restricted := p.allowedRegistries(a.GetNamespace())
+	if !restricted || isAllowed(foo.Spec.Image, allowed) {
+		return nil
+	}
+	if len(allowed) == 0 {
+		return admission.NewForbidden(a, fmt.Errorf("image %q is not allowed, no registry is allowed in namespace %q",
+			foo.Spec.Image, a.GetNamespace()))
+	}
+	return admission.NewForbidden(a, fmt.Errorf("image %q is not pulled from an allowed registry in namespace %q, allowed: %s",
+		foo.Spec.Image, a.GetNamespace(), strings.Join(allowed, ", ")))
+}
+
+// allowedRegistries returns the registries allowed in namespace, and whether
+// images are restricted to them at all. A namespace entry restricts images
+// even when empty, only the global list allows everything when empty.
+func (p *FooImagePolicy) allowedRegistries(namespace string) ([]string, bool) {
+	if allowed, ok := p.configuration.Namespaces[namespace]; ok {
+		return allowed, true
+	}
+	return p.configuration.AllowedRegistries, len(p.configuration.AllowedRegistries) > 0
+}
+
+func isAllowed(image string, allowed []string) bool {
+	repository := normalizeRepository(image)
+	for _, entry := range allowed {
+		entry = strings.TrimSuffix(entry, "/")
+		if repository == entry || strings.HasPrefix(repository, entry+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeRepository returns the repository of image prefixed with its
+// registry host, without tag or digest.
+func normalizeRepository(image string) string {
+	repository, _, _ := strings.Cut(image, "@")
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository = repository[:i]
+	}
+	host, _, found := strings.Cut(repository, "/")
+	if !found {
+		return defaultRegistry + "/" + officialRepositoryPrefix + repository
+	}
+	if !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return defaultRegistry + "/" + repository
+	}
+	return repository
+}
@@ -0,0 +1,143 @@
+package imagepolicy
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+)
+
+const testConfig = `
+allowedRegistries:
+- docker.io/library
+- registry.local:5000
+namespaces:
+  production:
+  - ghcr.io/foenye
+  sandbox: []
+`
+
+func attributes(namespace string, foo, oldFoo runtime.Object, operation admission.Operation, subresource string) admission.Attributes {
+	return admission.NewAttributesRecord(foo, oldFoo, greeting.Kind("Foo").WithVersion("version"),
+		namespace, "foo", greeting.Resource("foos").WithVersion("version"),
+		subresource, operation, nil, false, nil)
+}
+
+func fooWithImage(image string) *greeting.Foo {
+	return &greeting.Foo{Spec: greeting.FooSpec{Image: image}}
+}
+
+func TestValidate(t *testing.T) {
+	plugin, err := New(strings.NewReader(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		namespace   string
+		image       string
+		oldImage    string
+		operation   admission.Operation
+		subresource string
+		forbidden   bool
+	}{
+		"official image without registry": {
+			namespace: "default", image: "busybox:1.36", operation: admission.Create,
+		},
+		"official image with registry": {
+			namespace: "default", image: "docker.io/library/busybox:1.36", operation: admission.Create,
+		},
+		"image from allowed registry with digest": {
+			namespace: "default", image: "registry.local:5000/team/foo@sha256:" + strings.Repeat("a", 64), operation: admission.Create,
+		},
+		"user image on docker hub": {
+			namespace: "default", image: "someone/busybox:1.36", operation: admission.Create, forbidden: true,
+		},
+		"registry sharing a prefix": {
+			namespace: "default", image: "registry.local:50001/foo:1.0", operation: admission.Create, forbidden: true,
+		},
+		"namespace override allows": {
+			namespace: "production", image: "ghcr.io/foenye/foo:1.0", operation: admission.Create,
+		},
+		"namespace override forbids default registries": {
+			namespace: "production", image: "busybox:1.36", operation: admission.Create, forbidden: true,
+		},
+		"empty namespace override forbids everything": {
+			namespace: "sandbox", image: "quay.io/someone/foo:1.0", operation: admission.Create, forbidden: true,
+		},
+		"empty namespace override forbids default registries": {
+			namespace: "sandbox", image: "busybox:1.36", operation: admission.Create, forbidden: true,
+		},
+		"update changing image": {
+			namespace: "default", image: "quay.io/foo:1.0", oldImage: "busybox:1.36", operation: admission.Update, forbidden: true,
+		},
+		"update keeping image": {
+			namespace: "default", image: "quay.io/foo:1.0", oldImage: "quay.io/foo:1.0", operation: admission.Update,
+		},
+		"status update": {
+			namespace: "default", image: "quay.io/foo:1.0", oldImage: "busybox:1.36", operation: admission.Update, subresource: "status",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var oldFoo runtime.Object
+			if tc.operation == admission.Update {
+				oldFoo = fooWithImage(tc.oldImage)
+			}
+			err := plugin.Validate(context.TODO(),
+				attributes(tc.namespace, fooWithImage(tc.image), oldFoo, tc.operation, tc.subresource), nil)
+			if tc.forbidden != apierrors.IsForbidden(err) {
+				t.Errorf("expected forbidden %t, got %v", tc.forbidden, err)
+			}
+			if !tc.forbidden && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateWithoutConfiguration(t *testing.T) {
+	plugin, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := attributes("default", fooWithImage("quay.io/someone/foo:1.0"), nil, admission.Create, "")
+	if err := plugin.Validate(context.TODO(), attrs, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateWithoutGlobalRegistries(t *testing.T) {
+	plugin, err := New(strings.NewReader("namespaces:\n  kube-system: []\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := attributes("default", fooWithImage("quay.io/someone/foo:1.0"), nil, admission.Create, "")
+	if err := plugin.Validate(context.TODO(), attrs, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	attrs = attributes("kube-system", fooWithImage("busybox:1.36"), nil, admission.Create, "")
+	if err := plugin.Validate(context.TODO(), attrs, nil); !apierrors.IsForbidden(err) {
+		t.Errorf("expected forbidden error, got %v", err)
+	}
+}
+
+func TestNormalizeRepository(t *testing.T) {
+	for image, expected := range map[string]string{
+		"busybox":                          "docker.io/library/busybox",
+		"busybox:1.36":                     "docker.io/library/busybox",
+		"someone/busybox:1.36":             "docker.io/someone/busybox",
+		"library/busybox@sha256:abc":       "docker.io/library/busybox",
+		"localhost/foo:1.0":                "localhost/foo",
+		"registry.local:5000/foo":          "registry.local:5000/foo",
+		"registry.local:5000/team/foo:1.0": "registry.local:5000/team/foo",
+	} {
+		if actual := normalizeRepository(image); actual != expected {
+			t.Errorf("%s: expected %q, got %q", image, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package fuzzer
+
+import (
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting"
+	greetingv1 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v1"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	"sigs.k8s.io/randfill"
+)
+
+// labelName is the label set to metadata.name by the v1 and v2 defaulters.
+const labelName = "greeting.foen.ye/metadata.name"
+
+// Funcs returns the fuzzer functions for the greeting api group.
+var Funcs = func(codecs runtimeserializer.CodecFactory) []interface{} {
+	return []interface{}{
+		func(obj *greeting.Foo, c randfill.Continue) {
+			c.FillNoCustom(obj)
+			if obj.Labels == nil {
+				obj.Labels = map[string]string{}
+			}
+			obj.Labels[labelName] = obj.Name
+			// The annotations v1 carries spec.image and status in are reserved,
+			// they are dropped when converting back.
+			delete(obj.Annotations, greetingv1.AnnotationImage)
+			delete(obj.Annotations, greetingv1.AnnotationPreservedFields)
+			if len(obj.Annotations) == 0 {
+				obj.Annotations = nil
+			}
+		},
+	}
+}
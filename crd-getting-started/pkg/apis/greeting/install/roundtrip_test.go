@@ -0,0 +1,103 @@
+package install
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting"
+	greetingfuzzer "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/fuzzer"
+	greetingv1 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v1"
+	greetingv2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v2"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	"k8s.io/apimachinery/pkg/api/apitesting/roundtrip"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	"sigs.k8s.io/randfill"
+)
+
+func newFuzzer(funcs fuzzer.FuzzerFuncs, codecs runtimeserializer.CodecFactory) *randfill.Filler {
+	return fuzzer.FuzzerFor(fuzzer.MergeFuzzerFuncs(metafuzzer.Funcs, funcs), rand.NewSource(rand.Int63()), codecs)
+}
+
+func TestRoundTripTypes(t *testing.T) {
+	roundtrip.RoundTripProtobufTestForAPIGroup(t, Install, greetingfuzzer.Funcs)
+}
+
+func TestRoundTripExternalTypes(t *testing.T) {
+	scheme := runtime.NewScheme()
+	Install(scheme)
+	codecs := runtimeserializer.NewCodecFactory(scheme)
+
+	roundtrip.RoundTripExternalTypes(t, scheme, codecs, newFuzzer(greetingfuzzer.Funcs, codecs), nil)
+}
+
+func TestRoundTripYAML(t *testing.T) {
+	scheme := runtime.NewScheme()
+	Install(scheme)
+	codecs := runtimeserializer.NewCodecFactory(scheme)
+	f := newFuzzer(greetingfuzzer.Funcs, codecs)
+	info, ok := runtime.SerializerInfoForMediaType(codecs.SupportedMediaTypes(), runtime.ContentTypeYAML)
+	if !ok {
+		t.Fatalf("no serializer for %s", runtime.ContentTypeYAML)
+	}
+
+	for _, gv := range []schema.GroupVersion{greetingv1.SchemeGroupVersion, greetingv2.SchemeGroupVersion} {
+		t.Run(gv.Version, func(t *testing.T) {
+			codec := codecs.CodecForVersions(info.Serializer, codecs.UniversalDecoder(), gv, runtime.InternalGroupVersioner)
+
+			for i := 0; i < *roundtrip.FuzzIters; i++ {
+				for _, original := range []runtime.Object{&greeting.Foo{}, &greeting.FooList{}} {
+					f.Fill(original)
+					data, err := runtime.Encode(codec, original)
+					if err != nil {
+						t.Fatalf("failed to encode %T: %v", original, err)
+					}
+					decoded, err := runtime.Decode(codec, data)
+					if err != nil {
+						t.Fatalf("failed to decode %T: %v\n%s", original, err, data)
+					}
+					if !apiequality.Semantic.DeepEqual(original, decoded) {
+						t.Fatalf("%T changed in a YAML round trip (-want +got):\n%s", original, cmp.Diff(original, decoded))
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestRoundTripV1V2 converts internal -> v2 -> v1 -> v2 -> internal, as the
+// conversion webhook and the apiserver storage do when serving v1. Versions
+// convert through the internal version.
+func TestRoundTripV1V2(t *testing.T) {
+	scheme := runtime.NewScheme()
+	Install(scheme)
+	f := newFuzzer(greetingfuzzer.Funcs, runtimeserializer.NewCodecFactory(scheme))
+
+	for i := 0; i < *roundtrip.FuzzIters; i++ {
+		original := &greeting.Foo{}
+		f.Fill(original)
+
+		var obj runtime.Object = original.DeepCopy()
+		for _, gv := range []runtime.GroupVersioner{
+			greetingv2.SchemeGroupVersion,
+			runtime.InternalGroupVersioner,
+			greetingv1.SchemeGroupVersion,
+			runtime.InternalGroupVersioner,
+			greetingv2.SchemeGroupVersion,
+			runtime.InternalGroupVersioner,
+		} {
+			converted, err := scheme.ConvertToVersion(obj, gv)
+			if err != nil {
+				t.Fatalf("failed to convert %T to %v: %v", obj, gv, err)
+			}
+			obj = converted
+		}
+		if !apiequality.Semantic.DeepEqual(original, obj) {
+			t.Fatalf("Foo changed in a v1 <-> v2 round trip (-want +got):\n%s", cmp.Diff(original, obj))
+		}
+	}
+}
@@ -47,6 +47,10 @@ type FooStatus struct {
 	// +patchMergeKey=type
 	// +patchStrategy=merge
 	Conditions []FooCondition
+
+	// The generation of the foo observed by the controller
+	// +optional
+	ObservedGeneration int64
 }
 
 type FooConditionType string
@@ -59,6 +63,15 @@ const (
 type FooCondition struct {
 	Type   FooConditionType
 	Status metav1.ConditionStatus
+	// Last time the condition transitioned from one status to another.
+	// +optional
+	LastTransitionTime metav1.Time
+	// Unique, one-word, CamelCase reason for the condition's last transition.
+	// +optional
+	Reason string
+	// Human-readable message indicating details about last transition.
+	// +optional
+	Message string
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -1,10 +1,91 @@
 package v1
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting"
+	greetingv2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v2"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/conversion"
 )
 
+// AnnotationPreservedFields is reserved to carry, as JSON, the spec and status
+// of a Foo, so that the fields v1 can't represent survive. It is set when
+// converting down to v1 and removed again when converting up, so that reading
+// a Foo as v1 and writing it back keeps them. It never shows up in other
+// versions.
+const AnnotationPreservedFields = "greeting.foen.ye/v1-preserved-fields"
+
+// preservedFields are the fields of greeting.Foo a v1 Foo may lose. All of
+// them are kept, whether v1 represents them or not, so that fields added after
+// v1 need no change here. They are persisted as v2, whose serialization is
+// stable, unlike the one of the internal types.
+type preservedFields struct {
+	Spec   greetingv2.FooSpec   `json:"spec"`
+	Status greetingv2.FooStatus `json:"status"`
+}
+
+// withV1Fields sets the fields of spec that v1 represents from in, leaving the
+// others untouched.
+func withV1Fields(in *greeting.FooSpec, spec *greeting.FooSpec, s conversion.Scope) error {
+	v1Spec := &FooSpec{}
+	if err := Convert_greeting_FooSpec_To_v1_FooSpec(in, v1Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_v1_FooSpec_To_greeting_FooSpec(v1Spec, spec, s); err != nil {
+		return err
+	}
+	spec.Image = in.Image
+	return nil
+}
+
+// preserveFields returns the AnnotationPreservedFields value for in, empty if
+// v1 represents all of in.
+func preserveFields(in *greeting.Foo, s conversion.Scope) (string, error) {
+	represented := &greeting.FooSpec{}
+	if err := withV1Fields(&in.Spec, represented, s); err != nil {
+		return "", err
+	}
+	if apiequality.Semantic.DeepEqual(&in.Spec, represented) &&
+		apiequality.Semantic.DeepEqual(&in.Status, &greeting.FooStatus{}) {
+		return "", nil
+	}
+	fields := &preservedFields{}
+	if err := greetingv2.Convert_greeting_FooSpec_To_v2_FooSpec(&in.Spec, &fields.Spec, s); err != nil {
+		return "", err
+	}
+	if err := greetingv2.Convert_greeting_FooStatus_To_v2_FooStatus(&in.Status, &fields.Status, s); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// restoreFields sets the fields of out kept in the AnnotationPreservedFields
+// value, except the ones v1 represents which out already holds.
+func restoreFields(value string, out *greeting.Foo, s conversion.Scope) error {
+	fields := &preservedFields{}
+	if err := json.Unmarshal([]byte(value), fields); err != nil {
+		return fmt.Errorf("invalid %s annotation: %v", AnnotationPreservedFields, err)
+	}
+	spec, status := &greeting.FooSpec{}, &greeting.FooStatus{}
+	if err := greetingv2.Convert_v2_FooSpec_To_greeting_FooSpec(&fields.Spec, spec, s); err != nil {
+		return err
+	}
+	if err := greetingv2.Convert_v2_FooStatus_To_greeting_FooStatus(&fields.Status, status, s); err != nil {
+		return err
+	}
+	if err := withV1Fields(&out.Spec, spec, s); err != nil {
+		return err
+	}
+	out.Spec, out.Status = *spec, *status
+	return nil
+}
+
 // Convert_greeting_Foo_To_v1_Foo
 //
 //goland:noinspection GoSnakeCaseUsage
@@ -15,10 +96,21 @@ func Convert_greeting_Foo_To_v1_Foo(in *greeting.Foo, out *Foo, s conversion.Sco
 		return err
 	}
 	// do conversion here
-	if out.Annotations == nil {
-		out.Annotations = map[string]string{}
+	// out.ObjectMeta shares its annotations with in, copy them before writing.
+	annotations := make(map[string]string, len(in.Annotations)+1)
+	for k, v := range in.Annotations {
+		annotations[k] = v
 	}
-	out.Annotations[AnnotationImage] = in.Spec.Image
+	annotations[AnnotationImage] = in.Spec.Image
+	delete(annotations, AnnotationPreservedFields)
+	preserved, err := preserveFields(in, s)
+	if err != nil {
+		return err
+	}
+	if preserved != "" {
+		annotations[AnnotationPreservedFields] = preserved
+	}
+	out.Annotations = annotations
 	return nil
 }
 
@@ -33,9 +125,36 @@ func Convert_v1_Foo_To_greeting_Foo(in *Foo, out *greeting.Foo, s conversion.Sco
 	}
 	// do conversion here
 	out.Spec.Image = in.Annotations[AnnotationImage]
+	if preserved, ok := in.Annotations[AnnotationPreservedFields]; ok {
+		if err := restoreFields(preserved, out, s); err != nil {
+			return err
+		}
+	}
+	out.Annotations = withoutV1Annotations(in.Annotations)
 	return nil
 }
 
+// withoutV1Annotations returns annotations without the ones only v1 carries,
+// nil if none is left. The given map is never modified as out.ObjectMeta
+// shares it with in.
+func withoutV1Annotations(annotations map[string]string) map[string]string {
+	_, hasImage := annotations[AnnotationImage]
+	_, hasPreserved := annotations[AnnotationPreservedFields]
+	if !hasImage && !hasPreserved {
+		return annotations
+	}
+	out := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		if k != AnnotationImage && k != AnnotationPreservedFields {
+			out[k] = v
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
 // Convert_greeting_FooSpec_To_v1_FooSpec
 //
 //goland:noinspection GoSnakeCaseUsage
@@ -0,0 +1,146 @@
+package v1_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting"
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/install"
+	greetingv1 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v1"
+	greetingv2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v2"
+	"github.com/google/go-cmp/cmp"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/randfill"
+)
+
+func newScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	install.Install(scheme)
+	return scheme
+}
+
+func TestConvertPreservesStatus(t *testing.T) {
+	scheme := newScheme()
+	original := &greeting.Foo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foo",
+			Annotations: map[string]string{"team": "greeting"},
+		},
+		Spec: greeting.FooSpec{Image: "nginx:1.27", Config: greeting.FooConfig{Message: "hello"}},
+		Status: greeting.FooStatus{
+			Phase: greeting.FooPhaseReady,
+			Conditions: []greeting.FooCondition{{
+				Type:               greeting.FooConditionTypeWorker,
+				Status:             metav1.ConditionTrue,
+				LastTransitionTime: metav1.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+				Reason:             "DeploymentAvailable",
+				Message:            "deployment foo is available",
+			}},
+			ObservedGeneration: 3,
+		},
+	}
+	before := original.DeepCopy()
+
+	v1Foo := &greetingv1.Foo{}
+	if err := scheme.Convert(original, v1Foo, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !apiequality.Semantic.DeepEqual(before, original) {
+		t.Errorf("conversion altered its input (-want +got):\n%s", cmp.Diff(before, original))
+	}
+	if image := v1Foo.Annotations[greetingv1.AnnotationImage]; image != "nginx:1.27" {
+		t.Errorf("expected the %s annotation to hold the image, got %q", greetingv1.AnnotationImage, image)
+	}
+	preserved, ok := v1Foo.Annotations[greetingv1.AnnotationPreservedFields]
+	if !ok {
+		t.Fatalf("expected the %s annotation, got %v", greetingv1.AnnotationPreservedFields, v1Foo.Annotations)
+	}
+	// The annotation is persisted, it holds the spec and status serialized as v2.
+	fields := &struct {
+		Spec   greetingv2.FooSpec   `json:"spec"`
+		Status greetingv2.FooStatus `json:"status"`
+	}{}
+	if err := json.Unmarshal([]byte(preserved), fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields.Spec.Image != "nginx:1.27" || fields.Status.Phase != greetingv2.FooPhaseReady ||
+		fields.Status.ObservedGeneration != 3 || len(fields.Status.Conditions) != 1 {
+		t.Errorf("unexpected %s annotation %s", greetingv1.AnnotationPreservedFields, preserved)
+	}
+
+	roundTripped := &greeting.Foo{}
+	if err := scheme.Convert(v1Foo, roundTripped, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !apiequality.Semantic.DeepEqual(original, roundTripped) {
+		t.Errorf("round trip mismatch (-want +got):\n%s", cmp.Diff(original, roundTripped))
+	}
+	if _, ok := v1Foo.Annotations[greetingv1.AnnotationPreservedFields]; !ok {
+		t.Errorf("conversion removed the %s annotation from its input", greetingv1.AnnotationPreservedFields)
+	}
+}
+
+// TestConvertPreservesAllFields fills every field of a Foo, including ones
+// added after v1, and expects a v1 round trip to keep them all.
+func TestConvertPreservesAllFields(t *testing.T) {
+	scheme := newScheme()
+	filler := randfill.New().NilChance(0).Funcs(func(t *metav1.Time, c randfill.Continue) {
+		// metav1.Time is serialized with a second precision
+		*t = metav1.Unix(c.Int63n(1<<32), 0)
+	})
+
+	for i := 0; i < 20; i++ {
+		original := &greeting.Foo{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+		filler.Fill(&original.Spec)
+		filler.Fill(&original.Status)
+		original.Spec.Image = "nginx:1.27"
+
+		v1Foo := &greetingv1.Foo{}
+		if err := scheme.Convert(original, v1Foo, nil); err != nil {
+			t.Fatal(err)
+		}
+		roundTripped := &greeting.Foo{}
+		if err := scheme.Convert(v1Foo, roundTripped, nil); err != nil {
+			t.Fatal(err)
+		}
+		if !apiequality.Semantic.DeepEqual(original, roundTripped) {
+			t.Fatalf("round trip lost fields (-want +got):\n%s", cmp.Diff(original, roundTripped))
+		}
+	}
+}
+
+func TestConvertWithoutStatus(t *testing.T) {
+	scheme := newScheme()
+	original := &greeting.Foo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "foo",
+			// the annotation is output only, a stale one is dropped
+			Annotations: map[string]string{greetingv1.AnnotationPreservedFields: `{"status":{"phase":"Ready"}}`},
+		},
+	}
+
+	v1Foo := &greetingv1.Foo{}
+	if err := scheme.Convert(original, v1Foo, nil); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := v1Foo.Annotations[greetingv1.AnnotationPreservedFields]; ok {
+		t.Errorf("expected no %s annotation, got %q", greetingv1.AnnotationPreservedFields, value)
+	}
+}
+
+func TestConvertInvalidPreservedFields(t *testing.T) {
+	scheme := newScheme()
+	v1Foo := &greetingv1.Foo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foo",
+			Annotations: map[string]string{greetingv1.AnnotationPreservedFields: "{"},
+		},
+	}
+
+	if err := scheme.Convert(v1Foo, &greeting.Foo{}, nil); err == nil {
+		t.Error("expected an error for a malformed annotation")
+	}
+}
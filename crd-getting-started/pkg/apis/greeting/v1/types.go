@@ -4,6 +4,7 @@ import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 type FooSpec struct {
 	// Message greeting hello world!
+	// +kubebuilder:validation:MaxLength=15
 	Message string `json:"message" protobuf:"bytes,1,opt,name=message"`
 	// Description write casually
 	// +optional
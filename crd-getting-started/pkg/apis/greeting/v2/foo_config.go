@@ -2,6 +2,7 @@ package v2
 
 type FooConfig struct {
 	// Message says hello world!
+	// +kubebuilder:validation:MaxLength=15
 	Message string `json:"message" protobuf:"bytes,1,opt,name=message"`
 	// Description provides some verbose information
 	// +optional
@@ -9,10 +9,17 @@ type FooStatus struct {
 	// +optional
 	// +patchMergeKey=type
 	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
 	Conditions []FooCondition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,2,rep,name=conditions"`
+
+	// The generation of the foo observed by the controller
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty" protobuf:"varint,3,opt,name=observedGeneration"`
 }
 
 // FooPhase is a label for the condition of a foo at the current time.
+// +kubebuilder:validation:Enum=Processing;Ready
 type FooPhase string
 
 const (
@@ -210,48 +210,54 @@ func init() {
 }
 
 var fileDescriptor_f6b5bf28206e02c9 = []byte{
-	// 653 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x55, 0xcb, 0x6e, 0x13, 0x3d,
-	0x18, 0xcd, 0xb4, 0x4d, 0x2f, 0x4e, 0xff, 0x9f, 0xca, 0x6c, 0x4a, 0x17, 0x93, 0x2a, 0x6c, 0xca,
-	0x22, 0x36, 0x8d, 0x0a, 0x62, 0x1d, 0x50, 0x24, 0xa4, 0x56, 0xc0, 0x00, 0x42, 0xaa, 0x2a, 0x82,
-	0xeb, 0x71, 0x27, 0xa6, 0xcc, 0x78, 0x34, 0x76, 0x82, 0xb2, 0x82, 0x27, 0x40, 0x3c, 0x04, 0x7b,
-	0x5e, 0xa3, 0x1b, 0xa4, 0x2e, 0xbb, 0x0a, 0x24, 0xbc, 0x45, 0x57, 0xc8, 0x97, 0xc9, 0x0c, 0xa9,
-	0x10, 0x5d, 0x84, 0x9d, 0xbf, 0xdb, 0x39, 0xc7, 0x9f, 0x4f, 0x32, 0xe0, 0x28, 0xe2, 0xaa, 0xd7,
-	0x3f, 0x46, 0x54, 0xc4, 0xf8, 0x44, 0xb0, 0x64, 0xc8, 0x30, 0x7d, 0x27, 0xfa, 0x61, 0x33, 0x21,
-	0x8a, 0x0f, 0x58, 0x53, 0x89, 0x7e, 0x86, 0x69, 0x16, 0x36, 0x23, 0xa6, 0x14, 0x4f, 0xa2, 0xa6,
-	0x54, 0x24, 0x53, 0x2c, 0xc4, 0xe9, 0x69, 0x84, 0x49, 0xca, 0x25, 0x8e, 0x32, 0xc6, 0x74, 0x05,
-	0x0f, 0x5a, 0x38, 0x62, 0x09, 0xcb, 0x88, 0x62, 0x21, 0x4a, 0x33, 0xa1, 0x04, 0xdc, 0x2f, 0xd0,
-	0x91, 0x45, 0x47, 0x06, 0xbd, 0x6b, 0xd1, 0xbb, 0x1a, 0x1d, 0xd1, 0x2c, 0xec, 0x3a, 0xf4, 0xae,
-	0x43, 0x47, 0xe9, 0x69, 0x84, 0x34, 0x3a, 0xca, 0xd1, 0xd1, 0xa0, 0xb5, 0xd5, 0x2c, 0x69, 0x8d,
-	0x44, 0x24, 0xb0, 0x21, 0x39, 0xee, 0x9f, 0x98, 0xc8, 0x04, 0xe6, 0x64, 0xc9, 0xb7, 0xf6, 0x4e,
-	0x1f, 0x48, 0xc4, 0x85, 0x56, 0x19, 0x13, 0xda, 0xe3, 0x09, 0xcb, 0x86, 0x85, 0xec, 0x98, 0x29,
-	0x82, 0x07, 0xbb, 0xb3, 0x92, 0xb7, 0xf0, 0x9f, 0xa6, 0xb2, 0x7e, 0xa2, 0x78, 0xcc, 0xae, 0x0c,
-	0xdc, 0xff, 0xdb, 0x80, 0xa4, 0x3d, 0x16, 0x93, 0xd9, 0xb9, 0xc6, 0x78, 0x01, 0x2c, 0x76, 0x84,
-	0x80, 0x6f, 0xc0, 0xaa, 0xd6, 0x12, 0x12, 0x45, 0x36, 0xbd, 0x6d, 0x6f, 0xa7, 0xd6, 0xba, 0x8b,
-	0x2c, 0x24, 0x2a, 0x43, 0x16, 0x2b, 0xd1, 0xdd, 0x68, 0xb0, 0x8b, 0x9e, 0x1c, 0xbf, 0x65, 0x54,
-	0x1d, 0x30, 0x45, 0xda, 0xf0, 0x6c, 0x54, 0xaf, 0x4c, 0x46, 0x75, 0x50, 0xe4, 0x82, 0x29, 0x2a,
-	0x7c, 0x0f, 0x96, 0x64, 0xca, 0xe8, 0xe6, 0x82, 0x41, 0x7f, 0x89, 0xe6, 0xf9, 0x28, 0xa8, 0x23,
-	0xc4, 0xf3, 0x94, 0xd1, 0xf6, 0xba, 0x93, 0xb0, 0xa4, 0xa3, 0xc0, 0x10, 0xc2, 0x0f, 0x60, 0x59,
-	0x2a, 0xa2, 0xfa, 0x72, 0x73, 0xd1, 0x50, 0xbf, 0x9a, 0x3f, 0xb5, 0x81, 0x6f, 0xff, 0xef, 0xc8,
-	0x97, 0x6d, 0x1c, 0x38, 0xda, 0xc6, 0x17, 0x0f, 0xac, 0x77, 0x84, 0x78, 0x28, 0x92, 0x90, 0x2b,
-	0x2e, 0x12, 0xb8, 0x07, 0x96, 0xd4, 0x30, 0x65, 0x66, 0xd1, 0x6b, 0xed, 0xed, 0x5c, 0xf3, 0x8b,
-	0x61, 0xca, 0x2e, 0x47, 0xf5, 0x8d, 0x72, 0xaf, 0xce, 0x05, 0xa6, 0x1b, 0xbe, 0x9e, 0xde, 0x63,
-	0xc1, 0xcc, 0x75, 0x7e, 0xa7, 0xbb, 0x1c, 0xd5, 0xaf, 0xe5, 0x35, 0x34, 0xc5, 0x9e, 0x91, 0x19,
-	0x83, 0x35, 0xcb, 0x7c, 0xc2, 0x23, 0x78, 0x07, 0xac, 0xc4, 0x4c, 0x4a, 0x12, 0xe5, 0x2a, 0x6f,
-	0x38, 0xb6, 0x95, 0x03, 0x9b, 0x0e, 0xf2, 0x3a, 0xbc, 0x07, 0x6a, 0x21, 0x93, 0x34, 0xe3, 0xa9,
-	0x06, 0x75, 0xe2, 0x6e, 0xba, 0xf6, 0xda, 0xa3, 0xa2, 0x14, 0x94, 0xfb, 0x1a, 0xdf, 0x3d, 0xb0,
-	0xd2, 0x11, 0x62, 0x9f, 0x4b, 0x05, 0x8f, 0xae, 0xb8, 0x0f, 0x5d, 0xcf, 0x7d, 0x7a, 0xda, 0x78,
-	0x6f, 0xc3, 0xf1, 0xad, 0xe6, 0x99, 0x92, 0xf3, 0x06, 0xa0, 0xca, 0x15, 0x8b, 0xf5, 0xde, 0x16,
-	0x77, 0x6a, 0xad, 0x67, 0x73, 0x7f, 0xff, 0xf6, 0x7f, 0x8e, 0xbd, 0xfa, 0x58, 0xf3, 0x04, 0x96,
-	0xae, 0xf1, 0xd5, 0xde, 0x50, 0x5b, 0x11, 0xde, 0x06, 0x55, 0x1e, 0x17, 0xdb, 0x2c, 0x06, 0x74,
-	0x32, 0xb0, 0x35, 0xed, 0x54, 0x6a, 0xd6, 0xef, 0x7e, 0x24, 0xf3, 0x77, 0xaa, 0x7d, 0xdd, 0xc2,
-	0xa9, 0x36, 0x0e, 0x1c, 0x6d, 0xe3, 0x9b, 0x67, 0x3c, 0x60, 0x8d, 0x01, 0x31, 0xa8, 0xa6, 0x3d,
-	0x22, 0x73, 0xcd, 0xb7, 0x72, 0xcd, 0x4f, 0x75, 0xf2, 0x72, 0x54, 0x5f, 0xed, 0x08, 0x61, 0xce,
-	0x81, 0xed, 0x83, 0x9f, 0x3c, 0x00, 0x68, 0xee, 0xae, 0x7c, 0xdd, 0x87, 0xff, 0xe2, 0x12, 0x96,
-	0xa2, 0xf8, 0xc7, 0x99, 0xa6, 0x64, 0x50, 0x52, 0xd0, 0xce, 0xce, 0xc6, 0x7e, 0xe5, 0x7c, 0xec,
-	0x57, 0x2e, 0xc6, 0x7e, 0xe5, 0xe3, 0xc4, 0xf7, 0xce, 0x26, 0xbe, 0x77, 0x3e, 0xf1, 0xbd, 0x8b,
-	0x89, 0xef, 0xfd, 0x98, 0xf8, 0xde, 0xe7, 0x9f, 0x7e, 0xe5, 0x70, 0x7f, 0x9e, 0x5f, 0x9f, 0x5f,
-	0x01, 0x00, 0x00, 0xff, 0xff, 0xf8, 0xa9, 0x5b, 0x05, 0xcc, 0x06, 0x00, 0x00,
+	// 739 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x55, 0xcd, 0x6e, 0xd3, 0x4a,
+	0x14, 0x8e, 0xf3, 0xd3, 0x9f, 0x49, 0xef, 0xbd, 0xd5, 0xdc, 0x4d, 0x6e, 0x16, 0x4e, 0x95, 0x2b,
+	0xa1, 0x82, 0x94, 0x31, 0x8d, 0x0a, 0x62, 0x1d, 0x50, 0x10, 0x28, 0x55, 0xc1, 0x14, 0x21, 0x55,
+	0x15, 0x61, 0x62, 0x4f, 0x9d, 0xa1, 0xb5, 0xc7, 0xf2, 0x4c, 0x8c, 0xb2, 0x82, 0x27, 0x40, 0x6c,
+	0x79, 0x0a, 0x5e, 0xa3, 0xcb, 0x2e, 0xbb, 0x0a, 0x24, 0x3c, 0x00, 0xfb, 0xae, 0xd0, 0xcc, 0xd8,
+	0x71, 0xda, 0x14, 0xe8, 0x22, 0xdd, 0x79, 0xce, 0x39, 0xdf, 0xcf, 0x1c, 0x9f, 0x63, 0x83, 0x03,
+	0x8f, 0x8a, 0xfe, 0xa0, 0x87, 0x1c, 0xe6, 0x5b, 0x87, 0x8c, 0x04, 0x43, 0x62, 0x39, 0xc7, 0x6c,
+	0xe0, 0x36, 0x02, 0x2c, 0x68, 0x4c, 0x1a, 0x82, 0x0d, 0x22, 0xcb, 0x89, 0xdc, 0x86, 0x47, 0x84,
+	0xa0, 0x81, 0xd7, 0xe0, 0x02, 0x47, 0x82, 0xb8, 0x56, 0x78, 0xe4, 0x59, 0x38, 0xa4, 0xdc, 0xf2,
+	0x22, 0x42, 0x64, 0xc6, 0x8a, 0x9b, 0x96, 0x47, 0x02, 0x12, 0x61, 0x41, 0x5c, 0x14, 0x46, 0x4c,
+	0x30, 0xd8, 0xc9, 0xd8, 0x91, 0x66, 0x47, 0x8a, 0xbd, 0xab, 0xd9, 0xbb, 0x92, 0x1d, 0x39, 0x91,
+	0xdb, 0x4d, 0xd8, 0xbb, 0x09, 0x3b, 0x0a, 0x8f, 0x3c, 0x24, 0xd9, 0x51, 0xca, 0x8e, 0xe2, 0x66,
+	0xb5, 0x31, 0xe3, 0xd5, 0x63, 0x1e, 0xb3, 0x94, 0x48, 0x6f, 0x70, 0xa8, 0x4e, 0xea, 0xa0, 0x9e,
+	0xb4, 0x78, 0x75, 0xfb, 0xe8, 0x01, 0x47, 0x94, 0x49, 0x97, 0x3e, 0x76, 0xfa, 0x34, 0x20, 0xd1,
+	0x30, 0xb3, 0xed, 0x13, 0x81, 0xad, 0x78, 0xeb, 0xb2, 0xe5, 0xaa, 0xf5, 0x2b, 0x54, 0x34, 0x08,
+	0x04, 0xf5, 0xc9, 0x1c, 0xe0, 0xfe, 0x9f, 0x00, 0xdc, 0xe9, 0x13, 0x1f, 0x5f, 0xc6, 0xd5, 0xc7,
+	0x79, 0x50, 0x68, 0x33, 0x06, 0xdf, 0x80, 0x15, 0xe9, 0xc5, 0xc5, 0x02, 0x57, 0x8c, 0x0d, 0x63,
+	0xb3, 0xdc, 0xbc, 0x8b, 0x34, 0x25, 0x9a, 0xa5, 0xcc, 0x5a, 0x22, 0xab, 0x51, 0xbc, 0x85, 0x76,
+	0x7b, 0x6f, 0x89, 0x23, 0x76, 0x88, 0xc0, 0x2d, 0x78, 0x32, 0xaa, 0xe5, 0x26, 0xa3, 0x1a, 0xc8,
+	0x62, 0xf6, 0x94, 0x15, 0xbe, 0x03, 0x45, 0x1e, 0x12, 0xa7, 0x92, 0x57, 0xec, 0x2f, 0xd1, 0x22,
+	0x5f, 0x0a, 0x6a, 0x33, 0xf6, 0x22, 0x24, 0x4e, 0x6b, 0x2d, 0xb1, 0x50, 0x94, 0x27, 0x5b, 0x09,
+	0xc2, 0xf7, 0x60, 0x89, 0x0b, 0x2c, 0x06, 0xbc, 0x52, 0x50, 0xd2, 0xaf, 0x16, 0x2f, 0xad, 0xe8,
+	0x5b, 0x7f, 0x27, 0xe2, 0x4b, 0xfa, 0x6c, 0x27, 0xb2, 0xf5, 0x1f, 0x79, 0xb0, 0xd6, 0x66, 0xec,
+	0x21, 0x0b, 0x5c, 0x2a, 0x28, 0x0b, 0xe0, 0x36, 0x28, 0x8a, 0x61, 0x48, 0x54, 0xa3, 0x57, 0x5b,
+	0x1b, 0xa9, 0xe7, 0xbd, 0x61, 0x48, 0xce, 0x47, 0xb5, 0xf5, 0xd9, 0x5a, 0x19, 0xb3, 0x55, 0x35,
+	0x7c, 0x3d, 0xbd, 0x47, 0x5e, 0xe1, 0xda, 0x17, 0xe5, 0xce, 0x47, 0xb5, 0x6b, 0xcd, 0x1a, 0x9a,
+	0x72, 0x5f, 0xb4, 0x09, 0x63, 0x00, 0x8f, 0x31, 0x17, 0x7b, 0x11, 0x0e, 0xb8, 0xd6, 0xa6, 0x3e,
+	0x49, 0x7a, 0x76, 0xe7, 0x7a, 0xc3, 0x20, 0x11, 0xad, 0x6a, 0xe2, 0x0b, 0x76, 0xe6, 0xd8, 0xec,
+	0x2b, 0x14, 0xe0, 0x2d, 0xb0, 0x14, 0x11, 0xcc, 0x59, 0x50, 0x29, 0xaa, 0x7b, 0x4d, 0xdb, 0x68,
+	0xab, 0xa8, 0x9d, 0x64, 0xe1, 0x6d, 0xb0, 0xec, 0x13, 0xce, 0xb1, 0x47, 0x2a, 0x25, 0x55, 0xf8,
+	0x4f, 0x52, 0xb8, 0xbc, 0xa3, 0xc3, 0x76, 0x9a, 0xaf, 0xfb, 0x60, 0x55, 0x37, 0xf1, 0x90, 0x7a,
+	0xb3, 0x38, 0xe3, 0xf7, 0x38, 0x78, 0x0f, 0x94, 0x5d, 0xc2, 0x9d, 0x88, 0x86, 0xd2, 0x5d, 0xd2,
+	0xe7, 0x7f, 0x93, 0xf2, 0xf2, 0xa3, 0x2c, 0x65, 0xcf, 0xd6, 0xd5, 0xbf, 0x1a, 0x60, 0xb9, 0xcd,
+	0x58, 0x87, 0x72, 0x01, 0x0f, 0xe6, 0x16, 0x09, 0x5d, 0xaf, 0x77, 0x12, 0xad, 0xd6, 0x68, 0x3d,
+	0xd1, 0x5b, 0x49, 0x23, 0x33, 0x4b, 0x14, 0x83, 0x12, 0x15, 0xc4, 0x97, 0x23, 0x50, 0xd8, 0x2c,
+	0x37, 0x9f, 0x2f, 0x7c, 0x94, 0x5b, 0x7f, 0x25, 0xea, 0xa5, 0x27, 0x52, 0xc7, 0xd6, 0x72, 0xf5,
+	0x2f, 0xfa, 0x86, 0x72, 0xab, 0xe0, 0xff, 0xa0, 0x44, 0xfd, 0xac, 0x9b, 0x19, 0x40, 0x06, 0x6d,
+	0x9d, 0x93, 0x4b, 0xe7, 0xa8, 0xf6, 0x57, 0xf2, 0x37, 0xb4, 0x74, 0xfa, 0xed, 0x66, 0xd3, 0xa2,
+	0xcf, 0x76, 0x22, 0x5b, 0xff, 0x9c, 0x57, 0x33, 0xa0, 0x67, 0x1c, 0x5a, 0xa0, 0x14, 0xf6, 0x31,
+	0x4f, 0x3d, 0xff, 0x97, 0x7a, 0x7e, 0x26, 0x83, 0xe7, 0xa3, 0xda, 0x4a, 0x9b, 0x31, 0xf5, 0x6c,
+	0xeb, 0x3a, 0xf8, 0xd1, 0x00, 0xc0, 0x49, 0x17, 0x25, 0x6d, 0xf7, 0xfe, 0x4d, 0x5c, 0x42, 0x4b,
+	0x64, 0x1f, 0xcf, 0x69, 0x88, 0xdb, 0x33, 0x0e, 0xe0, 0x53, 0x00, 0x59, 0x8f, 0x93, 0x28, 0x26,
+	0xee, 0x63, 0xfd, 0x0d, 0x97, 0x13, 0x2a, 0xb7, 0xb3, 0x90, 0x6d, 0xdc, 0xee, 0x5c, 0x85, 0x7d,
+	0x05, 0xaa, 0x15, 0x9d, 0x8c, 0xcd, 0xdc, 0xe9, 0xd8, 0xcc, 0x9d, 0x8d, 0xcd, 0xdc, 0x87, 0x89,
+	0x69, 0x9c, 0x4c, 0x4c, 0xe3, 0x74, 0x62, 0x1a, 0x67, 0x13, 0xd3, 0xf8, 0x36, 0x31, 0x8d, 0x4f,
+	0xdf, 0xcd, 0xdc, 0x7e, 0x67, 0x91, 0x3f, 0xe5, 0x9f, 0x03, 0x00, 0x2f, 0xa2, 0x89, 0xe1, 0xe3,
+	0x07, 0x00, 0x00,
 }
 
 func (m *Foo) Marshal() (dAtA []byte, err error) {
@@ -327,6 +333,26 @@ func (m *FooCondition) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	i -= len(m.Message)
+	copy(dAtA[i:], m.Message)
+	i = encodeVarintGenerated(dAtA, i, uint64(len(m.Message)))
+	i--
+	dAtA[i] = 0x2a
+	i -= len(m.Reason)
+	copy(dAtA[i:], m.Reason)
+	i = encodeVarintGenerated(dAtA, i, uint64(len(m.Reason)))
+	i--
+	dAtA[i] = 0x22
+	{
+		size, err := m.LastTransitionTime.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintGenerated(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x1a
 	i -= len(m.Status)
 	copy(dAtA[i:], m.Status)
 	i = encodeVarintGenerated(dAtA, i, uint64(len(m.Status)))
@@ -478,6 +504,9 @@ func (m *FooStatus) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	i = encodeVarintGenerated(dAtA, i, uint64(m.ObservedGeneration))
+	i--
+	dAtA[i] = 0x18
 	if len(m.Conditions) > 0 {
 		for iNdEx := len(m.Conditions) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -536,6 +565,12 @@ func (m *FooCondition) Size() (n int) {
 	n += 1 + l + sovGenerated(uint64(l))
 	l = len(m.Status)
 	n += 1 + l + sovGenerated(uint64(l))
+	l = m.LastTransitionTime.Size()
+	n += 1 + l + sovGenerated(uint64(l))
+	l = len(m.Reason)
+	n += 1 + l + sovGenerated(uint64(l))
+	l = len(m.Message)
+	n += 1 + l + sovGenerated(uint64(l))
 	return n
 }
 
@@ -596,6 +631,7 @@ func (m *FooStatus) Size() (n int) {
 			n += 1 + l + sovGenerated(uint64(l))
 		}
 	}
+	n += 1 + sovGenerated(uint64(m.ObservedGeneration))
 	return n
 }
 
@@ -624,6 +660,9 @@ func (this *FooCondition) String() string {
 	s := strings.Join([]string{`&FooCondition{`,
 		`Type:` + fmt.Sprintf("%v", this.Type) + `,`,
 		`Status:` + fmt.Sprintf("%v", this.Status) + `,`,
+		`LastTransitionTime:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.LastTransitionTime), "Time", "v1.Time", 1), `&`, ``, 1) + `,`,
+		`Reason:` + fmt.Sprintf("%v", this.Reason) + `,`,
+		`Message:` + fmt.Sprintf("%v", this.Message) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -678,6 +717,7 @@ func (this *FooStatus) String() string {
 	s := strings.Join([]string{`&FooStatus{`,
 		`Phase:` + fmt.Sprintf("%v", this.Phase) + `,`,
 		`Conditions:` + repeatedStringForConditions + `,`,
+		`ObservedGeneration:` + fmt.Sprintf("%v", this.ObservedGeneration) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -932,6 +972,103 @@ func (m *FooCondition) Unmarshal(dAtA []byte) error {
 			}
 			m.Status = k8s_io_apimachinery_pkg_apis_meta_v1.ConditionStatus(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LastTransitionTime", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGenerated
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthGenerated
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthGenerated
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.LastTransitionTime.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Reason", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGenerated
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthGenerated
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthGenerated
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Reason = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Message", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGenerated
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthGenerated
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthGenerated
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Message = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipGenerated(dAtA[iNdEx:])
@@ -1394,6 +1531,25 @@ func (m *FooStatus) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ObservedGeneration", wireType)
+			}
+			m.ObservedGeneration = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGenerated
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ObservedGeneration |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipGenerated(dAtA[iNdEx:])
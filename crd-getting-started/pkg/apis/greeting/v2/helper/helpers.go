@@ -0,0 +1,57 @@
+package helper
+
+import (
+	greetingv2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NewFooCondition returns a condition of the given type and status whose
+// LastTransitionTime is now.
+func NewFooCondition(conditionType greetingv2.FooConditionType, status metav1.ConditionStatus, reason, message string) greetingv2.FooCondition {
+	return greetingv2.FooCondition{
+		Type:               conditionType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// SetFooCondition sets the condition of foo, overwriting the existing one of
+// the same type or appending a new one. LastTransitionTime is only updated when
+// the status of the condition changes, and defaults to now when unset.
+func SetFooCondition(foo *greetingv2.Foo, newCondition greetingv2.FooCondition) {
+	if newCondition.LastTransitionTime.IsZero() {
+		newCondition.LastTransitionTime = metav1.Now()
+	}
+
+	existingCondition := GetFooConditionByType(foo, newCondition.Type)
+	if existingCondition == nil {
+		foo.Status.Conditions = append(foo.Status.Conditions, newCondition)
+		return
+	}
+	if existingCondition.Status != newCondition.Status {
+		existingCondition.Status = newCondition.Status
+		existingCondition.LastTransitionTime = newCondition.LastTransitionTime
+	}
+	existingCondition.Reason = newCondition.Reason
+	existingCondition.Message = newCondition.Message
+}
+
+// GetFooConditionByType returns the condition of foo with the given type, or
+// nil if it is not present.
+func GetFooConditionByType(foo *greetingv2.Foo, conditionType greetingv2.FooConditionType) *greetingv2.FooCondition {
+	for i := range foo.Status.Conditions {
+		if foo.Status.Conditions[i].Type == conditionType {
+			return &foo.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsFooConditionTrue indicates if the condition of the given type is present
+// and strictly true.
+func IsFooConditionTrue(foo *greetingv2.Foo, conditionType greetingv2.FooConditionType) bool {
+	condition := GetFooConditionByType(foo, conditionType)
+	return condition != nil && condition.Status == metav1.ConditionTrue
+}
@@ -0,0 +1,98 @@
+package helper
+
+import (
+	"testing"
+	"time"
+
+	greetingv2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	earlier = metav1.NewTime(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC))
+	later   = metav1.NewTime(time.Date(2025, time.January, 2, 0, 0, 0, 0, time.UTC))
+)
+
+func fooWithConditions(conditions ...greetingv2.FooCondition) *greetingv2.Foo {
+	return &greetingv2.Foo{Status: greetingv2.FooStatus{Conditions: conditions}}
+}
+
+func TestSetFooCondition(t *testing.T) {
+	testCases := map[string]struct {
+		foo       *greetingv2.Foo
+		condition greetingv2.FooCondition
+		expected  []greetingv2.FooCondition
+	}{
+		"appends missing condition": {
+			foo: fooWithConditions(
+				greetingv2.FooCondition{Type: greetingv2.FooConditionTypeConfig, Status: metav1.ConditionTrue, LastTransitionTime: earlier},
+			),
+			condition: greetingv2.FooCondition{Type: greetingv2.FooConditionTypeWorker, Status: metav1.ConditionFalse, LastTransitionTime: later, Reason: "Progressing"},
+			expected: []greetingv2.FooCondition{
+				{Type: greetingv2.FooConditionTypeConfig, Status: metav1.ConditionTrue, LastTransitionTime: earlier},
+				{Type: greetingv2.FooConditionTypeWorker, Status: metav1.ConditionFalse, LastTransitionTime: later, Reason: "Progressing"},
+			},
+		},
+		"bumps transition time on status change": {
+			foo: fooWithConditions(
+				greetingv2.FooCondition{Type: greetingv2.FooConditionTypeWorker, Status: metav1.ConditionFalse, LastTransitionTime: earlier, Reason: "Progressing"},
+			),
+			condition: greetingv2.FooCondition{Type: greetingv2.FooConditionTypeWorker, Status: metav1.ConditionTrue, LastTransitionTime: later, Reason: "Available", Message: "ready"},
+			expected: []greetingv2.FooCondition{
+				{Type: greetingv2.FooConditionTypeWorker, Status: metav1.ConditionTrue, LastTransitionTime: later, Reason: "Available", Message: "ready"},
+			},
+		},
+		"keeps transition time when status is unchanged": {
+			foo: fooWithConditions(
+				greetingv2.FooCondition{Type: greetingv2.FooConditionTypeWorker, Status: metav1.ConditionFalse, LastTransitionTime: earlier, Reason: "Missing"},
+			),
+			condition: greetingv2.FooCondition{Type: greetingv2.FooConditionTypeWorker, Status: metav1.ConditionFalse, LastTransitionTime: later, Reason: "Progressing", Message: "0/1"},
+			expected: []greetingv2.FooCondition{
+				{Type: greetingv2.FooConditionTypeWorker, Status: metav1.ConditionFalse, LastTransitionTime: earlier, Reason: "Progressing", Message: "0/1"},
+			},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			SetFooCondition(tc.foo, tc.condition)
+			if len(tc.foo.Status.Conditions) != len(tc.expected) {
+				t.Fatalf("expected %d conditions, got %#v", len(tc.expected), tc.foo.Status.Conditions)
+			}
+			for i := range tc.expected {
+				actual := tc.foo.Status.Conditions[i]
+				if actual.Type != tc.expected[i].Type || actual.Status != tc.expected[i].Status ||
+					!actual.LastTransitionTime.Equal(&tc.expected[i].LastTransitionTime) ||
+					actual.Reason != tc.expected[i].Reason || actual.Message != tc.expected[i].Message {
+					t.Errorf("conditions[%d]: expected %#v, got %#v", i, tc.expected[i], actual)
+				}
+			}
+		})
+	}
+}
+
+func TestSetFooConditionDefaultsTransitionTime(t *testing.T) {
+	foo := fooWithConditions()
+	SetFooCondition(foo, greetingv2.FooCondition{Type: greetingv2.FooConditionTypeConfig, Status: metav1.ConditionTrue})
+	if foo.Status.Conditions[0].LastTransitionTime.IsZero() {
+		t.Error("expected LastTransitionTime to be set")
+	}
+}
+
+func TestIsFooConditionTrue(t *testing.T) {
+	foo := fooWithConditions(
+		greetingv2.FooCondition{Type: greetingv2.FooConditionTypeConfig, Status: metav1.ConditionTrue},
+		greetingv2.FooCondition{Type: greetingv2.FooConditionTypeWorker, Status: metav1.ConditionUnknown},
+	)
+	if !IsFooConditionTrue(foo, greetingv2.FooConditionTypeConfig) {
+		t.Error("expected Config to be true")
+	}
+	if IsFooConditionTrue(foo, greetingv2.FooConditionTypeWorker) {
+		t.Error("expected Worker not to be true")
+	}
+	if IsFooConditionTrue(fooWithConditions(), greetingv2.FooConditionTypeWorker) {
+		t.Error("expected missing condition not to be true")
+	}
+	if GetFooConditionByType(foo, greetingv2.FooConditionTypeWorker) != &foo.Status.Conditions[1] {
+		t.Error("expected the existing condition to be returned")
+	}
+}
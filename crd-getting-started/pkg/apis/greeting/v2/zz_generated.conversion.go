@@ -119,6 +119,9 @@ func Convert_greeting_Foo_To_v2_Foo(in *greeting.Foo, out *Foo, s conversion.Sco
 func autoConvert_v2_FooCondition_To_greeting_FooCondition(in *FooCondition, out *greeting.FooCondition, s conversion.Scope) error {
 	out.Type = greeting.FooConditionType(in.Type)
 	out.Status = v1.ConditionStatus(in.Status)
+	out.LastTransitionTime = in.LastTransitionTime
+	out.Reason = in.Reason
+	out.Message = in.Message
 	return nil
 }
 
@@ -130,6 +133,9 @@ func Convert_v2_FooCondition_To_greeting_FooCondition(in *FooCondition, out *gre
 func autoConvert_greeting_FooCondition_To_v2_FooCondition(in *greeting.FooCondition, out *FooCondition, s conversion.Scope) error {
 	out.Type = FooConditionType(in.Type)
 	out.Status = v1.ConditionStatus(in.Status)
+	out.LastTransitionTime = in.LastTransitionTime
+	out.Reason = in.Reason
+	out.Message = in.Message
 	return nil
 }
 
@@ -211,6 +217,7 @@ func Convert_greeting_FooSpec_To_v2_FooSpec(in *greeting.FooSpec, out *FooSpec,
 func autoConvert_v2_FooStatus_To_greeting_FooStatus(in *FooStatus, out *greeting.FooStatus, s conversion.Scope) error {
 	out.Phase = greeting.FooPhase(in.Phase)
 	out.Conditions = *(*[]greeting.FooCondition)(unsafe.Pointer(&in.Conditions))
+	out.ObservedGeneration = in.ObservedGeneration
 	return nil
 }
 
@@ -222,6 +229,7 @@ func Convert_v2_FooStatus_To_greeting_FooStatus(in *FooStatus, out *greeting.Foo
 func autoConvert_greeting_FooStatus_To_v2_FooStatus(in *greeting.FooStatus, out *FooStatus, s conversion.Scope) error {
 	out.Phase = FooPhase(in.Phase)
 	out.Conditions = *(*[]FooCondition)(unsafe.Pointer(&in.Conditions))
+	out.ObservedGeneration = in.ObservedGeneration
 	return nil
 }
 
@@ -0,0 +1,107 @@
+package validation
+
+import (
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// MaxMessageLength is the max length of FooConfig.Message, kept in line with the CRD schema.
+const MaxMessageLength = 15
+
+// imageReferenceRegexp matches a container image reference: [registry[:port]/]repository[:tag][@digest].
+var imageReferenceRegexp = regexp.MustCompile(`^` +
+	`(?:(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?/)?` +
+	`[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*` +
+	`(?::[\w][\w.-]{0,127})?` +
+	`(?:@[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,})?` +
+	`$`)
+
+var (
+	supportedConditionTypes    = sets.New(greeting.FooConditionTypeWorker, greeting.FooConditionTypeConfig)
+	supportedConditionStatuses = sets.New(metav1.ConditionTrue, metav1.ConditionFalse, metav1.ConditionUnknown)
+	supportedPhases            = sets.New(greeting.FooPhaseProcessing, greeting.FooPhaseReady)
+)
+
+// ValidateFoo validates a Foo on creation.
+func ValidateFoo(foo *greeting.Foo) field.ErrorList {
+	allErrs := apivalidation.ValidateObjectMeta(&foo.ObjectMeta, true, apivalidation.NameIsDNSSubdomain, field.NewPath("metadata"))
+	allErrs = append(allErrs, ValidateFooSpec(&foo.Spec, field.NewPath("spec"))...)
+	return allErrs
+}
+
+// ValidateFooUpdate validates an update of a Foo, status is ignored.
+func ValidateFooUpdate(newFoo, oldFoo *greeting.Foo) field.ErrorList {
+	allErrs := apivalidation.ValidateObjectMetaUpdate(&newFoo.ObjectMeta, &oldFoo.ObjectMeta, field.NewPath("metadata"))
+	allErrs = append(allErrs, ValidateFooSpec(&newFoo.Spec, field.NewPath("spec"))...)
+	return allErrs
+}
+
+// ValidateFooStatusUpdate validates an update of the status of a Foo.
+func ValidateFooStatusUpdate(newFoo, oldFoo *greeting.Foo) field.ErrorList {
+	allErrs := apivalidation.ValidateObjectMetaUpdate(&newFoo.ObjectMeta, &oldFoo.ObjectMeta, field.NewPath("metadata"))
+	allErrs = append(allErrs, ValidateFooStatus(&newFoo.Status, field.NewPath("status"))...)
+	return allErrs
+}
+
+// ValidateFooSpec validates the image and the config of a FooSpec.
+func ValidateFooSpec(spec *greeting.FooSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	imagePath := fldPath.Child("image")
+	if len(spec.Image) == 0 {
+		allErrs = append(allErrs, field.Required(imagePath, ""))
+	} else if !imageReferenceRegexp.MatchString(spec.Image) {
+		allErrs = append(allErrs, field.Invalid(imagePath, spec.Image, "must be a valid image reference"))
+	}
+
+	allErrs = append(allErrs, ValidateFooConfig(&spec.Config, fldPath.Child("config"))...)
+	return allErrs
+}
+
+// ValidateFooConfig validates a FooConfig, message is required and length-bounded.
+func ValidateFooConfig(config *greeting.FooConfig, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	messagePath := fldPath.Child("message")
+	if len(config.Message) == 0 {
+		allErrs = append(allErrs, field.Required(messagePath, ""))
+	} else if utf8.RuneCountInString(config.Message) > MaxMessageLength {
+		allErrs = append(allErrs, field.TooLong(messagePath, config.Message, MaxMessageLength))
+	}
+
+	return allErrs
+}
+
+// ValidateFooStatus validates the phase and the conditions of a FooStatus.
+func ValidateFooStatus(status *greeting.FooStatus, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if len(status.Phase) > 0 && !supportedPhases.Has(status.Phase) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("phase"), status.Phase, sets.List(supportedPhases)))
+	}
+
+	seen := sets.New[greeting.FooConditionType]()
+	for i, condition := range status.Conditions {
+		idxPath := fldPath.Child("conditions").Index(i)
+		if !supportedConditionTypes.Has(condition.Type) {
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("type"), condition.Type, sets.List(supportedConditionTypes)))
+		} else if seen.Has(condition.Type) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("type"), condition.Type))
+		}
+		seen.Insert(condition.Type)
+
+		if !supportedConditionStatuses.Has(condition.Status) {
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("status"), condition.Status, sets.List(supportedConditionStatuses)))
+		}
+	}
+
+	allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(status.ObservedGeneration, fldPath.Child("observedGeneration"))...)
+
+	return allErrs
+}
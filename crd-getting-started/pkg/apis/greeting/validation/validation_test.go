@@ -0,0 +1,164 @@
+package validation
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+func validFoo() *greeting.Foo {
+	return &greeting.Foo{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default", ResourceVersion: "1"},
+		Spec: greeting.FooSpec{
+			Image:  "busybox:1.36",
+			Config: greeting.FooConfig{Message: "hello world"},
+		},
+	}
+}
+
+func TestValidateFoo(t *testing.T) {
+	testCases := map[string]struct {
+		mutate func(foo *greeting.Foo)
+		errs   field.ErrorList
+	}{
+		"valid": {
+			mutate: func(_ *greeting.Foo) {},
+		},
+		"valid image with registry and digest": {
+			mutate: func(foo *greeting.Foo) {
+				foo.Spec.Image = "registry.local:5000/library/busybox@sha256:" + strings.Repeat("a", 64)
+			},
+		},
+		"missing image": {
+			mutate: func(foo *greeting.Foo) { foo.Spec.Image = "" },
+			errs:   field.ErrorList{field.Required(field.NewPath("spec", "image"), "")},
+		},
+		"invalid image": {
+			mutate: func(foo *greeting.Foo) { foo.Spec.Image = "Busybox:latest" },
+			errs:   field.ErrorList{field.Invalid(field.NewPath("spec", "image"), "Busybox:latest", "")},
+		},
+		"missing message": {
+			mutate: func(foo *greeting.Foo) { foo.Spec.Config.Message = "" },
+			errs:   field.ErrorList{field.Required(field.NewPath("spec", "config", "message"), "")},
+		},
+		"multi-byte message within the limit": {
+			mutate: func(foo *greeting.Foo) { foo.Spec.Config.Message = strings.Repeat("你好", 7) },
+		},
+		"multi-byte message too long": {
+			mutate: func(foo *greeting.Foo) { foo.Spec.Config.Message = strings.Repeat("你好", 8) },
+			errs:   field.ErrorList{field.TooLong(field.NewPath("spec", "config", "message"), "", MaxMessageLength)},
+		},
+		"message too long": {
+			mutate: func(foo *greeting.Foo) { foo.Spec.Config.Message = "hello world, hello world" },
+			errs:   field.ErrorList{field.TooLong(field.NewPath("spec", "config", "message"), "", MaxMessageLength)},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			foo := validFoo()
+			tc.mutate(foo)
+			assertErrors(t, tc.errs, ValidateFoo(foo))
+		})
+	}
+}
+
+// TestCRDImagePattern keeps the spec.image pattern of the CRD, enforced when
+// Foo is served by the kube-apiserver, in line with imageReferenceRegexp.
+func TestCRDImagePattern(t *testing.T) {
+	data, err := os.ReadFile("../../../../config/crd/bases/greeting.foen.ye_foos.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := yaml.Unmarshal(data, crd); err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range crd.Spec.Versions {
+		spec, ok := version.Schema.OpenAPIV3Schema.Properties["spec"]
+		if !ok {
+			t.Fatalf("%s: expected a spec schema", version.Name)
+		}
+		image, ok := spec.Properties["image"]
+		if !ok {
+			// v1 keeps the image in an annotation.
+			continue
+		}
+		if image.Pattern != imageReferenceRegexp.String() {
+			t.Errorf("%s: expected spec.image pattern %s, got %s", version.Name, imageReferenceRegexp, image.Pattern)
+		}
+	}
+}
+
+func TestValidateFooStatusUpdate(t *testing.T) {
+	testCases := map[string]struct {
+		status greeting.FooStatus
+		errs   field.ErrorList
+	}{
+		"valid": {
+			status: greeting.FooStatus{
+				Phase: greeting.FooPhaseReady,
+				Conditions: []greeting.FooCondition{
+					{Type: greeting.FooConditionTypeWorker, Status: metav1.ConditionTrue},
+					{Type: greeting.FooConditionTypeConfig, Status: metav1.ConditionTrue},
+				},
+			},
+		},
+		"unknown phase": {
+			status: greeting.FooStatus{Phase: "Done"},
+			errs:   field.ErrorList{field.NotSupported(field.NewPath("status", "phase"), "Done", []string{})},
+		},
+		"unknown condition type": {
+			status: greeting.FooStatus{Conditions: []greeting.FooCondition{{Type: "Ready", Status: metav1.ConditionTrue}}},
+			errs: field.ErrorList{
+				field.NotSupported(field.NewPath("status", "conditions").Index(0).Child("type"), "Ready", []string{}),
+			},
+		},
+		"duplicate condition type": {
+			status: greeting.FooStatus{Conditions: []greeting.FooCondition{
+				{Type: greeting.FooConditionTypeWorker, Status: metav1.ConditionTrue},
+				{Type: greeting.FooConditionTypeWorker, Status: metav1.ConditionFalse},
+			}},
+			errs: field.ErrorList{
+				field.Duplicate(field.NewPath("status", "conditions").Index(1).Child("type"), greeting.FooConditionTypeWorker),
+			},
+		},
+		"invalid condition status": {
+			status: greeting.FooStatus{Conditions: []greeting.FooCondition{{Type: greeting.FooConditionTypeConfig, Status: "Maybe"}}},
+			errs: field.ErrorList{
+				field.NotSupported(field.NewPath("status", "conditions").Index(0).Child("status"), "Maybe", []string{}),
+			},
+		},
+		"negative observed generation": {
+			status: greeting.FooStatus{ObservedGeneration: -1},
+			errs:   field.ErrorList{field.Invalid(field.NewPath("status", "observedGeneration"), int64(-1), "")},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			oldFoo := validFoo()
+			newFoo := validFoo()
+			newFoo.Status = tc.status
+			assertErrors(t, tc.errs, ValidateFooStatusUpdate(newFoo, oldFoo))
+		})
+	}
+}
+
+func assertErrors(t *testing.T, expected, actual field.ErrorList) {
+	t.Helper()
+	if len(expected) != len(actual) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expected), len(actual), actual)
+	}
+	for i := range expected {
+		if expected[i].Type != actual[i].Type || expected[i].Field != actual[i].Field {
+			t.Errorf("expected error %q at %q, got %v", expected[i].Type, expected[i].Field, actual[i])
+		}
+	}
+}
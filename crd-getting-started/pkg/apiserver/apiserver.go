@@ -0,0 +1,113 @@
+package apiserver
+
+import (
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting"
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/install"
+	greetingv1 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v1"
+	greetingv2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v2"
+	fooregistry "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/registry/greeting/foo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apiserver/pkg/registry/rest"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+)
+
+var (
+	// Scheme defines methods for serializing and deserializing API objects.
+	Scheme = runtime.NewScheme()
+	// Codecs provides methods for retrieving codecs and serializers for specific
+	// versions and content types.
+	Codecs = serializer.NewCodecFactory(Scheme)
+)
+
+func init() {
+	install.Install(Scheme)
+
+	// we need to add the options to empty v1
+	// TODO fix the server code to avoid this
+	metav1.AddToGroupVersion(Scheme, schema.GroupVersion{Version: "v1"})
+
+	// TODO: keep the generic API server from wanting this
+	unversioned := schema.GroupVersion{Group: "", Version: "v1"}
+	Scheme.AddUnversionedTypes(unversioned,
+		&metav1.Status{},
+		&metav1.APIVersions{},
+		&metav1.APIGroupList{},
+		&metav1.APIGroup{},
+		&metav1.APIResourceList{},
+	)
+}
+
+// ExtraConfig holds custom apiserver config
+type ExtraConfig struct {
+}
+
+// Config defines the config for the apiserver
+type Config struct {
+	GenericConfig *genericapiserver.RecommendedConfig
+	ExtraConfig   ExtraConfig
+}
+
+// GreetingServer contains state for a Kubernetes cluster master/api server.
+type GreetingServer struct {
+	GenericAPIServer *genericapiserver.GenericAPIServer
+}
+
+type completedConfig struct {
+	GenericConfig genericapiserver.CompletedConfig
+	ExtraConfig   *ExtraConfig
+}
+
+// CompletedConfig embeds a private pointer that cannot be instantiated outside of this package.
+type CompletedConfig struct {
+	*completedConfig
+}
+
+// Complete fills in any fields not set that are required to have valid data. It's mutating the receiver.
+func (cfg *Config) Complete() CompletedConfig {
+	c := completedConfig{
+		cfg.GenericConfig.Complete(),
+		&cfg.ExtraConfig,
+	}
+
+	return CompletedConfig{&c}
+}
+
+// New returns a new instance of GreetingServer from the given config.
+// greeting.foen.ye is served at both v1 and v2, v2 is the preferred (and storage) version.
+// v1 has no status, so foos/status is only served at v2.
+func (c completedConfig) New() (*GreetingServer, error) {
+	genericServer, err := c.GenericConfig.New("greeting-apiserver", genericapiserver.NewEmptyDelegate())
+	if err != nil {
+		return nil, err
+	}
+
+	s := &GreetingServer{
+		GenericAPIServer: genericServer,
+	}
+
+	apiGroupInfo := genericapiserver.NewDefaultAPIGroupInfo(greeting.GroupName, Scheme, metav1.ParameterCodec, Codecs)
+
+	fooStorage, err := fooregistry.NewREST(Scheme, c.GenericConfig.RESTOptionsGetter)
+	if err != nil {
+		return nil, err
+	}
+
+	fooStatusStorage := fooregistry.NewStatusREST(Scheme, fooStorage)
+
+	apiGroupInfo.VersionedResourcesStorageMap[greetingv2.SchemeGroupVersion.Version] = map[string]rest.Storage{
+		"foos":        fooStorage,
+		"foos/status": fooStatusStorage,
+	}
+	apiGroupInfo.VersionedResourcesStorageMap[greetingv1.SchemeGroupVersion.Version] = map[string]rest.Storage{
+		"foos": fooStorage,
+	}
+
+	if err := s.GenericAPIServer.InstallAPIGroup(&apiGroupInfo); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
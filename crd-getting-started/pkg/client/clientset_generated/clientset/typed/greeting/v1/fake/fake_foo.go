@@ -4,19 +4,20 @@ package fake
 
 import (
 	v1 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v1"
-	greetingv1 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/clientset_generated/clientset/typed/greeting/v1"
+	greetingv1 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/applyconfiguration/greeting/v1"
+	typedgreetingv1 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/clientset_generated/clientset/typed/greeting/v1"
 	gentype "k8s.io/client-go/gentype"
 )
 
 // fakeFoos implements FooInterface
 type fakeFoos struct {
-	*gentype.FakeClientWithList[*v1.Foo, *v1.FooList]
+	*gentype.FakeClientWithListAndApply[*v1.Foo, *v1.FooList, *greetingv1.FooApplyConfiguration]
 	Fake *FakeGreetingV1
 }
 
-func newFakeFoos(fake *FakeGreetingV1, namespace string) greetingv1.FooInterface {
+func newFakeFoos(fake *FakeGreetingV1, namespace string) typedgreetingv1.FooInterface {
 	return &fakeFoos{
-		gentype.NewFakeClientWithList[*v1.Foo, *v1.FooList](
+		gentype.NewFakeClientWithListAndApply[*v1.Foo, *v1.FooList, *greetingv1.FooApplyConfiguration](
 			fake.Fake,
 			namespace,
 			v1.SchemeGroupVersion.WithResource("foos"),
@@ -6,6 +6,7 @@ import (
 	context "context"
 
 	greetingv1 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v1"
+	applyconfigurationgreetingv1 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/applyconfiguration/greeting/v1"
 	scheme "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/clientset_generated/clientset/scheme"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	types "k8s.io/apimachinery/pkg/types"
@@ -29,18 +30,19 @@ type FooInterface interface {
 	List(ctx context.Context, opts metav1.ListOptions) (*greetingv1.FooList, error)
 	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
 	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *greetingv1.Foo, err error)
+	Apply(ctx context.Context, foo *applyconfigurationgreetingv1.FooApplyConfiguration, opts metav1.ApplyOptions) (result *greetingv1.Foo, err error)
 	FooExpansion
 }
 
 // foos implements FooInterface
 type foos struct {
-	*gentype.ClientWithList[*greetingv1.Foo, *greetingv1.FooList]
+	*gentype.ClientWithListAndApply[*greetingv1.Foo, *greetingv1.FooList, *applyconfigurationgreetingv1.FooApplyConfiguration]
 }
 
 // newFoos returns a Foos
 func newFoos(c *GreetingV1Client, namespace string) *foos {
 	return &foos{
-		gentype.NewClientWithList[*greetingv1.Foo, *greetingv1.FooList](
+		gentype.NewClientWithListAndApply[*greetingv1.Foo, *greetingv1.FooList, *applyconfigurationgreetingv1.FooApplyConfiguration](
 			"foos",
 			c.RESTClient(),
 			scheme.ParameterCodec,
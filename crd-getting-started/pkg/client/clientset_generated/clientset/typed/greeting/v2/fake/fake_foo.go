@@ -4,19 +4,20 @@ package fake
 
 import (
 	v2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v2"
-	greetingv2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/clientset_generated/clientset/typed/greeting/v2"
+	greetingv2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/applyconfiguration/greeting/v2"
+	typedgreetingv2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/clientset_generated/clientset/typed/greeting/v2"
 	gentype "k8s.io/client-go/gentype"
 )
 
 // fakeFoos implements FooInterface
 type fakeFoos struct {
-	*gentype.FakeClientWithList[*v2.Foo, *v2.FooList]
+	*gentype.FakeClientWithListAndApply[*v2.Foo, *v2.FooList, *greetingv2.FooApplyConfiguration]
 	Fake *FakeGreetingV2
 }
 
-func newFakeFoos(fake *FakeGreetingV2, namespace string) greetingv2.FooInterface {
+func newFakeFoos(fake *FakeGreetingV2, namespace string) typedgreetingv2.FooInterface {
 	return &fakeFoos{
-		gentype.NewFakeClientWithList[*v2.Foo, *v2.FooList](
+		gentype.NewFakeClientWithListAndApply[*v2.Foo, *v2.FooList, *greetingv2.FooApplyConfiguration](
 			fake.Fake,
 			namespace,
 			v2.SchemeGroupVersion.WithResource("foos"),
@@ -6,6 +6,7 @@ import (
 	context "context"
 
 	greetingv2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v2"
+	applyconfigurationgreetingv2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/applyconfiguration/greeting/v2"
 	scheme "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/clientset_generated/clientset/scheme"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	types "k8s.io/apimachinery/pkg/types"
@@ -31,18 +32,21 @@ type FooInterface interface {
 	List(ctx context.Context, opts v1.ListOptions) (*greetingv2.FooList, error)
 	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
 	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *greetingv2.Foo, err error)
+	Apply(ctx context.Context, foo *applyconfigurationgreetingv2.FooApplyConfiguration, opts v1.ApplyOptions) (result *greetingv2.Foo, err error)
+	// Add a +genclient:noStatus comment above the type to avoid generating ApplyStatus().
+	ApplyStatus(ctx context.Context, foo *applyconfigurationgreetingv2.FooApplyConfiguration, opts v1.ApplyOptions) (result *greetingv2.Foo, err error)
 	FooExpansion
 }
 
 // foos implements FooInterface
 type foos struct {
-	*gentype.ClientWithList[*greetingv2.Foo, *greetingv2.FooList]
+	*gentype.ClientWithListAndApply[*greetingv2.Foo, *greetingv2.FooList, *applyconfigurationgreetingv2.FooApplyConfiguration]
 }
 
 // newFoos returns a Foos
 func newFoos(c *GreetingV2Client, namespace string) *foos {
 	return &foos{
-		gentype.NewClientWithList[*greetingv2.Foo, *greetingv2.FooList](
+		gentype.NewClientWithListAndApply[*greetingv2.Foo, *greetingv2.FooList, *applyconfigurationgreetingv2.FooApplyConfiguration](
 			"foos",
 			c.RESTClient(),
 			scheme.ParameterCodec,
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// freePort returns a local TCP port that is free at the time of the call.
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// startEtcd runs the etcd binary found on PATH as a throwaway single member
+// cluster and returns its client URL. The test is skipped without one.
+func startEtcd(t *testing.T) string {
+	t.Helper()
+	etcdPath, err := exec.LookPath("etcd")
+	if err != nil {
+		t.Skip("etcd not found on PATH, see https://etcd.io/docs/latest/install/")
+	}
+	clientURL := fmt.Sprintf("http://127.0.0.1:%d", freePort(t))
+	peerURL := fmt.Sprintf("http://127.0.0.1:%d", freePort(t))
+	cmd := exec.Command(etcdPath,
+		"--data-dir", t.TempDir(),
+		"--listen-client-urls", clientURL,
+		"--advertise-client-urls", clientURL,
+		"--listen-peer-urls", peerURL,
+		"--initial-advertise-peer-urls", peerURL,
+		"--initial-cluster", "default="+peerURL,
+		"--unsafe-no-fsync",
+	)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	return clientURL
+}
+
+// TestStandaloneServer runs a standalone greeting API server against a local
+// etcd and checks the greeting.foen.ye versions it serves.
+func TestStandaloneServer(t *testing.T) {
+	etcdURL := startEtcd(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewGreetingServerOptions(os.Stdout, os.Stderr)
+	o.Standalone = true
+	o.RecommendedOptions.Etcd.StorageConfig.Transport.ServerList = []string{etcdURL}
+	o.RecommendedOptions.SecureServing.Listener = listener
+	o.RecommendedOptions.SecureServing.BindPort = listener.Addr().(*net.TCPAddr).Port
+	o.RecommendedOptions.SecureServing.ServerCert.CertDirectory = t.TempDir()
+	if err := o.Complete(); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Validate(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() { stopped <- o.RunGreetingServer(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-stopped; err != nil {
+			t.Errorf("unexpected error running server: %v", err)
+		}
+	})
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	baseURL := "https://" + listener.Addr().String()
+	get := func(path string, into any) error {
+		resp, err := client.Get(baseURL + path)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("GET %s: %s: %s", path, resp.Status, body)
+		}
+		if into == nil {
+			return nil
+		}
+		return json.Unmarshal(body, into)
+	}
+
+	if err := wait.PollUntilContextTimeout(ctx, 100*time.Millisecond, 30*time.Second, true,
+		func(context.Context) (bool, error) {
+			return get("/readyz", nil) == nil, nil
+		}); err != nil {
+		t.Fatalf("server never became ready: %v", err)
+	}
+
+	group := &metav1.APIGroup{}
+	if err := get("/apis/"+greeting.GroupName, group); err != nil {
+		t.Fatal(err)
+	}
+	var versions []string
+	for _, version := range group.Versions {
+		versions = append(versions, version.Version)
+	}
+	if len(versions) != 2 || versions[0] != "v2" || versions[1] != "v1" {
+		t.Errorf("expected versions [v2 v1], got %v", versions)
+	}
+	if group.PreferredVersion.Version != "v2" {
+		t.Errorf("expected preferred version v2, got %q", group.PreferredVersion.Version)
+	}
+
+	for version, expected := range map[string][]string{
+		"v1": {"foos"},
+		"v2": {"foos", "foos/status"},
+	} {
+		resources := &metav1.APIResourceList{}
+		if err := get("/apis/"+greeting.GroupName+"/"+version, resources); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, resource := range resources.APIResources {
+			names = append(names, resource.Name)
+		}
+		if fmt.Sprint(names) != fmt.Sprint(expected) {
+			t.Errorf("expected %s resources %v, got %v", version, expected, names)
+		}
+	}
+
+	if err := get("/apis/"+greeting.GroupName+"/v2/namespaces/default/foos", nil); err != nil {
+		t.Errorf("unexpected error listing foos: %v", err)
+	}
+}
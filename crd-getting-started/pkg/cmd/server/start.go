@@ -0,0 +1,222 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/admission/plugin/defaultimage"
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/admission/plugin/imagepolicy"
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting"
+	greetingv2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v2"
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apiserver"
+	generatedopenapi "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/generated/openapi"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	apiserverapi "k8s.io/apiserver/pkg/apis/apiserver"
+	apiserverapiv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
+	"k8s.io/apiserver/pkg/authorization/authorizerfactory"
+	"k8s.io/apiserver/pkg/endpoints/openapi"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	genericoptions "k8s.io/apiserver/pkg/server/options"
+	utilcompatibility "k8s.io/apiserver/pkg/util/compatibility"
+	netutils "k8s.io/utils/net"
+)
+
+const defaultEtcdPathPrefix = "/registry/greeting.foen.ye"
+
+// greetingAdmissionPlugins are the admission plugins of greeting.foen.ye. They
+// run after the generic ones and are the only ones enabled in standalone mode.
+var greetingAdmissionPlugins = []string{defaultimage.PluginName, imagepolicy.PluginName}
+
+// admissionConfigScheme decodes the admission config file in standalone mode.
+var admissionConfigScheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(apiserverapi.AddToScheme(admissionConfigScheme))
+	utilruntime.Must(apiserverapiv1.AddToScheme(admissionConfigScheme))
+}
+
+// GreetingServerOptions contains state for master/api server
+type GreetingServerOptions struct {
+	RecommendedOptions *genericoptions.RecommendedOptions
+
+	// Standalone runs the server without a backing kube-apiserver: no delegated
+	// authentication/authorization, no core API informers and only the greeting
+	// admission plugins. Meant for local development against a local etcd.
+	Standalone bool
+	// standaloneAdmission keeps the admission options of a standalone server,
+	// whose generic admission chain requires a kube-apiserver.
+	standaloneAdmission *genericoptions.AdmissionOptions
+
+	StdOut io.Writer
+	StdErr io.Writer
+
+	AlternateDNS []string
+}
+
+// NewGreetingServerOptions returns a new GreetingServerOptions
+func NewGreetingServerOptions(out, errOut io.Writer) *GreetingServerOptions {
+	o := &GreetingServerOptions{
+		RecommendedOptions: genericoptions.NewRecommendedOptions(
+			defaultEtcdPathPrefix,
+			apiserver.Codecs.LegacyCodec(greetingv2.SchemeGroupVersion),
+		),
+
+		StdOut: out,
+		StdErr: errOut,
+	}
+	o.RecommendedOptions.Etcd.StorageConfig.EncodeVersioner = runtime.NewMultiGroupVersioner(
+		greetingv2.SchemeGroupVersion, schema.GroupKind{Group: greeting.GroupName})
+
+	defaultimage.Register(o.RecommendedOptions.Admission.Plugins)
+	imagepolicy.Register(o.RecommendedOptions.Admission.Plugins)
+	o.RecommendedOptions.Admission.RecommendedPluginOrder = append(
+		o.RecommendedOptions.Admission.RecommendedPluginOrder, greetingAdmissionPlugins...)
+	return o
+}
+
+// NewCommandStartGreetingServer provides a CLI handler for 'start master' command
+// with a default GreetingServerOptions.
+func NewCommandStartGreetingServer(ctx context.Context, defaults *GreetingServerOptions) *cobra.Command {
+	o := *defaults
+	cmd := &cobra.Command{
+		Short: "Launch a greeting API server",
+		Long:  "Launch a greeting API server serving greeting.foen.ye/v1 and greeting.foen.ye/v2",
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := o.Complete(); err != nil {
+				return err
+			}
+			if err := o.Validate(args); err != nil {
+				return err
+			}
+			return o.RunGreetingServer(c.Context())
+		},
+	}
+	cmd.SetContext(ctx)
+
+	flags := cmd.Flags()
+	o.RecommendedOptions.AddFlags(flags)
+	flags.BoolVar(&o.Standalone, "standalone", o.Standalone, ""+
+		"If true, run without a backing kube-apiserver: every request is authorized and "+
+		"core API informers, priority and fairness and all but the greeting admission plugins are disabled. "+
+		"For local development only.")
+
+	return cmd
+}
+
+// Validate validates GreetingServerOptions
+func (o *GreetingServerOptions) Validate(_ []string) error {
+	var errors []error
+	errors = append(errors, o.RecommendedOptions.Validate()...)
+	if len(errors) > 0 {
+		return fmt.Errorf("%v", errors)
+	}
+	return nil
+}
+
+// Complete fills in fields required to have valid data
+func (o *GreetingServerOptions) Complete() error {
+	if o.Standalone {
+		o.RecommendedOptions.CoreAPI = nil
+		o.standaloneAdmission = o.RecommendedOptions.Admission
+		o.RecommendedOptions.Admission = nil
+		o.RecommendedOptions.Features.EnablePriorityAndFairness = false
+		o.RecommendedOptions.Authentication.RemoteKubeConfigFileOptional = true
+		o.RecommendedOptions.Authorization.RemoteKubeConfigFileOptional = true
+	}
+	return nil
+}
+
+// Config returns config for the api server given GreetingServerOptions
+func (o *GreetingServerOptions) Config() (*apiserver.Config, error) {
+	// TODO have a "real" external address
+	if err := o.RecommendedOptions.SecureServing.MaybeDefaultWithSelfSignedCerts("localhost", o.AlternateDNS,
+		[]net.IP{netutils.ParseIPSloppy("127.0.0.1")}); err != nil {
+		return nil, fmt.Errorf("error creating self-signed certificates: %v", err)
+	}
+
+	serverConfig := genericapiserver.NewRecommendedConfig(apiserver.Codecs)
+
+	namer := openapi.NewDefinitionNamer(apiserver.Scheme)
+	serverConfig.OpenAPIConfig = genericapiserver.DefaultOpenAPIConfig(generatedopenapi.GetOpenAPIDefinitions, namer)
+	serverConfig.OpenAPIConfig.Info.Title = "Greeting"
+	serverConfig.OpenAPIConfig.Info.Version = "0.1"
+	serverConfig.OpenAPIV3Config = genericapiserver.DefaultOpenAPIV3Config(generatedopenapi.GetOpenAPIDefinitions, namer)
+	serverConfig.OpenAPIV3Config.Info.Title = "Greeting"
+	serverConfig.OpenAPIV3Config.Info.Version = "0.1"
+
+	serverConfig.EffectiveVersion = utilcompatibility.DefaultBuildEffectiveVersion()
+
+	if err := o.RecommendedOptions.ApplyTo(serverConfig); err != nil {
+		return nil, err
+	}
+
+	if o.Standalone {
+		serverConfig.Authorization.Authorizer = authorizerfactory.NewAlwaysAllowAuthorizer()
+		if err := applyStandaloneAdmission(o.standaloneAdmission, serverConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	config := &apiserver.Config{
+		GenericConfig: serverConfig,
+		ExtraConfig:   apiserver.ExtraConfig{},
+	}
+	return config, nil
+}
+
+// applyStandaloneAdmission builds an admission chain of the greeting plugins
+// not disabled by --disable-admission-plugins, configured from
+// --admission-control-config-file. Unlike AdmissionOptions.ApplyTo it needs
+// no kube-apiserver.
+func applyStandaloneAdmission(options *genericoptions.AdmissionOptions, c *genericapiserver.RecommendedConfig) error {
+	if options == nil {
+		return nil
+	}
+	var pluginNames []string
+	for _, name := range greetingAdmissionPlugins {
+		if !slices.Contains(options.DisablePlugins, name) {
+			pluginNames = append(pluginNames, name)
+		}
+	}
+
+	pluginsConfigProvider, err := admission.ReadAdmissionConfiguration(pluginNames, options.ConfigFile, admissionConfigScheme)
+	if err != nil {
+		return fmt.Errorf("failed to read plugin config: %v", err)
+	}
+	admissionChain, err := options.Plugins.NewFromPlugins(pluginNames, pluginsConfigProvider, admission.PluginInitializers{}, options.Decorators)
+	if err != nil {
+		return err
+	}
+	c.AdmissionControl = admissionChain
+	return nil
+}
+
+// RunGreetingServer starts a new GreetingServer given GreetingServerOptions
+func (o *GreetingServerOptions) RunGreetingServer(ctx context.Context) error {
+	config, err := o.Config()
+	if err != nil {
+		return err
+	}
+
+	server, err := config.Complete().New()
+	if err != nil {
+		return err
+	}
+
+	if config.GenericConfig.SharedInformerFactory != nil {
+		server.GenericAPIServer.AddPostStartHookOrDie("start-greeting-server-informers",
+			func(context genericapiserver.PostStartHookContext) error {
+				config.GenericConfig.SharedInformerFactory.Start(context.Done())
+				return nil
+			})
+	}
+
+	return server.GenericAPIServer.PrepareRun().RunWithContext(ctx)
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/admission/plugin/defaultimage"
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/admission/plugin/imagepolicy"
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting"
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/validation"
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apiserver"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/admission"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+)
+
+const admissionConfig = `
+apiVersion: apiserver.config.k8s.io/v1
+kind: AdmissionConfiguration
+plugins:
+- name: DefaultFooImage
+  configuration:
+    defaultImage: registry.local:5000/busybox:1.36
+- name: FooImagePolicy
+  configuration:
+    allowedRegistries:
+    - registry.local:5000
+`
+
+func TestStandaloneAdmission(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "admission.yaml")
+	if err := os.WriteFile(configFile, []byte(admissionConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	o := NewGreetingServerOptions(os.Stdout, os.Stderr)
+	o.Standalone = true
+	o.RecommendedOptions.Admission.ConfigFile = configFile
+	if err := o.Complete(); err != nil {
+		t.Fatal(err)
+	}
+
+	config := genericapiserver.NewRecommendedConfig(apiserver.Codecs)
+	if err := applyStandaloneAdmission(o.standaloneAdmission, config); err != nil {
+		t.Fatal(err)
+	}
+	chain := config.AdmissionControl
+
+	attributes := func(foo *greeting.Foo) admission.Attributes {
+		return admission.NewAttributesRecord(foo, nil, greeting.Kind("Foo").WithVersion("v2"), "default", "foo",
+			greeting.Resource("foos").WithVersion("v2"), "", admission.Create, nil, false, nil)
+	}
+
+	foo := &greeting.Foo{}
+	if err := chain.(admission.MutationInterface).Admit(context.TODO(), attributes(foo), nil); err != nil {
+		t.Fatal(err)
+	}
+	if foo.Spec.Image != "registry.local:5000/busybox:1.36" {
+		t.Errorf("expected configured default image, got %q", foo.Spec.Image)
+	}
+	if err := chain.(admission.ValidationInterface).Validate(context.TODO(), attributes(foo), nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	foo = &greeting.Foo{Spec: greeting.FooSpec{Image: "busybox:1.36"}}
+	err := chain.(admission.ValidationInterface).Validate(context.TODO(), attributes(foo), nil)
+	if !apierrors.IsForbidden(err) {
+		t.Errorf("expected forbidden error, got %v", err)
+	}
+}
+
+func TestStandaloneAdmissionDisabledPlugins(t *testing.T) {
+	o := NewGreetingServerOptions(os.Stdout, os.Stderr)
+	o.Standalone = true
+	o.RecommendedOptions.Admission.DisablePlugins = []string{defaultimage.PluginName, imagepolicy.PluginName}
+	if err := o.Complete(); err != nil {
+		t.Fatal(err)
+	}
+
+	config := genericapiserver.NewRecommendedConfig(apiserver.Codecs)
+	if err := applyStandaloneAdmission(o.standaloneAdmission, config); err != nil {
+		t.Fatal(err)
+	}
+	foo := &greeting.Foo{}
+	attrs := admission.NewAttributesRecord(foo, nil, greeting.Kind("Foo").WithVersion("v2"), "default", "foo",
+		greeting.Resource("foos").WithVersion("v2"), "", admission.Create, nil, false, nil)
+	if err := config.AdmissionControl.(admission.MutationInterface).Admit(context.TODO(), attrs, nil); err != nil {
+		t.Fatal(err)
+	}
+	if foo.Spec.Image != "" {
+		t.Errorf("expected no defaulting, got %q", foo.Spec.Image)
+	}
+}
+
+// TestV1CreateGetsConfiguredImage checks that a v1 foo without an image gets
+// the image configured for the DefaultFooImage plugin, as v2 foos do.
+func TestV1CreateGetsConfiguredImage(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "admission.yaml")
+	if err := os.WriteFile(configFile, []byte(admissionConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	o := NewGreetingServerOptions(os.Stdout, os.Stderr)
+	o.Standalone = true
+	o.RecommendedOptions.Admission.ConfigFile = configFile
+	if err := o.Complete(); err != nil {
+		t.Fatal(err)
+	}
+	config := genericapiserver.NewRecommendedConfig(apiserver.Codecs)
+	if err := applyStandaloneAdmission(o.standaloneAdmission, config); err != nil {
+		t.Fatal(err)
+	}
+
+	body := []byte(`{"apiVersion":"greeting.foen.ye/v1","kind":"Foo","metadata":{"name":"foo","namespace":"default"},"spec":{"message":"hello"}}`)
+	obj, _, err := apiserver.Codecs.UniversalDecoder(greeting.SchemeGroupVersion).Decode(body, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	foo := obj.(*greeting.Foo)
+	if foo.Spec.Image != "" {
+		t.Fatalf("expected no image before admission, got %q", foo.Spec.Image)
+	}
+	attrs := admission.NewAttributesRecord(foo, nil, greeting.Kind("Foo").WithVersion("v1"), "default", "foo",
+		greeting.Resource("foos").WithVersion("v1"), "", admission.Create, nil, false, nil)
+	if err := config.AdmissionControl.(admission.MutationInterface).Admit(context.TODO(), attrs, nil); err != nil {
+		t.Fatal(err)
+	}
+	if foo.Spec.Image != "registry.local:5000/busybox:1.36" {
+		t.Errorf("expected configured default image, got %q", foo.Spec.Image)
+	}
+	if errs := validation.ValidateFoo(foo); len(errs) != 0 {
+		t.Errorf("unexpected validation errors: %v", errs)
+	}
+}
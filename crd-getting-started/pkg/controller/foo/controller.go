@@ -0,0 +1,404 @@
+package foo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	greetingv2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v2"
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v2/helper"
+	greetingv2apply "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/applyconfiguration/greeting/v2"
+	clientset "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/clientset_generated/clientset"
+	greetingscheme "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/clientset_generated/clientset/scheme"
+	greetinginformers "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/informers/externalversions/greeting/v2"
+	greetinglisters "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/listers/greeting/v2"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	appsinformers "k8s.io/client-go/informers/apps/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+)
+
+const controllerName = "foo-controller"
+
+const (
+	// ConfigMapKeyMessage is the ConfigMap key FooConfig.Message is rendered into.
+	ConfigMapKeyMessage = "message"
+	// ConfigMapKeyDescription is the ConfigMap key FooConfig.Description is rendered into.
+	ConfigMapKeyDescription = "description"
+
+	// LabelFoo selects the ConfigMap, Deployment and pods belonging to a foo.
+	LabelFoo = "greeting.foen.ye/foo"
+	// AnnotationConfigHash records the rendered config on the pod template, so
+	// that the worker pods roll whenever FooConfig changes.
+	AnnotationConfigHash = "greeting.foen.ye/config-hash"
+)
+
+const (
+	// SuccessSynced is used as part of the Event 'reason' when a Foo is synced
+	SuccessSynced = "Synced"
+	// ErrResourceExists is used as part of the Event 'reason' when a Foo fails
+	// to sync due to a ConfigMap or Deployment of the same name already existing.
+	ErrResourceExists = "ErrResourceExists"
+
+	// MessageResourceExists is the message used for Events when a resource
+	// fails to sync due to a ConfigMap or Deployment already existing
+	MessageResourceExists = "Resource %q already exists and is not managed by Foo"
+	// MessageResourceSynced is the message used for an Event fired when a Foo
+	// is synced successfully
+	MessageResourceSynced = "Foo synced successfully"
+)
+
+// Reasons of the Config and Worker conditions.
+const (
+	ReasonSynced               = "Synced"
+	ReasonSyncFailed           = "SyncFailed"
+	ReasonConfigNotSynced      = "ConfigNotSynced"
+	ReasonDeploymentAvailable  = "DeploymentAvailable"
+	ReasonDeploymentRollingOut = "DeploymentRollingOut"
+)
+
+func init() {
+	// Add greeting types to the default Kubernetes Scheme so Events can be
+	// logged for greeting types.
+	utilruntime.Must(greetingscheme.AddToScheme(scheme.Scheme))
+}
+
+// Controller renders every Foo into a ConfigMap holding its FooConfig and a
+// Deployment running its image, and reports their state in FooStatus.
+type Controller struct {
+	kubeClient     kubernetes.Interface
+	greetingClient clientset.Interface
+
+	fooLister         greetinglisters.FooLister
+	foosSynced        cache.InformerSynced
+	configMapLister   corelisters.ConfigMapLister
+	configMapsSynced  cache.InformerSynced
+	deploymentLister  appslisters.DeploymentLister
+	deploymentsSynced cache.InformerSynced
+
+	queue    workqueue.TypedRateLimitingInterface[cache.ObjectName]
+	recorder record.EventRecorder
+}
+
+// NewController returns a new foo controller.
+func NewController(
+	ctx context.Context,
+	kubeClient kubernetes.Interface,
+	greetingClient clientset.Interface,
+	fooInformer greetinginformers.FooInformer,
+	configMapInformer coreinformers.ConfigMapInformer,
+	deploymentInformer appsinformers.DeploymentInformer) (*Controller, error) {
+	logger := klog.FromContext(ctx)
+
+	eventBroadcaster := record.NewBroadcaster(record.WithContext(ctx))
+	eventBroadcaster.StartStructuredLogging(0)
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+
+	c := &Controller{
+		kubeClient:        kubeClient,
+		greetingClient:    greetingClient,
+		fooLister:         fooInformer.Lister(),
+		foosSynced:        fooInformer.Informer().HasSynced,
+		configMapLister:   configMapInformer.Lister(),
+		configMapsSynced:  configMapInformer.Informer().HasSynced,
+		deploymentLister:  deploymentInformer.Lister(),
+		deploymentsSynced: deploymentInformer.Informer().HasSynced,
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.DefaultTypedControllerRateLimiter[cache.ObjectName](),
+			workqueue.TypedRateLimitingQueueConfig[cache.ObjectName]{Name: controllerName},
+		),
+		recorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerName}),
+	}
+
+	logger.Info("Setting up event handlers")
+	if _, err := fooInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueueFoo,
+		UpdateFunc: func(_, newObj interface{}) { c.enqueueFoo(newObj) },
+	}); err != nil {
+		return nil, err
+	}
+	// Requeue the owning Foo whenever one of its dependents changes, so that
+	// drift is reverted and FooStatus follows the Deployment rollout.
+	dependentHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc: c.handleObject,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if oldObj.(metav1.Object).GetResourceVersion() == newObj.(metav1.Object).GetResourceVersion() {
+				return
+			}
+			c.handleObject(newObj)
+		},
+		DeleteFunc: c.handleObject,
+	}
+	if _, err := configMapInformer.Informer().AddEventHandler(dependentHandler); err != nil {
+		return nil, err
+	}
+	if _, err := deploymentInformer.Informer().AddEventHandler(dependentHandler); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run waits for the informer caches to sync and starts workers processing
+// foos until ctx is done.
+func (c *Controller) Run(ctx context.Context, workers int) error {
+	defer utilruntime.HandleCrash()
+	defer c.queue.ShutDown()
+	logger := klog.FromContext(ctx)
+
+	logger.Info("Starting Foo controller")
+	if ok := cache.WaitForCacheSync(ctx.Done(), c.foosSynced, c.configMapsSynced, c.deploymentsSynced); !ok {
+		return errors.New("failed to wait for caches to sync")
+	}
+
+	logger.Info("Starting workers", "count", workers)
+	for i := 0; i < workers; i++ {
+		go wait.UntilWithContext(ctx, c.runWorker, time.Second)
+	}
+
+	<-ctx.Done()
+	logger.Info("Shutting down workers")
+	return nil
+}
+
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	key, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	if err := c.syncHandler(ctx, key); err != nil {
+		utilruntime.HandleErrorWithContext(ctx, err, "Error syncing; requeuing for later retry", "objectReference", key)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+// syncHandler drives the ConfigMap and Deployment of the Foo identified by key
+// towards the desired state and then updates FooStatus, even when a dependent
+// failed to sync, so the failing condition is visible to users.
+func (c *Controller) syncHandler(ctx context.Context, key cache.ObjectName) error {
+	logger := klog.LoggerWithValues(klog.FromContext(ctx), "objectRef", key)
+
+	foo, err := c.fooLister.Foos(key.Namespace).Get(key.Name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// Dependents are garbage collected through their owner references.
+			logger.V(4).Info("Foo no longer exists")
+			return nil
+		}
+		return err
+	}
+
+	configMap, configErr := c.syncConfigMap(ctx, foo)
+	var deployment *appsv1.Deployment
+	var workerErr error
+	if configErr == nil {
+		deployment, workerErr = c.syncDeployment(ctx, foo, configMap)
+	}
+
+	statusChanged, err := c.updateFooStatus(ctx, foo, configMap, configErr, deployment, workerErr)
+	if err != nil {
+		return utilerrors.NewAggregate([]error{configErr, workerErr, err})
+	}
+	if configErr != nil || workerErr != nil {
+		return utilerrors.NewAggregate([]error{configErr, workerErr})
+	}
+
+	// Resyncs of an up to date Foo would otherwise flood it with events.
+	if statusChanged {
+		c.recorder.Event(foo, corev1.EventTypeNormal, SuccessSynced, MessageResourceSynced)
+	}
+	return nil
+}
+
+func (c *Controller) syncConfigMap(ctx context.Context, foo *greetingv2.Foo) (*corev1.ConfigMap, error) {
+	desired := newConfigMap(foo)
+	configMap, err := c.configMapLister.ConfigMaps(foo.Namespace).Get(desired.Name)
+	if apierrors.IsNotFound(err) {
+		return c.kubeClient.CoreV1().ConfigMaps(foo.Namespace).Create(ctx, desired, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !metav1.IsControlledBy(configMap, foo) {
+		return nil, c.resourceExists(foo, configMap.Name)
+	}
+	if equality.Semantic.DeepEqual(configMap.Data, desired.Data) {
+		return configMap, nil
+	}
+
+	configMap = configMap.DeepCopy()
+	configMap.Data = desired.Data
+	return c.kubeClient.CoreV1().ConfigMaps(foo.Namespace).Update(ctx, configMap, metav1.UpdateOptions{})
+}
+
+func (c *Controller) syncDeployment(ctx context.Context, foo *greetingv2.Foo, configMap *corev1.ConfigMap) (*appsv1.Deployment, error) {
+	desired := newDeployment(foo, configMap)
+	deployment, err := c.deploymentLister.Deployments(foo.Namespace).Get(desired.Name)
+	if apierrors.IsNotFound(err) {
+		return c.kubeClient.AppsV1().Deployments(foo.Namespace).Create(ctx, desired, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !metav1.IsControlledBy(deployment, foo) {
+		return nil, c.resourceExists(foo, deployment.Name)
+	}
+	// Only compare the fields owned by the controller, the rest of the pod
+	// template is defaulted by the API server.
+	if workerImage(deployment) == foo.Spec.Image &&
+		deployment.Spec.Template.Annotations[AnnotationConfigHash] == desired.Spec.Template.Annotations[AnnotationConfigHash] {
+		return deployment, nil
+	}
+
+	deployment = deployment.DeepCopy()
+	deployment.Spec.Template = desired.Spec.Template
+	return c.kubeClient.AppsV1().Deployments(foo.Namespace).Update(ctx, deployment, metav1.UpdateOptions{})
+}
+
+func (c *Controller) resourceExists(foo *greetingv2.Foo, name string) error {
+	msg := fmt.Sprintf(MessageResourceExists, name)
+	c.recorder.Event(foo, corev1.EventTypeWarning, ErrResourceExists, msg)
+	return errors.New(msg)
+}
+
+// updateFooStatus sets the Config and Worker conditions and derives the phase
+// from them, skipping the write when nothing changed. The status is applied
+// server side, owned by the controller's field manager.
+func (c *Controller) updateFooStatus(ctx context.Context, foo *greetingv2.Foo,
+	configMap *corev1.ConfigMap, configErr error, deployment *appsv1.Deployment, workerErr error) (bool, error) {
+	fooCopy := foo.DeepCopy()
+
+	if configErr != nil {
+		helper.SetFooCondition(fooCopy, helper.NewFooCondition(greetingv2.FooConditionTypeConfig,
+			metav1.ConditionFalse, ReasonSyncFailed, configErr.Error()))
+	} else {
+		helper.SetFooCondition(fooCopy, helper.NewFooCondition(greetingv2.FooConditionTypeConfig,
+			metav1.ConditionTrue, ReasonSynced, fmt.Sprintf("ConfigMap %q is up to date", configMap.Name)))
+	}
+
+	switch {
+	case configErr != nil:
+		helper.SetFooCondition(fooCopy, helper.NewFooCondition(greetingv2.FooConditionTypeWorker,
+			metav1.ConditionFalse, ReasonConfigNotSynced, "Waiting for the config to be synced"))
+	case workerErr != nil:
+		helper.SetFooCondition(fooCopy, helper.NewFooCondition(greetingv2.FooConditionTypeWorker,
+			metav1.ConditionFalse, ReasonSyncFailed, workerErr.Error()))
+	case deploymentAvailable(deployment):
+		helper.SetFooCondition(fooCopy, helper.NewFooCondition(greetingv2.FooConditionTypeWorker,
+			metav1.ConditionTrue, ReasonDeploymentAvailable, fmt.Sprintf("Deployment %q is available", deployment.Name)))
+	default:
+		helper.SetFooCondition(fooCopy, helper.NewFooCondition(greetingv2.FooConditionTypeWorker,
+			metav1.ConditionFalse, ReasonDeploymentRollingOut, fmt.Sprintf("Deployment %q has %d of %d replicas available",
+				deployment.Name, deployment.Status.AvailableReplicas, ptr.Deref(deployment.Spec.Replicas, 1))))
+	}
+
+	fooCopy.Status.Phase = greetingv2.FooPhaseProcessing
+	if helper.IsFooConditionTrue(fooCopy, greetingv2.FooConditionTypeConfig) &&
+		helper.IsFooConditionTrue(fooCopy, greetingv2.FooConditionTypeWorker) {
+		fooCopy.Status.Phase = greetingv2.FooPhaseReady
+	}
+	fooCopy.Status.ObservedGeneration = foo.Generation
+
+	if equality.Semantic.DeepEqual(foo.Status, fooCopy.Status) {
+		return false, nil
+	}
+	_, err := c.greetingClient.GreetingV2().Foos(foo.Namespace).ApplyStatus(ctx, fooStatusApplyConfiguration(fooCopy),
+		metav1.ApplyOptions{FieldManager: controllerName, Force: true})
+	return err == nil, err
+}
+
+// fooStatusApplyConfiguration returns the apply configuration of the status of foo.
+func fooStatusApplyConfiguration(foo *greetingv2.Foo) *greetingv2apply.FooApplyConfiguration {
+	status := greetingv2apply.FooStatus().
+		WithPhase(foo.Status.Phase).
+		WithObservedGeneration(foo.Status.ObservedGeneration)
+	for _, condition := range foo.Status.Conditions {
+		status.WithConditions(greetingv2apply.FooCondition().
+			WithType(condition.Type).
+			WithStatus(condition.Status).
+			WithLastTransitionTime(condition.LastTransitionTime).
+			WithReason(condition.Reason).
+			WithMessage(condition.Message))
+	}
+	return greetingv2apply.Foo(foo.Name, foo.Namespace).WithStatus(status)
+}
+
+func (c *Controller) enqueueFoo(obj interface{}) {
+	objectRef, err := cache.ObjectToName(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.queue.Add(objectRef)
+}
+
+// handleObject enqueues the Foo controlling obj, if any.
+func (c *Controller) handleObject(obj interface{}) {
+	object, ok := obj.(metav1.Object)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("error decoding object, invalid type %T", obj))
+			return
+		}
+		object, ok = tombstone.Obj.(metav1.Object)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("error decoding object tombstone, invalid type %T", tombstone.Obj))
+			return
+		}
+	}
+
+	ownerRef := metav1.GetControllerOf(object)
+	if ownerRef == nil || ownerRef.Kind != "Foo" || ownerRef.APIVersion != greetingv2.SchemeGroupVersion.String() {
+		return
+	}
+	foo, err := c.fooLister.Foos(object.GetNamespace()).Get(ownerRef.Name)
+	if err != nil || foo.UID != ownerRef.UID {
+		return
+	}
+	c.enqueueFoo(foo)
+}
+
+// deploymentAvailable reports whether the latest rollout of deployment is complete.
+func deploymentAvailable(deployment *appsv1.Deployment) bool {
+	if deployment == nil || deployment.Status.ObservedGeneration < deployment.Generation {
+		return false
+	}
+	replicas := ptr.Deref(deployment.Spec.Replicas, 1)
+	return deployment.Status.UpdatedReplicas >= replicas && deployment.Status.AvailableReplicas >= replicas
+}
+
+func workerImage(deployment *appsv1.Deployment) string {
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name == workerContainerName {
+			return container.Image
+		}
+	}
+	return ""
+}
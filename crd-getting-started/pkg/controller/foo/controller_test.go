@@ -0,0 +1,373 @@
+package foo
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	greetingv2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v2"
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/clientset_generated/clientset/fake"
+	informers "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/client/informers/externalversions"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubeinformers "k8s.io/client-go/informers"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2/ktesting"
+	"sigs.k8s.io/yaml"
+)
+
+type fixture struct {
+	t *testing.T
+
+	greetingClient *fake.Clientset
+	kubeClient     *k8sfake.Clientset
+	recorder       *record.FakeRecorder
+
+	// Objects preloaded into the informer indexers and the fake clientsets.
+	foos        []*greetingv2.Foo
+	configMaps  []*corev1.ConfigMap
+	deployments []*appsv1.Deployment
+}
+
+func newFixture(t *testing.T) *fixture {
+	return &fixture{t: t}
+}
+
+func newFoo(name, image string) *greetingv2.Foo {
+	return &greetingv2.Foo{
+		TypeMeta:   metav1.TypeMeta{APIVersion: greetingv2.SchemeGroupVersion.String(), Kind: "Foo"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceDefault, UID: types.UID(name + "-uid")},
+		Spec: greetingv2.FooSpec{
+			Image:  image,
+			Config: greetingv2.FooConfig{Message: "hello", Description: "a foo"},
+		},
+	}
+}
+
+func (f *fixture) newController(ctx context.Context) *Controller {
+	var greetingObjects, kubeObjects []runtime.Object
+	for _, foo := range f.foos {
+		greetingObjects = append(greetingObjects, foo)
+	}
+	for _, configMap := range f.configMaps {
+		kubeObjects = append(kubeObjects, configMap)
+	}
+	for _, deployment := range f.deployments {
+		kubeObjects = append(kubeObjects, deployment)
+	}
+	f.greetingClient = fake.NewClientset(greetingObjects...)
+	f.kubeClient = k8sfake.NewSimpleClientset(kubeObjects...)
+
+	greetingInformers := informers.NewSharedInformerFactory(f.greetingClient, 0)
+	kubeInformers := kubeinformers.NewSharedInformerFactory(f.kubeClient, 0)
+	c, err := NewController(ctx, f.kubeClient, f.greetingClient,
+		greetingInformers.Greeting().V2().Foos(),
+		kubeInformers.Core().V1().ConfigMaps(),
+		kubeInformers.Apps().V1().Deployments())
+	if err != nil {
+		f.t.Fatalf("error creating foo controller: %v", err)
+	}
+	f.recorder = record.NewFakeRecorder(10)
+	c.recorder = f.recorder
+
+	for _, foo := range f.foos {
+		_ = greetingInformers.Greeting().V2().Foos().Informer().GetIndexer().Add(foo)
+	}
+	for _, configMap := range f.configMaps {
+		_ = kubeInformers.Core().V1().ConfigMaps().Informer().GetIndexer().Add(configMap)
+	}
+	for _, deployment := range f.deployments {
+		_ = kubeInformers.Apps().V1().Deployments().Informer().GetIndexer().Add(deployment)
+	}
+	// Only record the actions of the sync under test.
+	f.greetingClient.ClearActions()
+	f.kubeClient.ClearActions()
+	return c
+}
+
+func (f *fixture) run(foo *greetingv2.Foo) error {
+	_, ctx := ktesting.NewTestContext(f.t)
+	c := f.newController(ctx)
+	return c.syncHandler(ctx, cache.MetaObjectToName(foo))
+}
+
+// fooStatus returns the status last written through the fake greeting clientset.
+func (f *fixture) fooStatus(foo *greetingv2.Foo) greetingv2.FooStatus {
+	f.t.Helper()
+	got, err := f.greetingClient.GreetingV2().Foos(foo.Namespace).Get(context.TODO(), foo.Name, metav1.GetOptions{})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return got.Status
+}
+
+// statusApplies returns the number of status writes, which are server side applies.
+func (f *fixture) statusApplies() int {
+	count := 0
+	for _, action := range f.greetingClient.Actions() {
+		patch, ok := action.(core.PatchAction)
+		if ok && action.Matches("patch", "foos") && action.GetSubresource() == "status" &&
+			patch.GetPatchType() == types.ApplyPatchType {
+			count++
+		}
+	}
+	return count
+}
+
+func availableDeployment(foo *greetingv2.Foo) *appsv1.Deployment {
+	deployment := newDeployment(foo, newConfigMap(foo))
+	deployment.Generation = 1
+	deployment.Status = appsv1.DeploymentStatus{
+		ObservedGeneration: 1,
+		Replicas:           1,
+		UpdatedReplicas:    1,
+		AvailableReplicas:  1,
+	}
+	return deployment
+}
+
+type expectedCondition struct {
+	status metav1.ConditionStatus
+	reason string
+}
+
+func expectStatus(t *testing.T, status greetingv2.FooStatus, phase greetingv2.FooPhase, config, worker expectedCondition) {
+	t.Helper()
+	if status.Phase != phase {
+		t.Errorf("expected phase %q, got %q", phase, status.Phase)
+	}
+	expected := map[greetingv2.FooConditionType]expectedCondition{
+		greetingv2.FooConditionTypeConfig: config,
+		greetingv2.FooConditionTypeWorker: worker,
+	}
+	if len(status.Conditions) != len(expected) {
+		t.Fatalf("expected %d conditions, got %#v", len(expected), status.Conditions)
+	}
+	for _, condition := range status.Conditions {
+		if e := expected[condition.Type]; condition.Status != e.status || condition.Reason != e.reason {
+			t.Errorf("expected %s condition %s/%s, got %s/%s", condition.Type, e.status, e.reason, condition.Status, condition.Reason)
+		}
+		if condition.LastTransitionTime.IsZero() || condition.Message == "" {
+			t.Errorf("expected %s condition to have a transition time and message, got %#v", condition.Type, condition)
+		}
+	}
+}
+
+func TestCreatesConfigMapAndDeployment(t *testing.T) {
+	f := newFixture(t)
+	foo := newFoo("test", "busybox:1.36")
+	f.foos = append(f.foos, foo)
+
+	if err := f.run(foo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	configMap, err := f.kubeClient.CoreV1().ConfigMaps(foo.Namespace).Get(context.TODO(), "test-config", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configMap.Data[ConfigMapKeyMessage] != "hello" || configMap.Data[ConfigMapKeyDescription] != "a foo" {
+		t.Errorf("unexpected config map data %v", configMap.Data)
+	}
+	if !metav1.IsControlledBy(configMap, foo) {
+		t.Errorf("expected config map to be controlled by foo, got %v", configMap.OwnerReferences)
+	}
+
+	deployment, err := f.kubeClient.AppsV1().Deployments(foo.Namespace).Get(context.TODO(), "test", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image := workerImage(deployment); image != "busybox:1.36" {
+		t.Errorf("expected image busybox:1.36, got %q", image)
+	}
+	if !metav1.IsControlledBy(deployment, foo) {
+		t.Errorf("expected deployment to be controlled by foo, got %v", deployment.OwnerReferences)
+	}
+
+	// The deployment has not rolled out yet.
+	expectStatus(t, f.fooStatus(foo), greetingv2.FooPhaseProcessing,
+		expectedCondition{metav1.ConditionTrue, ReasonSynced},
+		expectedCondition{metav1.ConditionFalse, ReasonDeploymentRollingOut})
+	select {
+	case event := <-f.recorder.Events:
+		if !strings.Contains(event, SuccessSynced) {
+			t.Errorf("unexpected event %q", event)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected a synced event")
+	}
+}
+
+func TestReadyWhenDeploymentAvailable(t *testing.T) {
+	f := newFixture(t)
+	foo := newFoo("test", "busybox:1.36")
+	f.foos = append(f.foos, foo)
+	f.configMaps = append(f.configMaps, newConfigMap(foo))
+	f.deployments = append(f.deployments, availableDeployment(foo))
+
+	if err := f.run(foo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectStatus(t, f.fooStatus(foo), greetingv2.FooPhaseReady,
+		expectedCondition{metav1.ConditionTrue, ReasonSynced},
+		expectedCondition{metav1.ConditionTrue, ReasonDeploymentAvailable})
+	if applies := f.statusApplies(); applies != 1 {
+		t.Errorf("expected one status apply, got %d", applies)
+	}
+	for _, action := range f.kubeClient.Actions() {
+		if action.GetVerb() != "list" && action.GetVerb() != "watch" {
+			t.Errorf("unexpected kube action %s %s", action.GetVerb(), action.GetResource().Resource)
+		}
+	}
+}
+
+func TestSkipsUnchangedStatus(t *testing.T) {
+	foo := newFoo("test", "busybox:1.36")
+	foo.Generation = 2
+
+	f := newFixture(t)
+	f.foos = append(f.foos, foo)
+	f.configMaps = append(f.configMaps, newConfigMap(foo))
+	f.deployments = append(f.deployments, availableDeployment(foo))
+	if err := f.run(foo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	status := f.fooStatus(foo)
+	if status.ObservedGeneration != 2 {
+		t.Errorf("expected observed generation 2, got %d", status.ObservedGeneration)
+	}
+
+	// Syncing again with the written status is a no-op.
+	synced := foo.DeepCopy()
+	synced.Status = status
+	f = newFixture(t)
+	f.foos = append(f.foos, synced)
+	f.configMaps = append(f.configMaps, newConfigMap(foo))
+	f.deployments = append(f.deployments, availableDeployment(foo))
+	if err := f.run(synced); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if applies := f.statusApplies(); applies != 0 {
+		t.Errorf("expected no status apply, got %d", applies)
+	}
+	select {
+	case event := <-f.recorder.Events:
+		t.Errorf("expected no event for an unchanged foo, got %q", event)
+	default:
+	}
+}
+
+func TestUpdatesDriftedDependents(t *testing.T) {
+	f := newFixture(t)
+	foo := newFoo("test", "nginx:1.27")
+	f.foos = append(f.foos, foo)
+
+	stale := foo.DeepCopy()
+	stale.Spec.Image = "busybox:1.36"
+	stale.Spec.Config.Message = "bye"
+	f.configMaps = append(f.configMaps, newConfigMap(stale))
+	f.deployments = append(f.deployments, availableDeployment(stale))
+
+	if err := f.run(foo); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	configMap, err := f.kubeClient.CoreV1().ConfigMaps(foo.Namespace).Get(context.TODO(), "test-config", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configMap.Data[ConfigMapKeyMessage] != "hello" {
+		t.Errorf("expected message to be updated, got %v", configMap.Data)
+	}
+	deployment, err := f.kubeClient.AppsV1().Deployments(foo.Namespace).Get(context.TODO(), "test", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image := workerImage(deployment); image != "nginx:1.27" {
+		t.Errorf("expected image nginx:1.27, got %q", image)
+	}
+	if hash := deployment.Spec.Template.Annotations[AnnotationConfigHash]; hash != configHash(configMap) {
+		t.Errorf("expected config hash %q, got %q", configHash(configMap), hash)
+	}
+}
+
+func TestConfigMapNotControlledByFoo(t *testing.T) {
+	f := newFixture(t)
+	foo := newFoo("test", "busybox:1.36")
+	f.foos = append(f.foos, foo)
+	configMap := newConfigMap(foo)
+	configMap.OwnerReferences = nil
+	f.configMaps = append(f.configMaps, configMap)
+
+	if err := f.run(foo); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	expectStatus(t, f.fooStatus(foo), greetingv2.FooPhaseProcessing,
+		expectedCondition{metav1.ConditionFalse, ReasonSyncFailed},
+		expectedCondition{metav1.ConditionFalse, ReasonConfigNotSynced})
+	for _, action := range f.kubeClient.Actions() {
+		if action.Matches("create", "deployments") {
+			t.Error("expected no deployment to be created")
+		}
+	}
+	select {
+	case event := <-f.recorder.Events:
+		if !strings.Contains(event, ErrResourceExists) {
+			t.Errorf("unexpected event %q", event)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected a resource exists event")
+	}
+}
+
+func TestHandleObjectEnqueuesOwner(t *testing.T) {
+	f := newFixture(t)
+	foo := newFoo("test", "busybox:1.36")
+	f.foos = append(f.foos, foo)
+	_, ctx := ktesting.NewTestContext(t)
+	c := f.newController(ctx)
+
+	orphan := newConfigMap(foo)
+	orphan.OwnerReferences = nil
+	c.handleObject(orphan)
+	c.handleObject(cache.DeletedFinalStateUnknown{Key: "default/test", Obj: newDeployment(foo, newConfigMap(foo))})
+
+	if length := c.queue.Len(); length != 1 {
+		t.Fatalf("expected 1 queued foo, got %d", length)
+	}
+	key, _ := c.queue.Get()
+	if key != cache.MetaObjectToName(foo) {
+		t.Errorf("unexpected key %v", key)
+	}
+}
+
+// TestCRDServesStatus checks that only the v2 Foo CRD version, the one the
+// controller applies status through, serves the status subresource; v1 has
+// no status, as on the greeting apiserver.
+func TestCRDServesStatus(t *testing.T) {
+	data, err := os.ReadFile("../../../config/crd/bases/greeting.foen.ye_foos.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := yaml.Unmarshal(data, crd); err != nil {
+		t.Fatal(err)
+	}
+	for _, version := range crd.Spec.Versions {
+		served := version.Subresources != nil && version.Subresources.Status != nil
+		if expected := version.Name == greetingv2.SchemeGroupVersion.Version; served != expected {
+			t.Errorf("%s: expected status subresource served to be %v, got %v", version.Name, expected, served)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package foo
+
+import (
+	"encoding/hex"
+	"hash/fnv"
+
+	greetingv2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v2"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+const workerContainerName = "foo"
+
+// configMapName returns the name of the ConfigMap holding the FooConfig of foo.
+func configMapName(foo *greetingv2.Foo) string {
+	return foo.Name + "-config"
+}
+
+func labels(foo *greetingv2.Foo) map[string]string {
+	return map[string]string{LabelFoo: foo.Name}
+}
+
+func ownerReferences(foo *greetingv2.Foo) []metav1.OwnerReference {
+	return []metav1.OwnerReference{*metav1.NewControllerRef(foo, greetingv2.SchemeGroupVersion.WithKind("Foo"))}
+}
+
+// newConfigMap renders the FooConfig of foo into a ConfigMap.
+func newConfigMap(foo *greetingv2.Foo) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            configMapName(foo),
+			Namespace:       foo.Namespace,
+			Labels:          labels(foo),
+			OwnerReferences: ownerReferences(foo),
+		},
+		Data: map[string]string{
+			ConfigMapKeyMessage:     foo.Spec.Config.Message,
+			ConfigMapKeyDescription: foo.Spec.Config.Description,
+		},
+	}
+}
+
+// newDeployment returns a Deployment running the image of foo, with the
+// rendered config of configMap exposed as environment variables.
+func newDeployment(foo *greetingv2.Foo, configMap *corev1.ConfigMap) *appsv1.Deployment {
+	configMapEnv := func(name, key string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMap.Name},
+				Key:                  key,
+			}},
+		}
+	}
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            foo.Name,
+			Namespace:       foo.Namespace,
+			Labels:          labels(foo),
+			OwnerReferences: ownerReferences(foo),
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To[int32](1),
+			Selector: &metav1.LabelSelector{MatchLabels: labels(foo)},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels(foo),
+					Annotations: map[string]string{AnnotationConfigHash: configHash(configMap)},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  workerContainerName,
+						Image: foo.Spec.Image,
+						Env: []corev1.EnvVar{
+							configMapEnv("FOO_MESSAGE", ConfigMapKeyMessage),
+							configMapEnv("FOO_DESCRIPTION", ConfigMapKeyDescription),
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// configHash hashes the rendered config in a stable key order.
+func configHash(configMap *corev1.ConfigMap) string {
+	hasher := fnv.New32a()
+	for _, key := range []string{ConfigMapKeyMessage, ConfigMapKeyDescription} {
+		_, _ = hasher.Write([]byte(key + "=" + configMap.Data[key] + "\n"))
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
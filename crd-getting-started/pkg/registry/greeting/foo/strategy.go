@@ -0,0 +1,163 @@
+package foo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting"
+	greetingv1 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v1"
+	greetingv2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v2"
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/validation"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apiserver/pkg/registry/generic"
+	"k8s.io/apiserver/pkg/storage"
+	"k8s.io/apiserver/pkg/storage/names"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+// NewStrategy creates and returns a fooStrategy instance
+func NewStrategy(typer runtime.ObjectTyper) fooStrategy {
+	return fooStrategy{typer, names.SimpleNameGenerator}
+}
+
+// GetAttrs returns labels.Set, fields.Set, and error in case the given runtime.Object is not a Foo
+func GetAttrs(obj runtime.Object) (labels.Set, fields.Set, error) {
+	foo, ok := obj.(*greeting.Foo)
+	if !ok {
+		return nil, nil, fmt.Errorf("given object is not a Foo")
+	}
+	return foo.ObjectMeta.Labels, SelectableFields(foo), nil
+}
+
+// MatchFoo is the filter used by the generic etcd backend to watch events
+// from etcd to clients of the apiserver only interested in specific labels/fields.
+func MatchFoo(label labels.Selector, field fields.Selector) storage.SelectionPredicate {
+	return storage.SelectionPredicate{
+		Label:    label,
+		Field:    field,
+		GetAttrs: GetAttrs,
+	}
+}
+
+// SelectableFields returns a field set that represents the object.
+func SelectableFields(obj *greeting.Foo) fields.Set {
+	return generic.ObjectMetaFieldsSet(&obj.ObjectMeta, true)
+}
+
+type fooStrategy struct {
+	runtime.ObjectTyper
+	names.NameGenerator
+}
+
+func (fooStrategy) NamespaceScoped() bool {
+	return true
+}
+
+// GetResetFields returns the set of fields that get reset by the strategy
+// and should not be modified by the user.
+func (fooStrategy) GetResetFields() map[fieldpath.APIVersion]*fieldpath.Set {
+	return map[fieldpath.APIVersion]*fieldpath.Set{
+		fieldpath.APIVersion(greetingv1.SchemeGroupVersion.String()): fieldpath.NewSet(
+			fieldpath.MakePathOrDie("status"),
+		),
+		fieldpath.APIVersion(greetingv2.SchemeGroupVersion.String()): fieldpath.NewSet(
+			fieldpath.MakePathOrDie("status"),
+		),
+	}
+}
+
+// PrepareForCreate clears the status of a Foo before creation, status is only
+// ever written through the status subresource, and starts its generation at 1.
+func (fooStrategy) PrepareForCreate(_ context.Context, obj runtime.Object) {
+	foo := obj.(*greeting.Foo)
+	foo.Status = greeting.FooStatus{}
+	foo.Generation = 1
+}
+
+// PrepareForUpdate preserves the status of the existing Foo, changes of status
+// made through the main resource are ignored. The generation is incremented
+// whenever the spec changes, which includes the v1 image annotation as it is
+// converted into the spec.
+func (fooStrategy) PrepareForUpdate(_ context.Context, obj, old runtime.Object) {
+	newFoo := obj.(*greeting.Foo)
+	oldFoo := old.(*greeting.Foo)
+	newFoo.Status = oldFoo.Status
+	if !apiequality.Semantic.DeepEqual(newFoo.Spec, oldFoo.Spec) {
+		newFoo.Generation = oldFoo.Generation + 1
+	}
+}
+
+func (fooStrategy) Validate(_ context.Context, obj runtime.Object) field.ErrorList {
+	foo := obj.(*greeting.Foo)
+	return validation.ValidateFoo(foo)
+}
+
+// WarningsOnCreate returns warnings for the creation of the given object.
+func (fooStrategy) WarningsOnCreate(_ context.Context, _ runtime.Object) []string {
+	return nil
+}
+
+func (fooStrategy) AllowCreateOnUpdate() bool {
+	return false
+}
+
+func (fooStrategy) AllowUnconditionalUpdate() bool {
+	return false
+}
+
+func (fooStrategy) Canonicalize(_ runtime.Object) {
+}
+
+func (fooStrategy) ValidateUpdate(_ context.Context, obj, old runtime.Object) field.ErrorList {
+	return validation.ValidateFooUpdate(obj.(*greeting.Foo), old.(*greeting.Foo))
+}
+
+// WarningsOnUpdate returns warnings for the given update.
+func (fooStrategy) WarningsOnUpdate(_ context.Context, _, _ runtime.Object) []string {
+	return nil
+}
+
+type fooStatusStrategy struct {
+	fooStrategy
+}
+
+// NewStatusStrategy creates and returns a fooStatusStrategy instance
+func NewStatusStrategy(typer runtime.ObjectTyper) fooStatusStrategy {
+	return fooStatusStrategy{NewStrategy(typer)}
+}
+
+// GetResetFields returns the set of fields that get reset by the strategy
+// and should not be modified by the user.
+func (fooStatusStrategy) GetResetFields() map[fieldpath.APIVersion]*fieldpath.Set {
+	return map[fieldpath.APIVersion]*fieldpath.Set{
+		fieldpath.APIVersion(greetingv2.SchemeGroupVersion.String()): fieldpath.NewSet(
+			fieldpath.MakePathOrDie("spec"),
+			fieldpath.MakePathOrDie("metadata", "labels"),
+		),
+	}
+}
+
+// PrepareForUpdate clears fields that are not allowed to be set by end users on update,
+// only FooStatus is taken from the new object.
+func (fooStatusStrategy) PrepareForUpdate(_ context.Context, obj, old runtime.Object) {
+	newFoo := obj.(*greeting.Foo)
+	oldFoo := old.(*greeting.Foo)
+	newFoo.Spec = oldFoo.Spec
+	newFoo.Labels = oldFoo.Labels
+	newFoo.Annotations = oldFoo.Annotations
+	newFoo.OwnerReferences = oldFoo.OwnerReferences
+	newFoo.Finalizers = oldFoo.Finalizers
+}
+
+func (fooStatusStrategy) ValidateUpdate(_ context.Context, obj, old runtime.Object) field.ErrorList {
+	return validation.ValidateFooStatusUpdate(obj.(*greeting.Foo), old.(*greeting.Foo))
+}
+
+// WarningsOnUpdate returns warnings for the given update.
+func (fooStatusStrategy) WarningsOnUpdate(_ context.Context, _, _ runtime.Object) []string {
+	return nil
+}
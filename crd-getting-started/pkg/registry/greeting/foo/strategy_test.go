@@ -0,0 +1,191 @@
+package foo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting"
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/install"
+	greetingv1 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v1"
+	greetingv2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	install.Install(scheme)
+}
+
+func newFoo(name, image, message string, phase greeting.FooPhase) *greeting.Foo {
+	return &greeting.Foo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			Labels:            map[string]string{"app": name},
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+		},
+		Spec: greeting.FooSpec{
+			Image:  image,
+			Config: greeting.FooConfig{Message: message},
+		},
+		Status: greeting.FooStatus{
+			Phase: phase,
+			Conditions: []greeting.FooCondition{
+				{Type: greeting.FooConditionTypeWorker, Status: metav1.ConditionTrue, Reason: "PodReady"},
+			},
+			ObservedGeneration: 1,
+		},
+	}
+}
+
+var (
+	ready      = newFoo("ready", "busybox:1.36", "hello world", greeting.FooPhaseReady)
+	processing = newFoo("processing", "busybox:1.37", "hello there", greeting.FooPhaseProcessing)
+)
+
+func TestStatusClearedOnCreate(t *testing.T) {
+	foo := ready.DeepCopy()
+	NewStrategy(scheme).PrepareForCreate(context.TODO(), foo)
+	if !reflect.DeepEqual(foo.Status, greeting.FooStatus{}) {
+		t.Errorf("expected the status to be cleared on create, got %v", foo.Status)
+	}
+	if !reflect.DeepEqual(foo.Spec, ready.Spec) {
+		t.Errorf("expected the spec to be kept on create, got %v", foo.Spec)
+	}
+	if foo.Generation != 1 {
+		t.Errorf("expected generation 1 on create, got %d", foo.Generation)
+	}
+}
+
+func TestStatusPreservedOnUpdate(t *testing.T) {
+	foo := processing.DeepCopy()
+	foo.Spec.Image = "busybox:1.38"
+	foo.Status = ready.Status
+	NewStrategy(scheme).PrepareForUpdate(context.TODO(), foo, processing)
+	if !reflect.DeepEqual(foo.Status, processing.Status) {
+		t.Errorf("expected the status to be preserved, got %v", foo.Status)
+	}
+	if foo.Spec.Image != "busybox:1.38" {
+		t.Errorf("expected the spec to be updated, got %v", foo.Spec)
+	}
+}
+
+func TestGenerationOnUpdate(t *testing.T) {
+	testCases := map[string]struct {
+		mutate   func(foo *greeting.Foo)
+		expected int64
+	}{
+		"spec changed": {
+			mutate:   func(foo *greeting.Foo) { foo.Spec.Config.Message = "hello again" },
+			expected: 2,
+		},
+		"metadata changed": {
+			mutate:   func(foo *greeting.Foo) { foo.Labels = map[string]string{"app": "changed"} },
+			expected: 1,
+		},
+		"status changed": {
+			mutate:   func(foo *greeting.Foo) { foo.Status = ready.Status },
+			expected: 1,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			old := processing.DeepCopy()
+			old.Generation = 1
+			foo := old.DeepCopy()
+			tc.mutate(foo)
+			NewStrategy(scheme).PrepareForUpdate(context.TODO(), foo, old)
+			if foo.Generation != tc.expected {
+				t.Errorf("expected generation %d, got %d", tc.expected, foo.Generation)
+			}
+		})
+	}
+}
+
+func TestMetadataAndSpecPreservedOnStatusUpdate(t *testing.T) {
+	old := processing.DeepCopy()
+	old.Annotations = map[string]string{"team": "greeting"}
+	old.OwnerReferences = []metav1.OwnerReference{{APIVersion: "v1", Kind: "ConfigMap", Name: "owner", UID: "1"}}
+	old.Finalizers = []string{"greeting.foen.ye/cleanup"}
+	foo := old.DeepCopy()
+	foo.Spec.Image = "busybox:1.38"
+	foo.Labels = map[string]string{"app": "changed"}
+	foo.Annotations = nil
+	foo.OwnerReferences = nil
+	foo.Finalizers = nil
+	foo.Status = ready.Status
+	NewStatusStrategy(scheme).PrepareForUpdate(context.TODO(), foo, old)
+	if !reflect.DeepEqual(foo.Status, ready.Status) {
+		t.Errorf("expected the status to be updated, got %v", foo.Status)
+	}
+	foo.Status = old.Status
+	if !reflect.DeepEqual(foo, old) {
+		t.Errorf("expected everything but the status to be preserved, got %v", foo)
+	}
+}
+
+func TestGetResetFields(t *testing.T) {
+	testCases := map[string]struct {
+		resetFields map[fieldpath.APIVersion]*fieldpath.Set
+		expected    map[string][]string
+	}{
+		"main resource": {
+			resetFields: NewStrategy(scheme).GetResetFields(),
+			expected: map[string][]string{
+				greetingv1.SchemeGroupVersion.String(): {".status"},
+				greetingv2.SchemeGroupVersion.String(): {".status"},
+			},
+		},
+		"status subresource": {
+			resetFields: NewStatusStrategy(scheme).GetResetFields(),
+			expected: map[string][]string{
+				greetingv2.SchemeGroupVersion.String(): {".spec", ".metadata.labels"},
+			},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			actual := map[string][]string{}
+			for version, set := range tc.resetFields {
+				set.Iterate(func(path fieldpath.Path) {
+					actual[string(version)] = append(actual[string(version)], path.String())
+				})
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected reset fields %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestConvertToTable(t *testing.T) {
+	table, err := NewTableConvertor().ConvertToTable(context.TODO(),
+		&greeting.FooList{Items: []greeting.Foo{*ready, *processing}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var columns []string
+	for _, column := range table.ColumnDefinitions {
+		columns = append(columns, column.Name)
+	}
+	if expected := []string{"Name", "Image", "Message", "Phase", "Age"}; !reflect.DeepEqual(columns, expected) {
+		t.Errorf("expected columns %v, got %v", expected, columns)
+	}
+	expected := [][]interface{}{
+		{"ready", "busybox:1.36", "hello world", "Ready", "60m"},
+		{"processing", "busybox:1.37", "hello there", "Processing", "60m"},
+	}
+	if len(table.Rows) != len(expected) {
+		t.Fatalf("expected %d rows, got %d", len(expected), len(table.Rows))
+	}
+	for i, row := range table.Rows {
+		if !reflect.DeepEqual(row.Cells, expected[i]) {
+			t.Errorf("row %d: expected %v, got %v", i, expected[i], row.Cells)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package foo
+
+import (
+	"context"
+
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metatable "k8s.io/apimachinery/pkg/api/meta/table"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/registry/rest"
+)
+
+var swaggerMetadataDescriptions = metav1.ObjectMeta{}.SwaggerDoc()
+
+// NewTableConvertor returns a rest.TableConvertor printing Image, Message, Phase and Age of foos.
+func NewTableConvertor() rest.TableConvertor {
+	return fooTableConvertor{}
+}
+
+type fooTableConvertor struct{}
+
+// ConvertToTable implements the TableConvertor interface for Foo.
+func (fooTableConvertor) ConvertToTable(_ context.Context, obj runtime.Object, _ runtime.Object) (*metav1.Table, error) {
+	table := &metav1.Table{
+		ColumnDefinitions: []metav1.TableColumnDefinition{
+			{Name: "Name", Type: "string", Format: "name", Description: swaggerMetadataDescriptions["name"]},
+			{Name: "Image", Type: "string", Description: "Container image that the container is running to do our foo work."},
+			{Name: "Message", Type: "string", Description: "Message says hello world!"},
+			{Name: "Phase", Type: "string", Description: "A simple, high-level summary of where the Foo is in its lifecycle."},
+			{Name: "Age", Type: "string", Description: swaggerMetadataDescriptions["creationTimestamp"]},
+		},
+	}
+	if m, err := meta.ListAccessor(obj); err == nil {
+		table.ResourceVersion = m.GetResourceVersion()
+		table.Continue = m.GetContinue()
+		table.RemainingItemCount = m.GetRemainingItemCount()
+	} else {
+		if m, err := meta.CommonAccessor(obj); err == nil {
+			table.ResourceVersion = m.GetResourceVersion()
+		}
+	}
+
+	var err error
+	table.Rows, err = metatable.MetaToTableRow(obj, func(obj runtime.Object, _ metav1.Object, name, age string) ([]interface{}, error) {
+		foo := obj.(*greeting.Foo)
+		return []interface{}{name, foo.Spec.Image, foo.Spec.Config.Message, string(foo.Status.Phase), age}, nil
+	})
+	return table, err
+}
@@ -0,0 +1,10 @@
+package registry
+
+import (
+	genericregistry "k8s.io/apiserver/pkg/registry/generic/registry"
+)
+
+// REST implements a RESTStorage for API services against etcd
+type REST struct {
+	*genericregistry.Store
+}
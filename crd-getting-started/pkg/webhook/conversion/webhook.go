@@ -0,0 +1,133 @@
+package conversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/klog/v2"
+)
+
+// maxRequestBodyBytes bounds the size of a ConversionReview accepted by the webhook.
+const maxRequestBodyBytes = 3 * 1024 * 1024
+
+// Webhook serves apiextensions.k8s.io/v1 ConversionReview requests, converting
+// every object through the internal version registered in its scheme.
+type Webhook struct {
+	scheme       *runtime.Scheme
+	deserializer runtime.Decoder
+}
+
+var _ http.Handler = &Webhook{}
+
+// NewWebhook returns a Webhook converting objects with the given scheme, which
+// is expected to have been populated by install.Install.
+func NewWebhook(scheme *runtime.Scheme) *Webhook {
+	return &Webhook{
+		scheme:       scheme,
+		deserializer: serializer.NewCodecFactory(scheme).UniversalDeserializer(),
+	}
+}
+
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(rw, fmt.Sprintf("method %s is not allowed", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	if mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err != nil || mediaType != runtime.ContentTypeJSON {
+		http.Error(rw, fmt.Sprintf("content type %q is not supported, expected %q",
+			req.Header.Get("Content-Type"), runtime.ContentTypeJSON), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxRequestBodyBytes))
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("failed to read request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	review := &apiextensionsv1.ConversionReview{}
+	if err := json.Unmarshal(body, review); err != nil {
+		http.Error(rw, fmt.Sprintf("failed to decode ConversionReview: %v", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(rw, "ConversionReview has no request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = w.Convert(review.Request)
+	review.Request = nil
+	review.SetGroupVersionKind(apiextensionsv1.SchemeGroupVersion.WithKind("ConversionReview"))
+
+	rw.Header().Set("Content-Type", runtime.ContentTypeJSON)
+	if err := json.NewEncoder(rw).Encode(review); err != nil {
+		klog.ErrorS(err, "Failed to write ConversionReview response", "uid", review.Response.UID)
+	}
+}
+
+// Convert converts all objects of the request to its desired API version. The
+// response reports a failure if any object cannot be converted, as the API
+// server discards partial results anyway.
+func (w *Webhook) Convert(request *apiextensionsv1.ConversionRequest) *apiextensionsv1.ConversionResponse {
+	response := &apiextensionsv1.ConversionResponse{UID: request.UID}
+
+	desired, err := schema.ParseGroupVersion(request.DesiredAPIVersion)
+	if err != nil {
+		response.Result = failure(err)
+		return response
+	}
+
+	converted := make([]runtime.RawExtension, 0, len(request.Objects))
+	for i := range request.Objects {
+		obj, err := w.convertObject(request.Objects[i].Raw, desired)
+		if err != nil {
+			klog.V(2).InfoS("Failed to convert object", "uid", request.UID, "index", i, "err", err)
+			response.Result = failure(fmt.Errorf("objects[%d]: %w", i, err))
+			return response
+		}
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			response.Result = failure(fmt.Errorf("objects[%d]: %w", i, err))
+			return response
+		}
+		converted = append(converted, runtime.RawExtension{Raw: raw})
+	}
+
+	response.ConvertedObjects = converted
+	response.Result = metav1.Status{Status: metav1.StatusSuccess}
+	return response
+}
+
+// convertObject decodes raw and converts it to desired, hopping through the
+// internal version since conversion functions are only registered between each
+// external version and the internal one. Objects are not defaulted, a missing
+// image is set by the DefaultFooImage admission plugin when they are written.
+func (w *Webhook) convertObject(raw []byte, desired schema.GroupVersion) (runtime.Object, error) {
+	obj, gvk, err := w.deserializer.Decode(raw, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if gvk.GroupVersion() == desired {
+		return obj, nil
+	}
+	if gvk.Group != desired.Group {
+		return nil, fmt.Errorf("cannot convert %s to group %q", gvk, desired.Group)
+	}
+
+	internal, err := w.scheme.ConvertToVersion(obj, runtime.InternalGroupVersioner)
+	if err != nil {
+		return nil, err
+	}
+	return w.scheme.ConvertToVersion(internal, desired)
+}
+
+func failure(err error) metav1.Status {
+	return metav1.Status{Status: metav1.StatusFailure, Message: err.Error()}
+}
@@ -0,0 +1,255 @@
+package conversion
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/install"
+	greetingv1 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v1"
+	greetingv2 "github.com/foenye/cloud-native-tour/crd-getting-started/pkg/apis/greeting/v2"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	install.Install(scheme)
+	server := httptest.NewServer(NewWebhook(scheme))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// review posts objs to the webhook and returns the response, failing the test
+// on transport errors only.
+func review(t *testing.T, server *httptest.Server, desired string, objs ...runtime.Object) *apiextensionsv1.ConversionResponse {
+	t.Helper()
+	request := &apiextensionsv1.ConversionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: apiextensionsv1.SchemeGroupVersion.String(), Kind: "ConversionReview"},
+		Request:  &apiextensionsv1.ConversionRequest{UID: types.UID("uid"), DesiredAPIVersion: desired},
+	}
+	for _, obj := range objs {
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Request.Objects = append(request.Request.Objects, runtime.RawExtension{Raw: raw})
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Post(server.URL, runtime.ContentTypeJSON, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+	response := &apiextensionsv1.ConversionReview{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Response == nil || response.Response.UID != request.Request.UID {
+		t.Fatalf("unexpected response %#v", response.Response)
+	}
+	return response.Response
+}
+
+func decodeInto(t *testing.T, response *apiextensionsv1.ConversionResponse, objs ...any) {
+	t.Helper()
+	if response.Result.Status != metav1.StatusSuccess {
+		t.Fatalf("conversion failed: %s", response.Result.Message)
+	}
+	if len(response.ConvertedObjects) != len(objs) {
+		t.Fatalf("expected %d converted objects, got %d", len(objs), len(response.ConvertedObjects))
+	}
+	for i := range objs {
+		if err := json.Unmarshal(response.ConvertedObjects[i].Raw, objs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestConvertRoundTripFromV1(t *testing.T) {
+	server := newTestServer(t)
+	original := &greetingv1.Foo{
+		TypeMeta: metav1.TypeMeta{APIVersion: greetingv1.GroupVersion.String(), Kind: "Foo"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foo",
+			Namespace:   "default",
+			Labels:      map[string]string{"greeting.foen.ye/metadata.name": "foo"},
+			Annotations: map[string]string{greetingv1.AnnotationImage: "nginx:1.27"},
+		},
+		Spec: greetingv1.FooSpec{Message: "hello", Description: "a foo"},
+	}
+
+	upgraded := &greetingv2.Foo{}
+	decodeInto(t, review(t, server, greetingv2.GroupVersion.String(), original), upgraded)
+	if upgraded.APIVersion != greetingv2.GroupVersion.String() || upgraded.Kind != "Foo" {
+		t.Errorf("unexpected type meta %#v", upgraded.TypeMeta)
+	}
+	if upgraded.Spec.Image != "nginx:1.27" || upgraded.Spec.Config.Message != "hello" || upgraded.Spec.Config.Description != "a foo" {
+		t.Errorf("unexpected spec %#v", upgraded.Spec)
+	}
+	if _, ok := upgraded.Annotations[greetingv1.AnnotationImage]; ok {
+		t.Errorf("unexpected %s annotation on a v2 object", greetingv1.AnnotationImage)
+	}
+
+	roundTripped := &greetingv1.Foo{}
+	decodeInto(t, review(t, server, greetingv1.GroupVersion.String(), upgraded), roundTripped)
+	if !apiequality.Semantic.DeepEqual(original, roundTripped) {
+		t.Errorf("round trip mismatch:\nexpected %#v\ngot      %#v", original, roundTripped)
+	}
+}
+
+func TestConvertRoundTripFromV2(t *testing.T) {
+	server := newTestServer(t)
+	original := &greetingv2.Foo{
+		TypeMeta: metav1.TypeMeta{APIVersion: greetingv2.GroupVersion.String(), Kind: "Foo"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foo",
+			Namespace:   "default",
+			Labels:      map[string]string{"greeting.foen.ye/metadata.name": "foo"},
+			Annotations: map[string]string{"team": "greeting"},
+		},
+		Spec: greetingv2.FooSpec{
+			Image:  "nginx:1.27",
+			Config: greetingv2.FooConfig{Message: "hello", Description: "a foo"},
+		},
+		Status: greetingv2.FooStatus{
+			Phase: greetingv2.FooPhaseReady,
+			Conditions: []greetingv2.FooCondition{{
+				Type:               greetingv2.FooConditionTypeWorker,
+				Status:             metav1.ConditionTrue,
+				LastTransitionTime: metav1.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+				Reason:             "DeploymentAvailable",
+			}},
+			ObservedGeneration: 2,
+		},
+	}
+
+	downgraded := &greetingv1.Foo{}
+	decodeInto(t, review(t, server, greetingv1.GroupVersion.String(), original), downgraded)
+	if downgraded.Annotations[greetingv1.AnnotationImage] != "nginx:1.27" || downgraded.Spec.Message != "hello" {
+		t.Errorf("unexpected v1 object %#v", downgraded)
+	}
+
+	roundTripped := &greetingv2.Foo{}
+	decodeInto(t, review(t, server, greetingv2.GroupVersion.String(), downgraded), roundTripped)
+	if !apiequality.Semantic.DeepEqual(original, roundTripped) {
+		t.Errorf("round trip mismatch:\nexpected %#v\ngot      %#v", original, roundTripped)
+	}
+}
+
+// TestConvertDoesNotDefault checks that conversion leaves the objects it
+// converts otherwise untouched, it runs on every read of stored foos.
+func TestConvertDoesNotDefault(t *testing.T) {
+	server := newTestServer(t)
+	original := &greetingv1.Foo{
+		TypeMeta:   metav1.TypeMeta{APIVersion: greetingv1.GroupVersion.String(), Kind: "Foo"},
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Spec:       greetingv1.FooSpec{Message: "hello"},
+	}
+
+	upgraded := &greetingv2.Foo{}
+	decodeInto(t, review(t, server, greetingv2.GroupVersion.String(), original), upgraded)
+	if upgraded.Spec.Image != "" || len(upgraded.Labels) != 0 {
+		t.Errorf("expected no defaulting, got image %q and labels %v", upgraded.Spec.Image, upgraded.Labels)
+	}
+}
+
+func TestConvertMultipleObjects(t *testing.T) {
+	server := newTestServer(t)
+	first := &greetingv1.Foo{
+		TypeMeta:   metav1.TypeMeta{APIVersion: greetingv1.GroupVersion.String(), Kind: "Foo"},
+		ObjectMeta: metav1.ObjectMeta{Name: "first"},
+	}
+	second := &greetingv2.Foo{
+		TypeMeta:   metav1.TypeMeta{APIVersion: greetingv2.GroupVersion.String(), Kind: "Foo"},
+		ObjectMeta: metav1.ObjectMeta{Name: "second"},
+	}
+
+	converted := []*greetingv2.Foo{{}, {}}
+	decodeInto(t, review(t, server, greetingv2.GroupVersion.String(), first, second), converted[0], converted[1])
+	for i, name := range []string{"first", "second"} {
+		if converted[i].Name != name || converted[i].APIVersion != greetingv2.GroupVersion.String() {
+			t.Errorf("objects[%d]: unexpected object %#v", i, converted[i])
+		}
+	}
+}
+
+func TestConvertFailures(t *testing.T) {
+	server := newTestServer(t)
+	foo := &greetingv1.Foo{
+		TypeMeta:   metav1.TypeMeta{APIVersion: greetingv1.GroupVersion.String(), Kind: "Foo"},
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+	}
+	unknown := &metav1.PartialObjectMetadata{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "unknown.foen.ye/v1", Kind: "Foo"},
+		ObjectMeta: metav1.ObjectMeta{Name: "unknown"},
+	}
+
+	testCases := map[string]struct {
+		desired string
+		objs    []runtime.Object
+	}{
+		"unknown desired version": {desired: "greeting.foen.ye/v3", objs: []runtime.Object{foo}},
+		"other desired group":     {desired: "apps/v1", objs: []runtime.Object{foo}},
+		"invalid desired version": {desired: "a/b/c", objs: []runtime.Object{foo}},
+		"unknown object kind":     {desired: greetingv2.GroupVersion.String(), objs: []runtime.Object{foo, unknown}},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			response := review(t, server, tc.desired, tc.objs...)
+			if response.Result.Status != metav1.StatusFailure || response.Result.Message == "" {
+				t.Errorf("expected failure, got %#v", response.Result)
+			}
+			if len(response.ConvertedObjects) != 0 {
+				t.Errorf("expected no converted objects, got %d", len(response.ConvertedObjects))
+			}
+		})
+	}
+}
+
+func TestServeHTTPRejectsMalformedRequests(t *testing.T) {
+	server := newTestServer(t)
+
+	testCases := map[string]struct {
+		method      string
+		contentType string
+		body        string
+		code        int
+	}{
+		"wrong method":       {method: http.MethodGet, contentType: runtime.ContentTypeJSON, code: http.StatusMethodNotAllowed},
+		"wrong content type": {method: http.MethodPost, contentType: "text/plain", body: "{}", code: http.StatusUnsupportedMediaType},
+		"malformed body":     {method: http.MethodPost, contentType: runtime.ContentTypeJSON, body: "{", code: http.StatusBadRequest},
+		"missing request":    {method: http.MethodPost, contentType: runtime.ContentTypeJSON, body: "{}", code: http.StatusBadRequest},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, server.URL, bytes.NewBufferString(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", tc.contentType)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != tc.code {
+				t.Errorf("expected status code %d, got %d", tc.code, resp.StatusCode)
+			}
+		})
+	}
+}
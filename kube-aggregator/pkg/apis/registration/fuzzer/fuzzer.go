@@ -0,0 +1,20 @@
+package fuzzer
+
+import (
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	"sigs.k8s.io/randfill"
+)
+
+// Funcs returns the fuzzer functions for the registration api group.
+var Funcs = func(codecs runtimeserializer.CodecFactory) []interface{} {
+	return []interface{}{
+		func(obj *registration.ServiceReference, c randfill.Continue) {
+			c.FillNoCustom(obj) // fuzz self without calling this function again
+			// A ServiceReference without a port is defaulted to 443 by the external versions.
+			if obj.Port == 0 {
+				obj.Port = 443
+			}
+		},
+	}
+}
@@ -0,0 +1,76 @@
+package install
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	registrationv1beta1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// selectableFields are the field selectors of APIServices besides metadata.name.
+var selectableFields = []string{"spec.group", "spec.service.namespace"}
+
+func TestFieldLabelConversion(t *testing.T) {
+	for _, gvk := range []schema.GroupVersionKind{
+		registrationv1.SchemeGroupVersion.WithKind("APIService"),
+		registrationv1beta1.SchemeGroupVersion.WithKind("APIService"),
+	} {
+		for _, label := range append([]string{"metadata.name"}, selectableFields...) {
+			if _, _, err := Scheme.ConvertFieldLabel(gvk, label, "value"); err != nil {
+				t.Errorf("%s: expected %s to be supported: %v", gvk, label, err)
+			}
+		}
+		for _, label := range []string{"spec.version", "status.conditions[Available]"} {
+			if _, _, err := Scheme.ConvertFieldLabel(gvk, label, "value"); err == nil {
+				t.Errorf("%s: expected %s to be rejected", gvk, label)
+			}
+		}
+	}
+}
+
+// TestCRDSelectableFieldsAndColumns checks that the APIService CRD serves the
+// field selectors the field label conversion accepts, and the columns
+// kubectl shows for APIServices.
+func TestCRDSelectableFieldsAndColumns(t *testing.T) {
+	data, err := os.ReadFile("../../../../config/crd/bases/registration.foen.ye_apiservices.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	crd := struct {
+		Spec struct {
+			Versions []struct {
+				Name                     string
+				AdditionalPrinterColumns []struct{ Name string }
+				SelectableFields         []struct{ JSONPath string }
+			}
+		}
+	}{}
+	if err := yaml.Unmarshal(data, &crd); err != nil {
+		t.Fatal(err)
+	}
+
+	var expectedSelectable []string
+	for _, field := range selectableFields {
+		expectedSelectable = append(expectedSelectable, "."+field)
+	}
+	expectedColumns := []string{"Service", "Available", "Age"}
+	for _, version := range crd.Spec.Versions {
+		var selectable, printed []string
+		for _, field := range version.SelectableFields {
+			selectable = append(selectable, field.JSONPath)
+		}
+		for _, column := range version.AdditionalPrinterColumns {
+			printed = append(printed, column.Name)
+		}
+		if !reflect.DeepEqual(selectable, expectedSelectable) {
+			t.Errorf("%s: expected selectable fields %v, got %v", version.Name, expectedSelectable, selectable)
+		}
+		if !reflect.DeepEqual(printed, expectedColumns) {
+			t.Errorf("%s: expected printer columns %v, got %v", version.Name, expectedColumns, printed)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package install
+
+import (
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration"
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	registrationv1beta1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+var (
+	// Scheme holds the internal, v1 and v1beta1 registration types.
+	Scheme = runtime.NewScheme()
+	// Codecs provides the JSON, YAML and protobuf serializers of Scheme. The
+	// generated clientset prefers protobuf, so servers need all three.
+	Codecs = serializer.NewCodecFactory(Scheme)
+)
+
+func init() {
+	Install(Scheme)
+}
+
+// Install registers the registration API group, preferring v1 over v1beta1.
+func Install(scheme *runtime.Scheme) {
+	utilruntime.Must(registration.AddToScheme(scheme))
+	utilruntime.Must(registrationv1.Install(scheme))
+	utilruntime.Must(registrationv1beta1.Install(scheme))
+	utilruntime.Must(scheme.SetVersionPriority(registrationv1.SchemeGroupVersion, registrationv1beta1.SchemeGroupVersion))
+}
@@ -0,0 +1,81 @@
+package install
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration"
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	registrationv1beta1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const v1beta1APIService = `{
+  "apiVersion": "registration.foen.ye/v1beta1",
+  "kind": "APIService",
+  "metadata": {"name": "v1.foo.example.com"},
+  "spec": {
+    "service": {"namespace": "test-ns", "name": "api"},
+    "group": "foo.example.com",
+    "version": "v1",
+    "insecureSkipTLSVerify": true,
+    "groupPriorityMinimum": 1000,
+    "versionPriority": 15
+  }
+}`
+
+func TestPreferredVersion(t *testing.T) {
+	versions := Scheme.PrioritizedVersionsForGroup(registration.GroupName)
+	if len(versions) != 2 || versions[0] != registrationv1.SchemeGroupVersion || versions[1] != registrationv1beta1.SchemeGroupVersion {
+		t.Errorf("expected versions [%v %v], got %v", registrationv1.SchemeGroupVersion, registrationv1beta1.SchemeGroupVersion, versions)
+	}
+}
+
+// TestDecodeV1beta1EncodeV1Protobuf decodes v1beta1 JSON to the internal
+// version, as a server receiving it would, and serves it back as v1 protobuf.
+func TestDecodeV1beta1EncodeV1Protobuf(t *testing.T) {
+	obj, err := runtime.Decode(Codecs.UniversalDecoder(), []byte(v1beta1APIService))
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiService, ok := obj.(*registration.APIService)
+	if !ok {
+		t.Fatalf("expected to decode *registration.APIService, got %T", obj)
+	}
+	if apiService.Spec.Service == nil || apiService.Spec.Service.Port != 443 {
+		t.Fatalf("expected the service port to default to 443, got %+v", apiService.Spec.Service)
+	}
+
+	info, ok := runtime.SerializerInfoForMediaType(Codecs.SupportedMediaTypes(), runtime.ContentTypeProtobuf)
+	if !ok {
+		t.Fatalf("no serializer for %s", runtime.ContentTypeProtobuf)
+	}
+	data, err := runtime.Encode(Codecs.EncoderForVersion(info.Serializer, registrationv1.SchemeGroupVersion), apiService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte{0x6b, 0x38, 0x73, 0x00}) {
+		t.Fatalf("expected protobuf encoding, got %q", data)
+	}
+
+	obj, err = runtime.Decode(Codecs.UniversalDeserializer(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1APIService, ok := obj.(*registrationv1.APIService)
+	if !ok {
+		t.Fatalf("expected to decode *v1.APIService, got %T", obj)
+	}
+	if gvk := v1APIService.GroupVersionKind(); gvk != registrationv1.SchemeGroupVersion.WithKind("APIService") {
+		t.Errorf("expected %v, got %v", registrationv1.SchemeGroupVersion.WithKind("APIService"), gvk)
+	}
+	spec := v1APIService.Spec
+	if v1APIService.Name != "v1.foo.example.com" || spec.Group != "foo.example.com" || spec.Version != "v1" ||
+		!spec.InsecureSkipTLSVerify || spec.GroupPriorityMinimum != 1000 || spec.VersionPriority != 15 {
+		t.Errorf("unexpected APIService after conversion: %+v", v1APIService)
+	}
+	if service := spec.Service; service == nil || service.Namespace != "test-ns" || service.Name != "api" ||
+		service.Port == nil || *service.Port != 443 {
+		t.Errorf("unexpected service after conversion: %+v", service)
+	}
+}
@@ -0,0 +1,98 @@
+package install
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration"
+	registrationfuzzer "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/fuzzer"
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	registrationv1beta1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1beta1"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	"k8s.io/apimachinery/pkg/api/apitesting/roundtrip"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+)
+
+func newScheme() (*runtime.Scheme, runtimeserializer.CodecFactory) {
+	scheme := runtime.NewScheme()
+	Install(scheme)
+	return scheme, runtimeserializer.NewCodecFactory(scheme)
+}
+
+func TestRoundTripTypes(t *testing.T) {
+	roundtrip.RoundTripProtobufTestForAPIGroup(t, Install, registrationfuzzer.Funcs)
+}
+
+func TestRoundTripExternalTypes(t *testing.T) {
+	scheme, codecs := newScheme()
+	f := fuzzer.FuzzerFor(fuzzer.MergeFuzzerFuncs(metafuzzer.Funcs, registrationfuzzer.Funcs), rand.NewSource(rand.Int63()), codecs)
+
+	roundtrip.RoundTripExternalTypes(t, scheme, codecs, f, nil)
+}
+
+func TestRoundTripYAML(t *testing.T) {
+	_, codecs := newScheme()
+	f := fuzzer.FuzzerFor(fuzzer.MergeFuzzerFuncs(metafuzzer.Funcs, registrationfuzzer.Funcs), rand.NewSource(rand.Int63()), codecs)
+	info, ok := runtime.SerializerInfoForMediaType(codecs.SupportedMediaTypes(), runtime.ContentTypeYAML)
+	if !ok {
+		t.Fatalf("no serializer for %s", runtime.ContentTypeYAML)
+	}
+
+	for _, gv := range []schema.GroupVersion{registrationv1.SchemeGroupVersion, registrationv1beta1.SchemeGroupVersion} {
+		t.Run(gv.Version, func(t *testing.T) {
+			codec := codecs.CodecForVersions(info.Serializer, codecs.UniversalDecoder(), gv, runtime.InternalGroupVersioner)
+			for i := 0; i < *roundtrip.FuzzIters; i++ {
+				for _, original := range []runtime.Object{&registration.APIService{}, &registration.APIServiceList{}} {
+					f.Fill(original)
+					data, err := runtime.Encode(codec, original)
+					if err != nil {
+						t.Fatalf("failed to encode %T: %v", original, err)
+					}
+					decoded, err := runtime.Decode(codec, data)
+					if err != nil {
+						t.Fatalf("failed to decode %T: %v\n%s", original, err, data)
+					}
+					if !apiequality.Semantic.DeepEqual(original, decoded) {
+						t.Fatalf("%T changed in a YAML round trip (-want +got):\n%s", original, cmp.Diff(original, decoded))
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestRoundTripV1V1beta1 converts internal -> v1 -> v1beta1 -> v1 -> internal.
+// Versions convert through the internal version.
+func TestRoundTripV1V1beta1(t *testing.T) {
+	scheme, codecs := newScheme()
+	f := fuzzer.FuzzerFor(fuzzer.MergeFuzzerFuncs(metafuzzer.Funcs, registrationfuzzer.Funcs), rand.NewSource(rand.Int63()), codecs)
+
+	for i := 0; i < *roundtrip.FuzzIters; i++ {
+		original := &registration.APIService{}
+		f.Fill(original)
+
+		var obj runtime.Object = original.DeepCopy()
+		for _, gv := range []runtime.GroupVersioner{
+			registrationv1.SchemeGroupVersion,
+			runtime.InternalGroupVersioner,
+			registrationv1beta1.SchemeGroupVersion,
+			runtime.InternalGroupVersioner,
+			registrationv1.SchemeGroupVersion,
+			runtime.InternalGroupVersioner,
+		} {
+			converted, err := scheme.ConvertToVersion(obj, gv)
+			if err != nil {
+				t.Fatalf("failed to convert %T to %v: %v", obj, gv, err)
+			}
+			obj = converted
+		}
+		if !apiequality.Semantic.DeepEqual(original, obj) {
+			t.Fatalf("APIService changed in a v1 <-> v1beta1 round trip (-want +got):\n%s", cmp.Diff(original, obj))
+		}
+	}
+}
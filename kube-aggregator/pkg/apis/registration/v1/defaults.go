@@ -5,6 +5,15 @@ import (
 	"k8s.io/utils/ptr"
 )
 
+// DefaultServicePort is the port of a ServiceReference without one. Unset
+// ports are defaulted to it, consumers of ServiceReferences not known to be
+// defaulted fall back to it.
+const DefaultServicePort int32 = 443
+
+func init() {
+	localSchemeBuilder.Register(addDefaultingFuncs)
+}
+
 func addDefaultingFuncs(scheme *runtime.Scheme) error {
 	return RegisterDefaults(scheme)
 }
@@ -14,6 +23,6 @@ func addDefaultingFuncs(scheme *runtime.Scheme) error {
 //goland:noinspection GoUnusedExportedFunction,GoSnakeCaseUsage
 func SetDefaults_ServiceReference(obj *ServiceReference) {
 	if obj.Port == nil {
-		obj.Port = ptr.To[int32](443)
+		obj.Port = ptr.To(DefaultServicePort)
 	}
 }
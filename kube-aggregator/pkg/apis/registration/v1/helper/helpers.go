@@ -0,0 +1,108 @@
+package helper
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+// SortedByGroupAndVersion groups apiServices by API group. Groups are ordered
+// by the highest GroupPriorityMinimum of their APIServices, and then by name.
+// The APIServices of a group are ordered by VersionPriority, and then by
+// kube-like version, so the first one is the preferred version of its group.
+func SortedByGroupAndVersion(apiServices []*registrationv1.APIService) [][]*registrationv1.APIService {
+	sorted := slices.Clone(apiServices)
+	slices.SortFunc(sorted, compareByGroupPriorityMinimum)
+
+	var groups [][]*registrationv1.APIService
+	groupIndex := map[string]int{}
+	for _, apiService := range sorted {
+		i, exists := groupIndex[apiService.Spec.Group]
+		if !exists {
+			i = len(groups)
+			groupIndex[apiService.Spec.Group] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], apiService)
+	}
+	for _, group := range groups {
+		slices.SortFunc(group, compareByVersionPriority)
+	}
+	return groups
+}
+
+// compareByGroupPriorityMinimum orders APIServices by descending
+// GroupPriorityMinimum, and then by name.
+func compareByGroupPriorityMinimum(a, b *registrationv1.APIService) int {
+	return cmp.Or(
+		cmp.Compare(b.Spec.GroupPriorityMinimum, a.Spec.GroupPriorityMinimum),
+		strings.Compare(a.Name, b.Name),
+	)
+}
+
+// compareByVersionPriority orders APIServices by descending VersionPriority,
+// and then by descending kube-like version, e.g. v2, v1, v1beta1, v1alpha1, foo1.
+func compareByVersionPriority(a, b *registrationv1.APIService) int {
+	return cmp.Or(
+		cmp.Compare(b.Spec.VersionPriority, a.Spec.VersionPriority),
+		version.CompareKubeAwareVersionStrings(b.Spec.Version, a.Spec.Version),
+	)
+}
+
+// NewAPIServiceCondition returns a condition of the given type and status whose
+// LastTransitionTime is now.
+func NewAPIServiceCondition(conditionType registrationv1.APIServiceConditionType,
+	status registrationv1.ConditionStatus, reason, message string) registrationv1.APIServiceCondition {
+	return registrationv1.APIServiceCondition{
+		Type:               conditionType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// SetAPIServiceCondition sets the condition of apiService, overwriting the
+// existing one of the same type or appending a new one. LastTransitionTime is
+// only updated when the status of the condition changes, and defaults to now
+// when unset.
+func SetAPIServiceCondition(apiService *registrationv1.APIService, newCondition registrationv1.APIServiceCondition) {
+	if newCondition.LastTransitionTime.IsZero() {
+		newCondition.LastTransitionTime = metav1.Now()
+	}
+
+	existingCondition := GetAPIServiceConditionByType(apiService, newCondition.Type)
+	if existingCondition == nil {
+		apiService.Status.Conditions = append(apiService.Status.Conditions, newCondition)
+		return
+	}
+	if existingCondition.Status != newCondition.Status {
+		existingCondition.Status = newCondition.Status
+		existingCondition.LastTransitionTime = newCondition.LastTransitionTime
+	}
+	existingCondition.Reason = newCondition.Reason
+	existingCondition.Message = newCondition.Message
+}
+
+// GetAPIServiceConditionByType returns the condition of apiService with the
+// given type, or nil if it is not present.
+func GetAPIServiceConditionByType(apiService *registrationv1.APIService,
+	conditionType registrationv1.APIServiceConditionType) *registrationv1.APIServiceCondition {
+	for i := range apiService.Status.Conditions {
+		if apiService.Status.Conditions[i].Type == conditionType {
+			return &apiService.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsAPIServiceConditionTrue indicates if the condition of the given type is
+// present and strictly true.
+func IsAPIServiceConditionTrue(apiService *registrationv1.APIService, conditionType registrationv1.APIServiceConditionType) bool {
+	condition := GetAPIServiceConditionByType(apiService, conditionType)
+	return condition != nil && condition.Status == registrationv1.ConditionTrue
+}
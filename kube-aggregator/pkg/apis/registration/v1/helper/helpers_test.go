@@ -0,0 +1,165 @@
+package helper
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	earlier = metav1.NewTime(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC))
+	later   = metav1.NewTime(time.Date(2025, time.January, 2, 0, 0, 0, 0, time.UTC))
+)
+
+func apiServiceWithConditions(conditions ...registrationv1.APIServiceCondition) *registrationv1.APIService {
+	return &registrationv1.APIService{Status: registrationv1.APIServiceStatus{Conditions: conditions}}
+}
+
+func TestSetAPIServiceCondition(t *testing.T) {
+	testCases := map[string]struct {
+		apiService *registrationv1.APIService
+		condition  registrationv1.APIServiceCondition
+		expected   []registrationv1.APIServiceCondition
+	}{
+		"appends missing condition": {
+			apiService: apiServiceWithConditions(),
+			condition:  registrationv1.APIServiceCondition{Type: registrationv1.Available, Status: registrationv1.ConditionTrue, LastTransitionTime: later, Reason: "Passed"},
+			expected: []registrationv1.APIServiceCondition{
+				{Type: registrationv1.Available, Status: registrationv1.ConditionTrue, LastTransitionTime: later, Reason: "Passed"},
+			},
+		},
+		"bumps transition time on status change": {
+			apiService: apiServiceWithConditions(
+				registrationv1.APIServiceCondition{Type: registrationv1.Available, Status: registrationv1.ConditionTrue, LastTransitionTime: earlier, Reason: "Passed"},
+			),
+			condition: registrationv1.APIServiceCondition{Type: registrationv1.Available, Status: registrationv1.ConditionFalse, LastTransitionTime: later, Reason: "FailedDiscoveryCheck", Message: "timeout"},
+			expected: []registrationv1.APIServiceCondition{
+				{Type: registrationv1.Available, Status: registrationv1.ConditionFalse, LastTransitionTime: later, Reason: "FailedDiscoveryCheck", Message: "timeout"},
+			},
+		},
+		"keeps transition time when status is unchanged": {
+			apiService: apiServiceWithConditions(
+				registrationv1.APIServiceCondition{Type: registrationv1.Available, Status: registrationv1.ConditionFalse, LastTransitionTime: earlier, Reason: "ServiceAccessError"},
+			),
+			condition: registrationv1.APIServiceCondition{Type: registrationv1.Available, Status: registrationv1.ConditionFalse, LastTransitionTime: later, Reason: "FailedDiscoveryCheck", Message: "timeout"},
+			expected: []registrationv1.APIServiceCondition{
+				{Type: registrationv1.Available, Status: registrationv1.ConditionFalse, LastTransitionTime: earlier, Reason: "FailedDiscoveryCheck", Message: "timeout"},
+			},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			SetAPIServiceCondition(tc.apiService, tc.condition)
+			if len(tc.apiService.Status.Conditions) != len(tc.expected) {
+				t.Fatalf("expected %d conditions, got %#v", len(tc.expected), tc.apiService.Status.Conditions)
+			}
+			for i := range tc.expected {
+				actual := tc.apiService.Status.Conditions[i]
+				if actual.Type != tc.expected[i].Type || actual.Status != tc.expected[i].Status ||
+					!actual.LastTransitionTime.Equal(&tc.expected[i].LastTransitionTime) ||
+					actual.Reason != tc.expected[i].Reason || actual.Message != tc.expected[i].Message {
+					t.Errorf("conditions[%d]: expected %#v, got %#v", i, tc.expected[i], actual)
+				}
+			}
+		})
+	}
+}
+
+func TestSetAPIServiceConditionDefaultsTransitionTime(t *testing.T) {
+	apiService := apiServiceWithConditions()
+	SetAPIServiceCondition(apiService, registrationv1.APIServiceCondition{Type: registrationv1.Available, Status: registrationv1.ConditionTrue})
+	if apiService.Status.Conditions[0].LastTransitionTime.IsZero() {
+		t.Error("expected LastTransitionTime to be set")
+	}
+}
+
+func TestIsAPIServiceConditionTrue(t *testing.T) {
+	if !IsAPIServiceConditionTrue(apiServiceWithConditions(
+		registrationv1.APIServiceCondition{Type: registrationv1.Available, Status: registrationv1.ConditionTrue},
+	), registrationv1.Available) {
+		t.Error("expected Available to be true")
+	}
+	if IsAPIServiceConditionTrue(apiServiceWithConditions(
+		registrationv1.APIServiceCondition{Type: registrationv1.Available, Status: registrationv1.ConditionUnknown},
+	), registrationv1.Available) {
+		t.Error("expected unknown Available not to be true")
+	}
+	if IsAPIServiceConditionTrue(apiServiceWithConditions(), registrationv1.Available) {
+		t.Error("expected missing condition not to be true")
+	}
+}
+
+func newAPIService(group, version string, groupPriorityMinimum, versionPriority int32) *registrationv1.APIService {
+	return &registrationv1.APIService{
+		ObjectMeta: metav1.ObjectMeta{Name: version + "." + group},
+		Spec: registrationv1.APIServiceSpec{
+			Group:                group,
+			Version:              version,
+			GroupPriorityMinimum: groupPriorityMinimum,
+			VersionPriority:      versionPriority,
+		},
+	}
+}
+
+func TestSortedByGroupAndVersion(t *testing.T) {
+	testCases := map[string]struct {
+		apiServices []*registrationv1.APIService
+		expected    [][]string
+	}{
+		"groups by highest priority": {
+			apiServices: []*registrationv1.APIService{
+				newAPIService("foo", "v1", 10, 15),
+				newAPIService("bar", "v1", 15, 15),
+				newAPIService("foo", "v2", 20, 15),
+			},
+			expected: [][]string{{"v2.foo", "v1.foo"}, {"v1.bar"}},
+		},
+		"groups of the same priority by name": {
+			apiServices: []*registrationv1.APIService{
+				newAPIService("foo", "v1", 10, 15),
+				newAPIService("bar", "v1", 10, 15),
+				newAPIService("baz", "v1", 10, 15),
+			},
+			expected: [][]string{{"v1.bar"}, {"v1.baz"}, {"v1.foo"}},
+		},
+		"versions by priority": {
+			apiServices: []*registrationv1.APIService{
+				newAPIService("foo", "v2", 10, 10),
+				newAPIService("foo", "v1", 10, 20),
+				newAPIService("foo", "v3alpha1", 10, 15),
+			},
+			expected: [][]string{{"v1.foo", "v3alpha1.foo", "v2.foo"}},
+		},
+		"versions of the same priority by kube-like version": {
+			apiServices: []*registrationv1.APIService{
+				newAPIService("foo", "foo10", 10, 15),
+				newAPIService("foo", "v1beta1", 10, 15),
+				newAPIService("foo", "v12alpha1", 10, 15),
+				newAPIService("foo", "v1", 10, 15),
+				newAPIService("foo", "foo1", 10, 15),
+				newAPIService("foo", "v10", 10, 15),
+				newAPIService("foo", "v11beta2", 10, 15),
+				newAPIService("foo", "v2", 10, 15),
+			},
+			expected: [][]string{{"v10.foo", "v2.foo", "v1.foo", "v11beta2.foo", "v1beta1.foo", "v12alpha1.foo", "foo1.foo", "foo10.foo"}},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var actual [][]string
+			for _, group := range SortedByGroupAndVersion(tc.apiServices) {
+				var names []string
+				for _, apiService := range group {
+					names = append(names, apiService.Name)
+				}
+				actual = append(actual, names)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -6,6 +6,11 @@ import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 // +genclient:nonNamespaced
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +k8s:prerelease-lifecycle-gen:introduced=1.10
+// +kubebuilder:printcolumn:name="Service",type=string,JSONPath=`.spec.service.name`,description="The name of the service that hosts this API endpoint, empty when served locally."
+// +kubebuilder:printcolumn:name="Available",type=string,JSONPath=`.status.conditions[?(@.type=="Available")].status`,description="Whether this service is available."
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:selectablefield:JSONPath=`.spec.group`
+// +kubebuilder:selectablefield:JSONPath=`.spec.service.namespace`
 
 // APIService represents a server for a particular GroupVersion.
 // Name must be "version.group".
@@ -0,0 +1,26 @@
+package v1beta1
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func init() {
+	localSchemeBuilder.Register(addFieldLabelConversionFuncs)
+}
+
+// addFieldLabelConversionFuncs registers the field selectors of APIServices,
+// which are named the same in every version. Besides metadata.name, they are
+// the selectableFields of the APIService CRD, which serves APIServices.
+func addFieldLabelConversionFuncs(scheme *runtime.Scheme) error {
+	return scheme.AddFieldLabelConversionFunc(SchemeGroupVersion.WithKind("APIService"),
+		func(label, value string) (string, string, error) {
+			switch label {
+			case "metadata.name", "spec.group", "spec.service.namespace":
+				return label, value, nil
+			default:
+				return "", "", fmt.Errorf("field label not supported: %s", label)
+			}
+		})
+}
@@ -8,6 +8,11 @@ import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 // +k8s:prerelease-lifecycle-gen:introduced=1.7
 // +k8s:prerelease-lifecycle-gen:deprecated=1.19
 // +k8s:prerelease-lifecycle-gen:replacement=apiregistration.k8s.io,v1,APIService
+// +kubebuilder:printcolumn:name="Service",type=string,JSONPath=`.spec.service.name`,description="The name of the service that hosts this API endpoint, empty when served locally."
+// +kubebuilder:printcolumn:name="Available",type=string,JSONPath=`.status.conditions[?(@.type=="Available")].status`,description="Whether this service is available."
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:selectablefield:JSONPath=`.spec.group`
+// +kubebuilder:selectablefield:JSONPath=`.spec.service.namespace`
 
 // APIService represents a server for a particular GroupVersion.
 // Name must be "version.group".
@@ -0,0 +1,209 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/api/validation/path"
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+const (
+	// MaxGroupPriorityMinimum is the highest allowed GroupPriorityMinimum.
+	MaxGroupPriorityMinimum = 20000
+	// MaxVersionPriority is the highest allowed VersionPriority.
+	MaxVersionPriority = 1000
+
+	// maxConditionReasonLength and maxConditionMessageLength are the limits of
+	// metav1.Condition, which APIServiceCondition follows.
+	maxConditionReasonLength  = 1024
+	maxConditionMessageLength = 32768
+)
+
+// conditionReasonRegexp matches a one-word, CamelCase condition reason.
+var conditionReasonRegexp = regexp.MustCompile(`^[A-Za-z]([A-Za-z0-9_,:]*[A-Za-z0-9_])?$`)
+
+var (
+	supportedConditionTypes    = sets.New(registration.Available)
+	supportedConditionStatuses = sets.New(registration.ConditionTrue, registration.ConditionFalse, registration.ConditionUnknown)
+)
+
+// ValidateAPIService validates an APIService on creation. Only the groups in
+// localGroups, which are served by the aggregator itself, may be registered
+// without a service.
+func ValidateAPIService(apiService *registration.APIService, localGroups sets.Set[string]) field.ErrorList {
+	requiredName := apiService.Spec.Version + "." + apiService.Spec.Group
+	allErrs := apivalidation.ValidateObjectMeta(&apiService.ObjectMeta, false,
+		func(name string, prefix bool) []string {
+			if errs := path.IsValidPathSegmentName(name); len(errs) > 0 {
+				return errs
+			}
+			if name != requiredName {
+				return []string{fmt.Sprintf("must be `spec.version+\".\"+spec.group`: %q", requiredName)}
+			}
+			return nil
+		},
+		field.NewPath("metadata"))
+	allErrs = append(allErrs, ValidateAPIServiceSpec(&apiService.Spec, localGroups, field.NewPath("spec"))...)
+	return allErrs
+}
+
+// ValidateAPIServiceUpdate validates an update of an APIService, status is
+// ignored.
+func ValidateAPIServiceUpdate(newAPIService, oldAPIService *registration.APIService, localGroups sets.Set[string]) field.ErrorList {
+	allErrs := ValidateAPIService(newAPIService, localGroups)
+	allErrs = append(allErrs, apivalidation.ValidateObjectMetaUpdate(&newAPIService.ObjectMeta, &oldAPIService.ObjectMeta, field.NewPath("metadata"))...)
+	return allErrs
+}
+
+// ValidateAPIServiceStatusUpdate validates an update of the status of an
+// APIService.
+func ValidateAPIServiceStatusUpdate(newAPIService, oldAPIService *registration.APIService) field.ErrorList {
+	allErrs := apivalidation.ValidateObjectMetaUpdate(&newAPIService.ObjectMeta, &oldAPIService.ObjectMeta, field.NewPath("metadata"))
+	allErrs = append(allErrs, ValidateAPIServiceStatusTransition(&newAPIService.Status, &oldAPIService.Status, field.NewPath("status"))...)
+	return allErrs
+}
+
+// ValidateAPIServiceSpec validates the group version, priorities and service
+// of an APIServiceSpec.
+func ValidateAPIServiceSpec(spec *registration.APIServiceSpec, localGroups sets.Set[string], fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	groupPath := fldPath.Child("group")
+	if len(spec.Group) == 0 {
+		if spec.Version != "v1" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("version"), spec.Version, "v1 is the only allowed version for the core group"))
+		}
+	} else {
+		for _, msg := range utilvalidation.IsDNS1123Subdomain(spec.Group) {
+			allErrs = append(allErrs, field.Invalid(groupPath, spec.Group, msg))
+		}
+	}
+	for _, msg := range utilvalidation.IsDNS1035Label(spec.Version) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("version"), spec.Version, msg))
+	}
+
+	if spec.GroupPriorityMinimum < 1 || spec.GroupPriorityMinimum > MaxGroupPriorityMinimum {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("groupPriorityMinimum"), spec.GroupPriorityMinimum,
+			fmt.Sprintf("must be between 1 and %d, inclusive", MaxGroupPriorityMinimum)))
+	}
+	if spec.VersionPriority < 1 || spec.VersionPriority > MaxVersionPriority {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("versionPriority"), spec.VersionPriority,
+			fmt.Sprintf("must be between 1 and %d, inclusive", MaxVersionPriority)))
+	}
+
+	if spec.Service == nil {
+		if !localGroups.Has(spec.Group) {
+			allErrs = append(allErrs, field.Required(fldPath.Child("service"),
+				fmt.Sprintf("may only be omitted for the local groups %q", sets.List(localGroups))))
+		}
+		if spec.InsecureSkipTLSVerify {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("insecureSkipTLSVerify"), spec.InsecureSkipTLSVerify,
+				"local APIServices may not have insecureSkipTLSVerify"))
+		}
+		if len(spec.CABundle) > 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("caBundle"), "<bytes>", "local APIServices may not have a caBundle"))
+		}
+		return allErrs
+	}
+
+	allErrs = append(allErrs, ValidateServiceReference(spec.Service, fldPath.Child("service"))...)
+	if len(spec.CABundle) > 0 {
+		if spec.InsecureSkipTLSVerify {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("caBundle"), "may not be present when insecureSkipTLSVerify is true"))
+		} else if _, err := certutil.ParseCertsPEM(spec.CABundle); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("caBundle"), "<bytes>", fmt.Sprintf("must be a PEM encoded CA bundle: %v", err)))
+		}
+	}
+	return allErrs
+}
+
+// ValidateServiceReference validates the name, namespace and port of a
+// ServiceReference.
+func ValidateServiceReference(service *registration.ServiceReference, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if len(service.Namespace) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("namespace"), ""))
+	} else {
+		for _, msg := range apivalidation.ValidateNamespaceName(service.Namespace, false) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("namespace"), service.Namespace, msg))
+		}
+	}
+	if len(service.Name) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), ""))
+	} else {
+		for _, msg := range utilvalidation.IsDNS1035Label(service.Name) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), service.Name, msg))
+		}
+	}
+	for _, msg := range utilvalidation.IsValidPortNum(int(service.Port)) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("port"), service.Port, msg))
+	}
+	return allErrs
+}
+
+// ValidateAPIServiceStatus validates the conditions of an APIServiceStatus.
+func ValidateAPIServiceStatus(status *registration.APIServiceStatus, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	seen := sets.New[registration.APIServiceConditionType]()
+	for i, condition := range status.Conditions {
+		idxPath := fldPath.Child("conditions").Index(i)
+		if !supportedConditionTypes.Has(condition.Type) {
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("type"), condition.Type, sets.List(supportedConditionTypes)))
+		} else if seen.Has(condition.Type) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("type"), condition.Type))
+		}
+		seen.Insert(condition.Type)
+
+		if !supportedConditionStatuses.Has(condition.Status) {
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("status"), condition.Status, sets.List(supportedConditionStatuses)))
+		}
+		if condition.LastTransitionTime.IsZero() {
+			allErrs = append(allErrs, field.Required(idxPath.Child("lastTransitionTime"), ""))
+		}
+		if len(condition.Reason) > maxConditionReasonLength {
+			allErrs = append(allErrs, field.TooLong(idxPath.Child("reason"), condition.Reason, maxConditionReasonLength))
+		} else if len(condition.Reason) > 0 && !conditionReasonRegexp.MatchString(condition.Reason) {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("reason"), condition.Reason, "must be a one-word, CamelCase reason"))
+		}
+		if len(condition.Message) > maxConditionMessageLength {
+			allErrs = append(allErrs, field.TooLong(idxPath.Child("message"), condition.Message, maxConditionMessageLength))
+		}
+	}
+	return allErrs
+}
+
+// ValidateAPIServiceStatusTransition validates newStatus, and that the
+// LastTransitionTime of its conditions moves forward if and only if their
+// status changed since oldStatus.
+func ValidateAPIServiceStatusTransition(newStatus, oldStatus *registration.APIServiceStatus, fldPath *field.Path) field.ErrorList {
+	allErrs := ValidateAPIServiceStatus(newStatus, fldPath)
+
+	oldConditions := map[registration.APIServiceConditionType]registration.APIServiceCondition{}
+	for _, condition := range oldStatus.Conditions {
+		oldConditions[condition.Type] = condition
+	}
+	for i, condition := range newStatus.Conditions {
+		oldCondition, exists := oldConditions[condition.Type]
+		if !exists || condition.LastTransitionTime.IsZero() {
+			continue
+		}
+		transitionPath := fldPath.Child("conditions").Index(i).Child("lastTransitionTime")
+		switch {
+		case condition.Status != oldCondition.Status && !oldCondition.LastTransitionTime.Before(&condition.LastTransitionTime):
+			allErrs = append(allErrs, field.Invalid(transitionPath, condition.LastTransitionTime,
+				fmt.Sprintf("must be after %s when the status changes", oldCondition.LastTransitionTime.UTC())))
+		case condition.Status == oldCondition.Status && !condition.LastTransitionTime.Equal(&oldCondition.LastTransitionTime):
+			allErrs = append(allErrs, field.Invalid(transitionPath, condition.LastTransitionTime,
+				"may only change along with the status"))
+		}
+	}
+	return allErrs
+}
@@ -0,0 +1,272 @@
+package validation
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration"
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/install"
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	registrationv1beta1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+var localGroups = sets.New(registration.GroupName)
+
+// decode converts an APIService of the given version, given as its name and
+// spec, to the internal version the way the API server does, with defaults.
+func decode(t *testing.T, apiVersion, name string, spec map[string]interface{}) *registration.APIService {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       "APIService",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec":       spec,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := runtime.Decode(install.Codecs.UniversalDecoder(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return obj.(*registration.APIService)
+}
+
+func TestValidateAPIService(t *testing.T) {
+	caBundle, _, err := certutil.GenerateSelfSignedCertKey("api.test-ns.svc", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := func(port int) map[string]interface{} {
+		return map[string]interface{}{"namespace": "test-ns", "name": "api", "port": port}
+	}
+	// validSpec returns a valid spec of foo.example.com/v1 with the given changes.
+	validSpec := func(changes map[string]interface{}) map[string]interface{} {
+		spec := map[string]interface{}{
+			"service":              map[string]interface{}{"namespace": "test-ns", "name": "api"},
+			"group":                "foo.example.com",
+			"version":              "v1",
+			"caBundle":             caBundle,
+			"groupPriorityMinimum": 1000,
+			"versionPriority":      15,
+		}
+		for key, value := range changes {
+			if value == nil {
+				delete(spec, key)
+				continue
+			}
+			spec[key] = value
+		}
+		return spec
+	}
+
+	testCases := map[string]struct {
+		name string
+		spec map[string]interface{}
+		errs field.ErrorList
+	}{
+		"valid": {
+			spec: validSpec(nil),
+		},
+		"valid with port": {
+			spec: validSpec(map[string]interface{}{"service": service(8443)}),
+		},
+		"valid insecure skip tls verify": {
+			spec: validSpec(map[string]interface{}{"caBundle": nil, "insecureSkipTLSVerify": true}),
+		},
+		"valid system trust roots": {
+			spec: validSpec(map[string]interface{}{"caBundle": nil}),
+		},
+		"valid local": {
+			name: "v1." + registration.GroupName,
+			spec: validSpec(map[string]interface{}{"group": registration.GroupName, "service": nil, "caBundle": nil}),
+		},
+		"name is not version.group": {
+			name: "foo.example.com",
+			spec: validSpec(nil),
+			errs: field.ErrorList{field.Invalid(field.NewPath("metadata", "name"), "", "")},
+		},
+		"invalid group": {
+			name: "v1.Foo",
+			spec: validSpec(map[string]interface{}{"group": "Foo"}),
+			errs: field.ErrorList{field.Invalid(field.NewPath("spec", "group"), "", "")},
+		},
+		"invalid version": {
+			name: "1.foo.example.com",
+			spec: validSpec(map[string]interface{}{"version": "1"}),
+			errs: field.ErrorList{field.Invalid(field.NewPath("spec", "version"), "", "")},
+		},
+		"core group other than v1": {
+			name: "v2.",
+			spec: validSpec(map[string]interface{}{"group": "", "version": "v2"}),
+			errs: field.ErrorList{field.Invalid(field.NewPath("spec", "version"), "", "")},
+		},
+		"missing service of a remote group": {
+			spec: validSpec(map[string]interface{}{"service": nil, "caBundle": nil}),
+			errs: field.ErrorList{field.Required(field.NewPath("spec", "service"), "")},
+		},
+		"local with ca bundle and insecure skip tls verify": {
+			name: "v1." + registration.GroupName,
+			spec: validSpec(map[string]interface{}{"group": registration.GroupName, "service": nil, "insecureSkipTLSVerify": true}),
+			errs: field.ErrorList{
+				field.Invalid(field.NewPath("spec", "insecureSkipTLSVerify"), "", ""),
+				field.Invalid(field.NewPath("spec", "caBundle"), "", ""),
+			},
+		},
+		"ca bundle is not pem": {
+			spec: validSpec(map[string]interface{}{"caBundle": []byte("not a certificate")}),
+			errs: field.ErrorList{field.Invalid(field.NewPath("spec", "caBundle"), "", "")},
+		},
+		"ca bundle and insecure skip tls verify": {
+			spec: validSpec(map[string]interface{}{"insecureSkipTLSVerify": true}),
+			errs: field.ErrorList{field.Forbidden(field.NewPath("spec", "caBundle"), "")},
+		},
+		"group priority minimum too low": {
+			spec: validSpec(map[string]interface{}{"groupPriorityMinimum": 0}),
+			errs: field.ErrorList{field.Invalid(field.NewPath("spec", "groupPriorityMinimum"), "", "")},
+		},
+		"group priority minimum too high": {
+			spec: validSpec(map[string]interface{}{"groupPriorityMinimum": MaxGroupPriorityMinimum + 1}),
+			errs: field.ErrorList{field.Invalid(field.NewPath("spec", "groupPriorityMinimum"), "", "")},
+		},
+		"version priority too low": {
+			spec: validSpec(map[string]interface{}{"versionPriority": nil}),
+			errs: field.ErrorList{field.Invalid(field.NewPath("spec", "versionPriority"), "", "")},
+		},
+		"version priority too high": {
+			spec: validSpec(map[string]interface{}{"versionPriority": MaxVersionPriority + 1}),
+			errs: field.ErrorList{field.Invalid(field.NewPath("spec", "versionPriority"), "", "")},
+		},
+		"port too high": {
+			spec: validSpec(map[string]interface{}{"service": service(65536)}),
+			errs: field.ErrorList{field.Invalid(field.NewPath("spec", "service", "port"), "", "")},
+		},
+		"invalid service reference": {
+			spec: validSpec(map[string]interface{}{"service": map[string]interface{}{"name": "API"}}),
+			errs: field.ErrorList{
+				field.Required(field.NewPath("spec", "service", "namespace"), ""),
+				field.Invalid(field.NewPath("spec", "service", "name"), "", ""),
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		for _, apiVersion := range []string{registrationv1.SchemeGroupVersion.String(), registrationv1beta1.SchemeGroupVersion.String()} {
+			t.Run(name+" "+apiVersion, func(t *testing.T) {
+				apiServiceName := tc.name
+				if len(apiServiceName) == 0 {
+					apiServiceName = "v1.foo.example.com"
+				}
+				apiService := decode(t, apiVersion, apiServiceName, tc.spec)
+				assertErrors(t, tc.errs, ValidateAPIService(apiService, localGroups))
+
+				// Updates are held to the same rules.
+				oldAPIService := apiService.DeepCopy()
+				oldAPIService.ResourceVersion = "1"
+				apiService.ResourceVersion = "1"
+				assertErrors(t, tc.errs, ValidateAPIServiceUpdate(apiService, oldAPIService, localGroups))
+			})
+		}
+	}
+}
+
+func TestValidateAPIServiceStatusUpdate(t *testing.T) {
+	earlier := metav1.NewTime(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC))
+	later := metav1.NewTime(time.Date(2025, time.January, 2, 0, 0, 0, 0, time.UTC))
+	available := func(status registration.ConditionStatus, lastTransitionTime metav1.Time, reason string) registration.APIServiceCondition {
+		return registration.APIServiceCondition{
+			Type:               registration.Available,
+			Status:             status,
+			LastTransitionTime: lastTransitionTime,
+			Reason:             reason,
+			Message:            "probed",
+		}
+	}
+	conditionPath := field.NewPath("status", "conditions")
+
+	testCases := map[string]struct {
+		oldConditions []registration.APIServiceCondition
+		newConditions []registration.APIServiceCondition
+		errs          field.ErrorList
+	}{
+		"first condition": {
+			newConditions: []registration.APIServiceCondition{available(registration.ConditionTrue, earlier, "Passed")},
+		},
+		"transition": {
+			oldConditions: []registration.APIServiceCondition{available(registration.ConditionTrue, earlier, "Passed")},
+			newConditions: []registration.APIServiceCondition{available(registration.ConditionFalse, later, "FailedDiscoveryCheck")},
+		},
+		"same status with a new reason": {
+			oldConditions: []registration.APIServiceCondition{available(registration.ConditionFalse, earlier, "ServiceAccessError")},
+			newConditions: []registration.APIServiceCondition{available(registration.ConditionFalse, earlier, "FailedDiscoveryCheck")},
+		},
+		"transition without a new transition time": {
+			oldConditions: []registration.APIServiceCondition{available(registration.ConditionTrue, earlier, "Passed")},
+			newConditions: []registration.APIServiceCondition{available(registration.ConditionFalse, earlier, "FailedDiscoveryCheck")},
+			errs:          field.ErrorList{field.Invalid(conditionPath.Index(0).Child("lastTransitionTime"), "", "")},
+		},
+		"transition back in time": {
+			oldConditions: []registration.APIServiceCondition{available(registration.ConditionTrue, later, "Passed")},
+			newConditions: []registration.APIServiceCondition{available(registration.ConditionFalse, earlier, "FailedDiscoveryCheck")},
+			errs:          field.ErrorList{field.Invalid(conditionPath.Index(0).Child("lastTransitionTime"), "", "")},
+		},
+		"new transition time without a transition": {
+			oldConditions: []registration.APIServiceCondition{available(registration.ConditionTrue, earlier, "Passed")},
+			newConditions: []registration.APIServiceCondition{available(registration.ConditionTrue, later, "Passed")},
+			errs:          field.ErrorList{field.Invalid(conditionPath.Index(0).Child("lastTransitionTime"), "", "")},
+		},
+		"missing transition time": {
+			newConditions: []registration.APIServiceCondition{available(registration.ConditionTrue, metav1.Time{}, "Passed")},
+			errs:          field.ErrorList{field.Required(conditionPath.Index(0).Child("lastTransitionTime"), "")},
+		},
+		"unknown condition type": {
+			newConditions: []registration.APIServiceCondition{{Type: "Ready", Status: registration.ConditionTrue, LastTransitionTime: earlier}},
+			errs:          field.ErrorList{field.NotSupported(conditionPath.Index(0).Child("type"), "Ready", []string{})},
+		},
+		"duplicate condition type": {
+			newConditions: []registration.APIServiceCondition{
+				available(registration.ConditionTrue, earlier, "Passed"),
+				available(registration.ConditionFalse, earlier, "FailedDiscoveryCheck"),
+			},
+			errs: field.ErrorList{field.Duplicate(conditionPath.Index(1).Child("type"), registration.Available)},
+		},
+		"invalid condition status": {
+			newConditions: []registration.APIServiceCondition{available("Maybe", earlier, "Passed")},
+			errs:          field.ErrorList{field.NotSupported(conditionPath.Index(0).Child("status"), "Maybe", []string{})},
+		},
+		"invalid reason": {
+			newConditions: []registration.APIServiceCondition{available(registration.ConditionTrue, earlier, "all checks passed")},
+			errs:          field.ErrorList{field.Invalid(conditionPath.Index(0).Child("reason"), "", "")},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			oldAPIService := &registration.APIService{
+				ObjectMeta: metav1.ObjectMeta{Name: "v1.foo.example.com", ResourceVersion: "1"},
+				Status:     registration.APIServiceStatus{Conditions: tc.oldConditions},
+			}
+			newAPIService := oldAPIService.DeepCopy()
+			newAPIService.Status.Conditions = tc.newConditions
+			assertErrors(t, tc.errs, ValidateAPIServiceStatusUpdate(newAPIService, oldAPIService))
+		})
+	}
+}
+
+func assertErrors(t *testing.T, expected, actual field.ErrorList) {
+	t.Helper()
+	if len(expected) != len(actual) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expected), len(actual), actual)
+	}
+	for i := range expected {
+		if expected[i].Type != actual[i].Type || expected[i].Field != actual[i].Field {
+			t.Errorf("expected error %q at %q, got %v", expected[i].Type, expected[i].Field, actual[i])
+		}
+	}
+}
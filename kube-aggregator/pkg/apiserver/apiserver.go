@@ -0,0 +1,174 @@
+package apiserver
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	registrationinformers "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/informers/externalversions/registration/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// APIAggregator proxies /apis/<group>/<version> requests to the service backing
+// the APIService of that group version, serves the discovery of all APIServices
+// at /apis, and hands every other request to its delegate. APIServices without
+// a service are local, the delegate serves them in-process.
+type APIAggregator struct {
+	serviceResolver ServiceResolver
+	trustProvider   *TrustProvider
+	circuitBreakers *CircuitBreakers
+	delegate        http.Handler
+	apis            *apisHandler
+
+	lock sync.RWMutex
+	// proxyHandlers are keyed by APIService name, which is "version.group".
+	proxyHandlers map[string]*proxyHandler
+}
+
+var _ http.Handler = &APIAggregator{}
+
+// Options configure an APIAggregator.
+type Options struct {
+	// ProxyClientCertFile and ProxyClientKeyFile are the PEM encoded client
+	// certificate and key the aggregator authenticates to services with. The
+	// services must trust it for their requestheader authentication, without
+	// it every proxied request reaches them anonymous.
+	ProxyClientCertFile string
+	ProxyClientKeyFile  string
+}
+
+// NewAPIAggregator returns an APIAggregator following the APIServices of the
+// given informer. Services are reached at the URL returned by serviceResolver.
+// The metrics of the aggregator are registered with the default Prometheus
+// registry.
+func NewAPIAggregator(apiServiceInformer registrationinformers.APIServiceInformer,
+	serviceResolver ServiceResolver, delegate http.Handler, options Options) (*APIAggregator, error) {
+	if err := RegisterMetrics(prometheus.DefaultRegisterer); err != nil {
+		return nil, fmt.Errorf("failed to register the aggregator metrics: %w", err)
+	}
+	trustProvider := NewTrustProvider()
+	if len(options.ProxyClientCertFile) > 0 || len(options.ProxyClientKeyFile) > 0 {
+		if err := trustProvider.LoadProxyClientCert(options.ProxyClientCertFile, options.ProxyClientKeyFile); err != nil {
+			return nil, err
+		}
+	}
+	a := &APIAggregator{
+		serviceResolver: serviceResolver,
+		trustProvider:   trustProvider,
+		circuitBreakers: NewCircuitBreakers(clock.RealClock{}),
+		delegate:        delegate,
+		proxyHandlers:   map[string]*proxyHandler{},
+	}
+	a.apis = &apisHandler{
+		lister:    apiServiceInformer.Lister(),
+		discovery: newDiscoveryManager(a),
+	}
+
+	if _, err := apiServiceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    a.addAPIService,
+		UpdateFunc: func(_, newObj interface{}) { a.addAPIService(newObj) },
+		DeleteFunc: a.deleteAPIService,
+	}); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// AddAPIService adds or updates the proxy to the service backing apiService,
+// or to the delegate when apiService is local.
+func (a *APIAggregator) AddAPIService(apiService *registrationv1.APIService) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	handler, exists := a.proxyHandlers[apiService.Name]
+	if !exists {
+		handler = &proxyHandler{serviceResolver: a.serviceResolver, trustProvider: a.trustProvider,
+			circuitBreakers: a.circuitBreakers, localDelegate: a.delegate}
+		a.proxyHandlers[apiService.Name] = handler
+	}
+	return handler.updateAPIService(apiService)
+}
+
+// RemoveAPIService stops proxying to the service of the named APIService, its
+// requests are left to the delegate.
+func (a *APIAggregator) RemoveAPIService(apiServiceName string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if handler, exists := a.proxyHandlers[apiServiceName]; exists {
+		handler.setHandlingInfo(nil)
+		delete(a.proxyHandlers, apiServiceName)
+	}
+	a.trustProvider.Forget(apiServiceName)
+	a.circuitBreakers.Forget(apiServiceName)
+}
+
+// CircuitBreakers returns the circuit breakers of the services proxied to, for
+// the availability controller to report and probe.
+func (a *APIAggregator) CircuitBreakers() *CircuitBreakers {
+	return a.circuitBreakers
+}
+
+// TrustProvider returns the trust of the services proxied to, for the
+// availability controller to probe them the way they are proxied to.
+func (a *APIAggregator) TrustProvider() *TrustProvider {
+	return a.trustProvider
+}
+
+func (a *APIAggregator) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/apis" || req.URL.Path == "/apis/" {
+		a.apis.ServeHTTP(w, req)
+		return
+	}
+	if handler := a.proxyHandlerFor(req.URL.Path); handler != nil {
+		handler.ServeHTTP(w, req)
+		return
+	}
+	a.delegate.ServeHTTP(w, req)
+}
+
+// proxyHandlerFor returns the proxyHandler serving the /apis/<group>/<version>
+// path, or nil when the path is not served by an APIService.
+func (a *APIAggregator) proxyHandlerFor(path string) *proxyHandler {
+	if !strings.HasPrefix(path, "/apis/") {
+		return nil
+	}
+	segments := strings.SplitN(strings.TrimPrefix(path, "/apis/"), "/", 3)
+	if len(segments) < 2 || segments[0] == "" || segments[1] == "" {
+		return nil
+	}
+
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return a.proxyHandlers[segments[1]+"."+segments[0]]
+}
+
+func (a *APIAggregator) addAPIService(obj interface{}) {
+	apiService := obj.(*registrationv1.APIService)
+	klog.V(4).InfoS("Updating proxy", "apiService", apiService.Name)
+	if err := a.AddAPIService(apiService); err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to proxy to APIService %q: %w", apiService.Name, err))
+	}
+}
+
+func (a *APIAggregator) deleteAPIService(obj interface{}) {
+	apiService, ok := obj.(*registrationv1.APIService)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("error decoding object, invalid type %T", obj))
+			return
+		}
+		apiService, ok = tombstone.Obj.(*registrationv1.APIService)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("error decoding object tombstone, invalid type %T", tombstone.Obj))
+			return
+		}
+	}
+	klog.V(4).InfoS("Removing proxy", "apiService", apiService.Name)
+	a.RemoveAPIService(apiService.Name)
+}
@@ -0,0 +1,259 @@
+package apiserver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+const (
+	// defaultBreakerWindow is how many of the latest requests to the service of
+	// an APIService the error rate is computed over.
+	defaultBreakerWindow = 20
+	// defaultBreakerMinRequests is how many requests must be in the window
+	// before the circuit may open.
+	defaultBreakerMinRequests = 10
+	// defaultBreakerFailureRatio is the share of failed requests in the window
+	// opening the circuit.
+	defaultBreakerFailureRatio = 0.5
+	// defaultSlowRequestThreshold is how long a service may take to respond
+	// before the request counts as failed.
+	defaultSlowRequestThreshold = 10 * time.Second
+	// defaultBreakerOpenDuration is how long an open circuit fails requests
+	// fast before it is half-open and the service may be tried again.
+	defaultBreakerOpenDuration = 30 * time.Second
+)
+
+// CircuitState is the state of the circuit breaker of an APIService.
+type CircuitState string
+
+const (
+	// CircuitClosed lets requests through to the service.
+	CircuitClosed CircuitState = "Closed"
+	// CircuitOpen fails requests fast, the service failed too many of them.
+	CircuitOpen CircuitState = "Open"
+	// CircuitHalfOpen lets a single trial request through to the service and
+	// fails the others fast, until the trial request or a probe of the service
+	// succeeds and closes the circuit, or fails and opens it again.
+	CircuitHalfOpen CircuitState = "HalfOpen"
+)
+
+// CircuitStatus is the status of the circuit breaker of an APIService.
+type CircuitStatus struct {
+	State CircuitState
+	// Message tells why the circuit opened, empty when it is closed.
+	Message string
+	// HalfOpenAt is when an open circuit becomes half-open.
+	HalfOpenAt time.Time
+}
+
+// CircuitBreakers keeps a circuit breaker per APIService, opened by the
+// requests proxied to its service failing or being slow. An open circuit
+// fails requests fast instead of letting them hang on a service that is down.
+// Once half-open, a trial request is let through to the service, closing the
+// circuit when it succeeds, so circuits close without the availability
+// controller. The controller probing the service closes it too.
+type CircuitBreakers struct {
+	clock                clock.PassiveClock
+	window               int
+	minRequests          int
+	failureRatio         float64
+	slowRequestThreshold time.Duration
+	openDuration         time.Duration
+
+	lock sync.Mutex
+	// breakers are keyed by APIService name.
+	breakers  map[string]*circuitBreaker
+	listeners []func(apiServiceName string)
+}
+
+// NewCircuitBreakers returns CircuitBreakers with the default thresholds, all
+// circuits closed.
+func NewCircuitBreakers(clock clock.PassiveClock) *CircuitBreakers {
+	return &CircuitBreakers{
+		clock:                clock,
+		window:               defaultBreakerWindow,
+		minRequests:          defaultBreakerMinRequests,
+		failureRatio:         defaultBreakerFailureRatio,
+		slowRequestThreshold: defaultSlowRequestThreshold,
+		openDuration:         defaultBreakerOpenDuration,
+		breakers:             map[string]*circuitBreaker{},
+	}
+}
+
+// circuitBreaker is the circuit breaker of a single APIService.
+type circuitBreaker struct {
+	// failed holds the outcome of the latest requests, a ring starting at next.
+	failed   []bool
+	next     int
+	failures int
+
+	open     bool
+	openedAt time.Time
+	message  string
+	// trialAt is when the trial request in flight through the half-open
+	// circuit was let through, zero when there is none.
+	trialAt time.Time
+}
+
+// AddListener registers listener to be called with the name of an APIService
+// whenever its circuit opens or closes.
+func (b *CircuitBreakers) AddListener(listener func(apiServiceName string)) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.listeners = append(b.listeners, listener)
+}
+
+// Status returns the status of the circuit breaker of the named APIService.
+func (b *CircuitBreakers) Status(apiServiceName string) CircuitStatus {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.statusLocked(b.breakers[apiServiceName])
+}
+
+func (b *CircuitBreakers) statusLocked(breaker *circuitBreaker) CircuitStatus {
+	if breaker == nil || !breaker.open {
+		return CircuitStatus{State: CircuitClosed}
+	}
+	status := CircuitStatus{State: CircuitOpen, Message: breaker.message, HalfOpenAt: breaker.openedAt.Add(b.openDuration)}
+	if !b.clock.Now().Before(status.HalfOpenAt) {
+		status.State = CircuitHalfOpen
+	}
+	return status
+}
+
+// Record records the outcome of a request proxied to the service of the named
+// APIService, which took latency to respond, opening its circuit once too many
+// of the latest requests failed.
+func (b *CircuitBreakers) Record(apiServiceName string, failed bool, latency time.Duration) {
+	b.lock.Lock()
+	breaker, exists := b.breakers[apiServiceName]
+	if !exists {
+		breaker = &circuitBreaker{}
+		b.breakers[apiServiceName] = breaker
+	}
+	// Requests in flight when the circuit opened tell nothing new.
+	if breaker.open {
+		b.lock.Unlock()
+		return
+	}
+
+	failed = failed || latency > b.slowRequestThreshold
+	if len(breaker.failed) < b.window {
+		breaker.failed = append(breaker.failed, failed)
+	} else {
+		if breaker.failed[breaker.next] {
+			breaker.failures--
+		}
+		breaker.failed[breaker.next] = failed
+		breaker.next = (breaker.next + 1) % b.window
+	}
+	if failed {
+		breaker.failures++
+	}
+
+	requests := len(breaker.failed)
+	if requests < b.minRequests || float64(breaker.failures) < b.failureRatio*float64(requests) {
+		b.lock.Unlock()
+		return
+	}
+	b.openLocked(breaker, fmt.Sprintf("%d of the latest %d requests to the service failed or took longer than %s",
+		breaker.failures, requests, b.slowRequestThreshold))
+	listeners := b.listeners
+	b.lock.Unlock()
+	notify(listeners, apiServiceName)
+}
+
+// RecordProbe records the outcome of probing the service of the named
+// APIService while its circuit is half-open: the circuit closes when the probe
+// succeeded and opens again when it failed. Probes of closed or open circuits
+// are ignored. It returns the status of the circuit after the probe.
+func (b *CircuitBreakers) RecordProbe(apiServiceName string, succeeded bool, message string) CircuitStatus {
+	return b.recordHalfOpen(apiServiceName, succeeded, "probing the service failed: "+message)
+}
+
+// recordTrial records the outcome of the trial request let through the
+// half-open circuit of the named APIService, which took latency to respond,
+// closing the circuit when it succeeded and opening it again when it failed.
+func (b *CircuitBreakers) recordTrial(apiServiceName string, failed bool, latency time.Duration) {
+	failed = failed || latency > b.slowRequestThreshold
+	b.recordHalfOpen(apiServiceName, !failed,
+		fmt.Sprintf("a trial request to the service failed or took longer than %s", b.slowRequestThreshold))
+}
+
+// releaseTrial lets another trial request through the half-open circuit of the
+// named APIService, the one in flight went away without telling anything.
+func (b *CircuitBreakers) releaseTrial(apiServiceName string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if breaker := b.breakers[apiServiceName]; breaker != nil && breaker.open {
+		breaker.trialAt = time.Time{}
+	}
+}
+
+// recordHalfOpen closes the half-open circuit of the named APIService when
+// succeeded, or opens it again with message, and returns its status. Circuits
+// that are not half-open are left untouched.
+func (b *CircuitBreakers) recordHalfOpen(apiServiceName string, succeeded bool, message string) CircuitStatus {
+	b.lock.Lock()
+	breaker := b.breakers[apiServiceName]
+	if status := b.statusLocked(breaker); status.State != CircuitHalfOpen {
+		b.lock.Unlock()
+		return status
+	}
+	if succeeded {
+		*breaker = circuitBreaker{}
+	} else {
+		b.openLocked(breaker, message)
+	}
+	status := b.statusLocked(breaker)
+	listeners := b.listeners
+	b.lock.Unlock()
+	notify(listeners, apiServiceName)
+	return status
+}
+
+// Forget drops the circuit breaker of the named APIService, closing its circuit.
+func (b *CircuitBreakers) Forget(apiServiceName string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	delete(b.breakers, apiServiceName)
+}
+
+// allow returns an error when requests to the service of the named APIService
+// must fail fast, along with when they may be retried. A half-open circuit lets
+// a single request through at a time, reported as trial, whose outcome must be
+// recorded with recordTrial. A trial request taking longer than the slow
+// request threshold is given up on and the next request is let through.
+func (b *CircuitBreakers) allow(apiServiceName string) (bool, time.Duration, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	breaker := b.breakers[apiServiceName]
+	status := b.statusLocked(breaker)
+	switch status.State {
+	case CircuitOpen:
+		return false, status.HalfOpenAt.Sub(b.clock.Now()), fmt.Errorf("circuit breaker open: %s", status.Message)
+	case CircuitHalfOpen:
+		now := b.clock.Now()
+		if trialUntil := breaker.trialAt.Add(b.slowRequestThreshold); !breaker.trialAt.IsZero() && now.Before(trialUntil) {
+			return false, trialUntil.Sub(now),
+				fmt.Errorf("circuit breaker half-open, waiting for a trial request to the service: %s", status.Message)
+		}
+		breaker.trialAt = now
+		return true, 0, nil
+	default:
+		return false, 0, nil
+	}
+}
+
+func (b *CircuitBreakers) openLocked(breaker *circuitBreaker, message string) {
+	*breaker = circuitBreaker{open: true, openedAt: b.clock.Now(), message: message}
+}
+
+func notify(listeners []func(apiServiceName string), apiServiceName string) {
+	for _, listener := range listeners {
+		listener(apiServiceName)
+	}
+}
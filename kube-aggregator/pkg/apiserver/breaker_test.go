@@ -0,0 +1,210 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	testingclock "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+)
+
+// flappingBackend is a backend answering with the status code it is set to,
+// after the delay it is set to, counting the requests it served.
+type flappingBackend struct {
+	code     atomic.Int32
+	delay    atomic.Int64
+	requests atomic.Int32
+}
+
+func (b *flappingBackend) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	b.requests.Add(1)
+	time.Sleep(time.Duration(b.delay.Load()))
+	w.WriteHeader(int(b.code.Load()))
+}
+
+// newBreakerAggregator returns an aggregator proxying foo.example.com/v1 to a
+// flapping backend, with circuit breakers following fakeClock.
+func newBreakerAggregator(t *testing.T) (*APIAggregator, *flappingBackend, *testingclock.FakePassiveClock) {
+	t.Helper()
+	flapping := &flappingBackend{}
+	flapping.code.Store(http.StatusOK)
+	backend, caBundle := newBackend(t, flapping)
+	aggregator, _ := newAggregator(t, newResolver(t, backend), notFoundDelegate)
+	fakeClock := testingclock.NewFakePassiveClock(time.Now())
+	aggregator.circuitBreakers.clock = fakeClock
+
+	apiService := newAPIService("foo.example.com", "v1", testService(nil))
+	apiService.Spec.CABundle = caBundle
+	if err := aggregator.AddAPIService(apiService); err != nil {
+		t.Fatal(err)
+	}
+	return aggregator, flapping, fakeClock
+}
+
+func expectFailedFast(t *testing.T, aggregator *APIAggregator, flapping *flappingBackend) *metav1.Status {
+	t.Helper()
+	before := flapping.requests.Load()
+	w := serve(aggregator, http.MethodGet, "/apis/foo.example.com/v1")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusServiceUnavailable, w.Code, w.Body.String())
+	}
+	if flapping.requests.Load() != before {
+		t.Errorf("expected the request to fail fast without reaching the backend")
+	}
+	status := &metav1.Status{}
+	if err := json.Unmarshal(w.Body.Bytes(), status); err != nil {
+		t.Fatalf("expected a Status body, got %q: %v", w.Body.String(), err)
+	}
+	if status.Kind != "Status" || status.Reason != metav1.StatusReasonServiceUnavailable || status.Code != http.StatusServiceUnavailable {
+		t.Errorf("unexpected Status %#v", status)
+	}
+	return status
+}
+
+func TestCircuitBreakerFlapping(t *testing.T) {
+	aggregator, flapping, fakeClock := newBreakerAggregator(t)
+	breakers := aggregator.CircuitBreakers()
+	opened := make(chan string, 10)
+	breakers.AddListener(func(name string) { opened <- name })
+	expectState := func(expected CircuitState) {
+		t.Helper()
+		if state := breakers.Status("v1.foo.example.com").State; state != expected {
+			t.Fatalf("expected circuit %s, got %s", expected, state)
+		}
+	}
+
+	// Failing below the ratio keeps the circuit closed.
+	for i := 0; i < 2*defaultBreakerMinRequests; i++ {
+		if i%3 == 0 {
+			flapping.code.Store(http.StatusBadGateway)
+		} else {
+			flapping.code.Store(http.StatusOK)
+		}
+		serve(aggregator, http.MethodGet, "/apis/foo.example.com/v1")
+	}
+	expectState(CircuitClosed)
+
+	// The backend goes down, the circuit opens once most requests fail.
+	flapping.code.Store(http.StatusServiceUnavailable)
+	for i := 0; i < defaultBreakerWindow && breakers.Status("v1.foo.example.com").State == CircuitClosed; i++ {
+		if w := serve(aggregator, http.MethodGet, "/apis/foo.example.com/v1"); w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected the failure of the backend, got %d", w.Code)
+		}
+	}
+	expectState(CircuitOpen)
+	if name := <-opened; name != "v1.foo.example.com" {
+		t.Errorf("expected listeners to be told about v1.foo.example.com, got %q", name)
+	}
+	status := expectFailedFast(t, aggregator, flapping)
+	if status.Details == nil || status.Details.RetryAfterSeconds != int32(defaultBreakerOpenDuration/time.Second) {
+		t.Errorf("expected to be asked to retry after %s, got %#v", defaultBreakerOpenDuration, status.Details)
+	}
+
+	// Once half-open, a trial request reaches the backend, which is still
+	// down, and opens the circuit again.
+	fakeClock.SetTime(fakeClock.Now().Add(defaultBreakerOpenDuration))
+	expectState(CircuitHalfOpen)
+	before := flapping.requests.Load()
+	if w := serve(aggregator, http.MethodGet, "/apis/foo.example.com/v1"); w.Code != http.StatusServiceUnavailable ||
+		flapping.requests.Load() != before+1 {
+		t.Fatalf("expected the trial request to reach the backend, got %d: %s", w.Code, w.Body.String())
+	}
+	expectState(CircuitOpen)
+	expectFailedFast(t, aggregator, flapping)
+
+	// A failed probe opens the circuit again.
+	flapping.code.Store(http.StatusOK)
+	fakeClock.SetTime(fakeClock.Now().Add(defaultBreakerOpenDuration))
+	if state := breakers.RecordProbe("v1.foo.example.com", false, "bad status 503").State; state != CircuitOpen {
+		t.Fatalf("expected the failed probe to open the circuit, got %s", state)
+	}
+	expectFailedFast(t, aggregator, flapping)
+
+	// A passed probe closes it.
+	fakeClock.SetTime(fakeClock.Now().Add(defaultBreakerOpenDuration))
+	if state := breakers.RecordProbe("v1.foo.example.com", true, "").State; state != CircuitClosed {
+		t.Fatalf("expected the passed probe to close the circuit, got %s", state)
+	}
+	if w := serve(aggregator, http.MethodGet, "/apis/foo.example.com/v1"); w.Code != http.StatusOK {
+		t.Errorf("expected the request to be proxied, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestCircuitBreakerHalfOpenTrial checks that a half-open circuit closes
+// through a trial request, without the availability controller probing the
+// service, and that a single trial request is let through at a time.
+func TestCircuitBreakerHalfOpenTrial(t *testing.T) {
+	aggregator, flapping, fakeClock := newBreakerAggregator(t)
+	breakers := aggregator.CircuitBreakers()
+	flapping.code.Store(http.StatusServiceUnavailable)
+	for i := 0; i < defaultBreakerMinRequests; i++ {
+		serve(aggregator, http.MethodGet, "/apis/foo.example.com/v1")
+	}
+	expectFailedFast(t, aggregator, flapping)
+
+	// A trial request in flight fails the others fast.
+	fakeClock.SetTime(fakeClock.Now().Add(defaultBreakerOpenDuration))
+	if trial, _, err := breakers.allow("v1.foo.example.com"); !trial || err != nil {
+		t.Fatalf("expected a trial request to be let through, got %v, %v", trial, err)
+	}
+	status := expectFailedFast(t, aggregator, flapping)
+	if status.Details == nil || status.Details.RetryAfterSeconds != int32(defaultSlowRequestThreshold/time.Second) {
+		t.Errorf("expected to be asked to retry after %s, got %#v", defaultSlowRequestThreshold, status.Details)
+	}
+
+	// The trial request never responded, the next one is let through and
+	// closes the circuit as the backend is back.
+	flapping.code.Store(http.StatusOK)
+	fakeClock.SetTime(fakeClock.Now().Add(defaultSlowRequestThreshold))
+	if w := serve(aggregator, http.MethodGet, "/apis/foo.example.com/v1"); w.Code != http.StatusOK {
+		t.Fatalf("expected the trial request to be proxied, got %d: %s", w.Code, w.Body.String())
+	}
+	if state := breakers.Status("v1.foo.example.com").State; state != CircuitClosed {
+		t.Fatalf("expected the trial request to close the circuit, got %s", state)
+	}
+	if w := serve(aggregator, http.MethodGet, "/apis/foo.example.com/v1"); w.Code != http.StatusOK {
+		t.Errorf("expected the request to be proxied, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCircuitBreakerSlowBackend(t *testing.T) {
+	aggregator, flapping, _ := newBreakerAggregator(t)
+	// Latency is measured with the clock of the circuit breakers.
+	aggregator.circuitBreakers.clock = clock.RealClock{}
+	aggregator.circuitBreakers.slowRequestThreshold = 20 * time.Millisecond
+	flapping.delay.Store(int64(50 * time.Millisecond))
+
+	for i := 0; i < defaultBreakerMinRequests; i++ {
+		if w := serve(aggregator, http.MethodGet, "/apis/foo.example.com/v1"); w.Code != http.StatusOK {
+			t.Fatalf("expected slow requests to be served, got %d", w.Code)
+		}
+	}
+	if state := aggregator.CircuitBreakers().Status("v1.foo.example.com").State; state != CircuitOpen {
+		t.Fatalf("expected slow requests to open the circuit, got %s", state)
+	}
+	expectFailedFast(t, aggregator, flapping)
+}
+
+func TestCircuitBreakerResetOnServiceChange(t *testing.T) {
+	aggregator, flapping, _ := newBreakerAggregator(t)
+	flapping.code.Store(http.StatusServiceUnavailable)
+	for i := 0; i < defaultBreakerMinRequests; i++ {
+		serve(aggregator, http.MethodGet, "/apis/foo.example.com/v1")
+	}
+	expectFailedFast(t, aggregator, flapping)
+
+	apiService := aggregator.proxyHandlerFor("/apis/foo.example.com/v1").handlingInfo.Load()
+	updated := newAPIService("foo.example.com", "v1", testService(ptr.To[int32](8443)))
+	updated.Spec.CABundle = apiService.trust.caBundle
+	if err := aggregator.AddAPIService(updated); err != nil {
+		t.Fatal(err)
+	}
+	if state := aggregator.CircuitBreakers().Status("v1.foo.example.com").State; state != CircuitClosed {
+		t.Errorf("expected a new service to close the circuit, got %s", state)
+	}
+}
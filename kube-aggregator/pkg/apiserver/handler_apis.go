@@ -0,0 +1,126 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1/helper"
+	registrationlisters "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/listers/registration/v1"
+	apidiscoveryv2 "k8s.io/api/apidiscovery/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+// aggregatedDiscoveryContentType is the media type of the aggregated discovery
+// document, as requested by clients in their Accept header.
+var aggregatedDiscoveryContentType = mime.FormatMediaType(runtime.ContentTypeJSON, map[string]string{
+	"g":  apidiscoveryv2.SchemeGroupVersion.Group,
+	"v":  apidiscoveryv2.SchemeGroupVersion.Version,
+	"as": "APIGroupDiscoveryList",
+})
+
+// apisHandler serves /apis, listing the API groups of all APIServices. Clients
+// get the legacy APIGroupList by default, or the aggregated discovery document
+// including the resources of every group version when they accept it.
+type apisHandler struct {
+	lister    registrationlisters.APIServiceLister
+	discovery *discoveryManager
+}
+
+var _ http.Handler = &apisHandler{}
+
+func (r *apisHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		http.Error(w, fmt.Sprintf("method %s is not allowed", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	aggregated, ok := negotiateDiscovery(req.Header.Get("Accept"))
+	if !ok {
+		http.Error(w, fmt.Sprintf("none of the media types in %q are supported, expected %q or %q",
+			req.Header.Get("Accept"), runtime.ContentTypeJSON, aggregatedDiscoveryContentType), http.StatusNotAcceptable)
+		return
+	}
+
+	apiServices, err := r.lister.List(labels.Everything())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	groups := helper.SortedByGroupAndVersion(apiServices)
+
+	w.Header().Set("Vary", "Accept")
+	if aggregated {
+		writeJSON(w, aggregatedDiscoveryContentType, r.discovery.apiGroupDiscoveryList(req.Context(), groups))
+		return
+	}
+	writeJSON(w, runtime.ContentTypeJSON, newAPIGroupList(groups))
+}
+
+// negotiateDiscovery returns whether accept asks for the aggregated discovery
+// document rather than the APIGroupList, and false if it asks for neither.
+// Media types are considered in the order they are listed, the way kubectl
+// lists them from most to least preferred.
+func negotiateDiscovery(accept string) (aggregated bool, ok bool) {
+	if strings.TrimSpace(accept) == "" {
+		return false, true
+	}
+	for _, clause := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(clause))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case runtime.ContentTypeJSON:
+			if params["g"] == "" && params["v"] == "" && params["as"] == "" {
+				return false, true
+			}
+			if params["g"] == apidiscoveryv2.SchemeGroupVersion.Group && params["v"] == apidiscoveryv2.SchemeGroupVersion.Version &&
+				params["as"] == "APIGroupDiscoveryList" {
+				return true, true
+			}
+		case "*/*", "application/*":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// newAPIGroupList returns the legacy discovery document of groups, as sorted
+// by helper.SortedByGroupAndVersion.
+func newAPIGroupList(groups [][]*registrationv1.APIService) *metav1.APIGroupList {
+	apiGroupList := &metav1.APIGroupList{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "APIGroupList"},
+		Groups:   []metav1.APIGroup{},
+	}
+	for _, apiServices := range groups {
+		apiGroup := metav1.APIGroup{Name: apiServices[0].Spec.Group}
+		for _, apiService := range apiServices {
+			apiGroup.Versions = append(apiGroup.Versions, metav1.GroupVersionForDiscovery{
+				GroupVersion: schema.GroupVersion{Group: apiService.Spec.Group, Version: apiService.Spec.Version}.String(),
+				Version:      apiService.Spec.Version,
+			})
+		}
+		apiGroup.PreferredVersion = apiGroup.Versions[0]
+		apiGroupList.Groups = append(apiGroupList.Groups, apiGroup)
+	}
+	return apiGroupList
+}
+
+func writeJSON(w http.ResponseWriter, contentType string, obj interface{}) {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	if _, err := w.Write(body); err != nil {
+		klog.ErrorS(err, "Error writing discovery response")
+	}
+}
@@ -0,0 +1,227 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	apidiscoveryv2 "k8s.io/api/apidiscovery/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// defaultDiscoveryRefreshInterval is how long the resources of an
+	// unchanged APIService are served from the cache.
+	defaultDiscoveryRefreshInterval = 30 * time.Second
+	// discoveryTimeout bounds fetching the resources of a group version.
+	discoveryTimeout = 5 * time.Second
+)
+
+// discoveryManager fetches the resources of every APIService from the handler
+// serving it, and caches them for the aggregated discovery document.
+type discoveryManager struct {
+	// handler serves /apis/<group>/<version> for every APIService, proxied
+	// or local.
+	handler http.Handler
+	// refreshInterval is how long cached resources are served before being
+	// fetched again.
+	refreshInterval time.Duration
+
+	lock sync.Mutex
+	// cache is keyed by APIService name.
+	cache map[string]*cachedDiscovery
+}
+
+// cachedDiscovery holds the resources last fetched for an APIService.
+type cachedDiscovery struct {
+	// resourceVersion of the APIService the resources were fetched for.
+	resourceVersion string
+	fetched         time.Time
+	resources       []apidiscoveryv2.APIResourceDiscovery
+	// stale is set when the last fetch failed, the resources are then those
+	// of the last successful one, if any.
+	stale bool
+}
+
+func newDiscoveryManager(handler http.Handler) *discoveryManager {
+	return &discoveryManager{
+		handler:         handler,
+		refreshInterval: defaultDiscoveryRefreshInterval,
+		cache:           map[string]*cachedDiscovery{},
+	}
+}
+
+// apiGroupDiscoveryList returns the aggregated discovery document of groups, as
+// sorted by helper.SortedByGroupAndVersion. Group versions whose resources
+// cannot be fetched are listed as stale.
+func (m *discoveryManager) apiGroupDiscoveryList(ctx context.Context, groups [][]*registrationv1.APIService) *apidiscoveryv2.APIGroupDiscoveryList {
+	var wg sync.WaitGroup
+	discoveries := make([][]*cachedDiscovery, len(groups))
+	for i, apiServices := range groups {
+		discoveries[i] = make([]*cachedDiscovery, len(apiServices))
+		for j, apiService := range apiServices {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				discoveries[i][j] = m.discover(ctx, apiService)
+			}()
+		}
+	}
+	wg.Wait()
+	m.prune(groups)
+
+	list := &apidiscoveryv2.APIGroupDiscoveryList{
+		TypeMeta: metav1.TypeMeta{APIVersion: apidiscoveryv2.SchemeGroupVersion.String(), Kind: "APIGroupDiscoveryList"},
+		Items:    []apidiscoveryv2.APIGroupDiscovery{},
+	}
+	for i, apiServices := range groups {
+		group := apidiscoveryv2.APIGroupDiscovery{ObjectMeta: metav1.ObjectMeta{Name: apiServices[0].Spec.Group}}
+		for j, apiService := range apiServices {
+			freshness := apidiscoveryv2.DiscoveryFreshnessCurrent
+			if discoveries[i][j].stale {
+				freshness = apidiscoveryv2.DiscoveryFreshnessStale
+			}
+			group.Versions = append(group.Versions, apidiscoveryv2.APIVersionDiscovery{
+				Version:   apiService.Spec.Version,
+				Resources: discoveries[i][j].resources,
+				Freshness: freshness,
+			})
+		}
+		list.Items = append(list.Items, group)
+	}
+	return list
+}
+
+// discover returns the resources of apiService, from the cache while they are
+// fresh and the APIService is unchanged.
+func (m *discoveryManager) discover(ctx context.Context, apiService *registrationv1.APIService) *cachedDiscovery {
+	m.lock.Lock()
+	cached := m.cache[apiService.Name]
+	m.lock.Unlock()
+	if cached != nil && cached.resourceVersion == apiService.ResourceVersion && time.Since(cached.fetched) < m.refreshInterval {
+		return cached
+	}
+
+	discovery := &cachedDiscovery{resourceVersion: apiService.ResourceVersion, fetched: time.Now()}
+	resources, err := m.fetch(ctx, apiService)
+	if err != nil {
+		klog.V(2).InfoS("Failed to discover resources, serving them as stale", "apiService", apiService.Name, "err", err)
+		discovery.stale = true
+		if cached != nil {
+			discovery.resources = cached.resources
+		}
+	} else {
+		discovery.resources = resources
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.cache[apiService.Name] = discovery
+	return discovery
+}
+
+// fetch gets the APIResourceList of apiService from the handler serving it.
+func (m *discoveryManager) fetch(ctx context.Context, apiService *registrationv1.APIService) ([]apidiscoveryv2.APIResourceDiscovery, error) {
+	ctx, cancel := context.WithTimeout(ctx, discoveryTimeout)
+	defer cancel()
+
+	groupVersion := schema.GroupVersion{Group: apiService.Spec.Group, Version: apiService.Spec.Version}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/apis/"+groupVersion.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", runtime.ContentTypeJSON)
+	// The handler is called in-process, the recorder collects its response.
+	w := httptest.NewRecorder()
+	m.handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d fetching %s: %s", w.Code, req.URL.Path, strings.TrimSpace(w.Body.String()))
+	}
+
+	resourceList := &metav1.APIResourceList{}
+	if err := json.Unmarshal(w.Body.Bytes(), resourceList); err != nil {
+		return nil, fmt.Errorf("failed to decode APIResourceList of %s: %w", groupVersion, err)
+	}
+	return convertAPIResourceList(groupVersion, resourceList), nil
+}
+
+// prune drops the cached resources of APIServices which no longer exist.
+func (m *discoveryManager) prune(groups [][]*registrationv1.APIService) {
+	names := map[string]bool{}
+	for _, apiServices := range groups {
+		for _, apiService := range apiServices {
+			names[apiService.Name] = true
+		}
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for name := range m.cache {
+		if !names[name] {
+			delete(m.cache, name)
+		}
+	}
+}
+
+// convertAPIResourceList converts the legacy resource list of groupVersion to
+// aggregated discovery resources, sorted by name, nesting subresources under
+// their parent resource.
+func convertAPIResourceList(groupVersion schema.GroupVersion, list *metav1.APIResourceList) []apidiscoveryv2.APIResourceDiscovery {
+	var resources []apidiscoveryv2.APIResourceDiscovery
+	subresources := map[string][]apidiscoveryv2.APISubresourceDiscovery{}
+	for _, apiResource := range list.APIResources {
+		if resource, subresource, ok := strings.Cut(apiResource.Name, "/"); ok {
+			subresources[resource] = append(subresources[resource], apidiscoveryv2.APISubresourceDiscovery{
+				Subresource:  subresource,
+				ResponseKind: responseKind(groupVersion, apiResource),
+				Verbs:        apiResource.Verbs,
+			})
+			continue
+		}
+
+		scope := apidiscoveryv2.ScopeCluster
+		if apiResource.Namespaced {
+			scope = apidiscoveryv2.ScopeNamespace
+		}
+		resources = append(resources, apidiscoveryv2.APIResourceDiscovery{
+			Resource:         apiResource.Name,
+			ResponseKind:     responseKind(groupVersion, apiResource),
+			Scope:            scope,
+			SingularResource: apiResource.SingularName,
+			Verbs:            apiResource.Verbs,
+			ShortNames:       apiResource.ShortNames,
+			Categories:       apiResource.Categories,
+		})
+	}
+
+	for i := range resources {
+		resources[i].Subresources = subresources[resources[i].Resource]
+	}
+	slices.SortFunc(resources, func(a, b apidiscoveryv2.APIResourceDiscovery) int {
+		return strings.Compare(a.Resource, b.Resource)
+	})
+	return resources
+}
+
+// responseKind returns the kind of apiResource, which defaults to the group
+// version serving it.
+func responseKind(groupVersion schema.GroupVersion, apiResource metav1.APIResource) *metav1.GroupVersionKind {
+	gvk := &metav1.GroupVersionKind{Group: groupVersion.Group, Version: groupVersion.Version, Kind: apiResource.Kind}
+	if apiResource.Group != "" {
+		gvk.Group = apiResource.Group
+	}
+	if apiResource.Version != "" {
+		gvk.Version = apiResource.Version
+	}
+	return gvk
+}
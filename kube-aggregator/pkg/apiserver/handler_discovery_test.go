@@ -0,0 +1,267 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	apidiscoveryv2 "k8s.io/api/apidiscovery/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// kubectlAccept is the Accept header kubectl sends for /apis.
+const kubectlAccept = "application/json;g=apidiscovery.k8s.io;v=v2;as=APIGroupDiscoveryList," +
+	"application/json;g=apidiscovery.k8s.io;v=v2beta1;as=APIGroupDiscoveryList,application/json"
+
+// resourceListHandler serves the APIResourceList of every group version in
+// resources, and counts the requests it served.
+type resourceListHandler struct {
+	resources map[string][]metav1.APIResource
+	requests  atomic.Int32
+}
+
+func (h *resourceListHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h.requests.Add(1)
+	groupVersion := req.URL.Path[len("/apis/"):]
+	resources, ok := h.resources[groupVersion]
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(&metav1.APIResourceList{GroupVersion: groupVersion, APIResources: resources})
+}
+
+var fooResources = []metav1.APIResource{
+	{Name: "foos", SingularName: "foo", Namespaced: true, Kind: "Foo", Verbs: metav1.Verbs{"get", "list"}, ShortNames: []string{"fo"}},
+	{Name: "foos/status", Namespaced: true, Kind: "Foo", Verbs: metav1.Verbs{"get", "update"}},
+	{Name: "bazs", SingularName: "baz", Kind: "Baz", Verbs: metav1.Verbs{"get"}, Categories: []string{"all"}},
+}
+
+// discoveryFixture is an aggregator serving
+//   - foo.example.com v1 and v2 from a backend,
+//   - local.example.com v1 from its delegate,
+//   - broken.example.com v1beta1 from a service it cannot trust,
+//
+// where foo.example.com has the highest group priority.
+type discoveryFixture struct {
+	aggregator *APIAggregator
+	indexer    cache.Indexer
+	backend    *resourceListHandler
+}
+
+func newDiscoveryFixture(t *testing.T) *discoveryFixture {
+	f := &discoveryFixture{backend: &resourceListHandler{resources: map[string][]metav1.APIResource{
+		"foo.example.com/v1": fooResources,
+		"foo.example.com/v2": fooResources[:1],
+	}}}
+	backend, caBundle := newBackend(t, f.backend)
+	delegate := &resourceListHandler{resources: map[string][]metav1.APIResource{
+		"local.example.com/v1": {{Name: "bars", SingularName: "bar", Kind: "Bar", Verbs: metav1.Verbs{"get"}}},
+	}}
+	f.aggregator, f.indexer = newAggregator(t, newResolver(t, backend), delegate)
+
+	fooV1 := newAPIService("foo.example.com", "v1", testService(nil))
+	fooV2 := newAPIService("foo.example.com", "v2", testService(nil))
+	for _, apiService := range []*registrationv1.APIService{fooV1, fooV2} {
+		apiService.Spec.CABundle = caBundle
+		apiService.Spec.GroupPriorityMinimum = 2000
+	}
+	local := newAPIService("local.example.com", "v1", nil)
+	broken := newAPIService("broken.example.com", "v1beta1", testService(nil))
+	for _, apiService := range []*registrationv1.APIService{fooV1, fooV2, local, broken} {
+		f.add(t, apiService)
+	}
+	return f
+}
+
+func (f *discoveryFixture) add(t *testing.T, apiService *registrationv1.APIService) {
+	t.Helper()
+	if err := f.indexer.Add(apiService); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.aggregator.AddAPIService(apiService)
+}
+
+func (f *discoveryFixture) get(t *testing.T, accept string, obj interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/apis", nil)
+	req.Header.Set("Accept", accept)
+	w := httptest.NewRecorder()
+	f.aggregator.ServeHTTP(w, req)
+	if w.Code == http.StatusOK && obj != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w
+}
+
+func TestAPIGroupList(t *testing.T) {
+	f := newDiscoveryFixture(t)
+
+	for _, accept := range []string{"", "application/json", "application/json;g=apidiscovery.k8s.io;v=v2beta1;as=APIGroupDiscoveryList, */*"} {
+		apiGroupList := &metav1.APIGroupList{}
+		w := f.get(t, accept, apiGroupList)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("Accept %q: expected an APIGroupList, got status %d of %q: %s", accept, w.Code, w.Header().Get("Content-Type"), w.Body.String())
+		}
+
+		expected := []metav1.APIGroup{
+			{
+				Name: "foo.example.com",
+				Versions: []metav1.GroupVersionForDiscovery{
+					{GroupVersion: "foo.example.com/v2", Version: "v2"},
+					{GroupVersion: "foo.example.com/v1", Version: "v1"},
+				},
+				PreferredVersion: metav1.GroupVersionForDiscovery{GroupVersion: "foo.example.com/v2", Version: "v2"},
+			},
+			{
+				Name:             "local.example.com",
+				Versions:         []metav1.GroupVersionForDiscovery{{GroupVersion: "local.example.com/v1", Version: "v1"}},
+				PreferredVersion: metav1.GroupVersionForDiscovery{GroupVersion: "local.example.com/v1", Version: "v1"},
+			},
+			{
+				Name:             "broken.example.com",
+				Versions:         []metav1.GroupVersionForDiscovery{{GroupVersion: "broken.example.com/v1beta1", Version: "v1beta1"}},
+				PreferredVersion: metav1.GroupVersionForDiscovery{GroupVersion: "broken.example.com/v1beta1", Version: "v1beta1"},
+			},
+		}
+		if apiGroupList.Kind != "APIGroupList" || !reflect.DeepEqual(apiGroupList.Groups, expected) {
+			t.Errorf("Accept %q: expected groups %#v, got %#v", accept, expected, apiGroupList)
+		}
+	}
+	if requests := f.backend.requests.Load(); requests != 0 {
+		t.Errorf("expected the APIGroupList not to fetch resources, got %d requests", requests)
+	}
+}
+
+func TestAggregatedDiscovery(t *testing.T) {
+	f := newDiscoveryFixture(t)
+
+	list := &apidiscoveryv2.APIGroupDiscoveryList{}
+	w := f.get(t, kubectlAccept, list)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != aggregatedDiscoveryContentType {
+		t.Fatalf("expected an APIGroupDiscoveryList, got status %d of %q: %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	fooV1Resources := []apidiscoveryv2.APIResourceDiscovery{
+		{
+			Resource:         "bazs",
+			ResponseKind:     &metav1.GroupVersionKind{Group: "foo.example.com", Version: "v1", Kind: "Baz"},
+			Scope:            apidiscoveryv2.ScopeCluster,
+			SingularResource: "baz",
+			Verbs:            []string{"get"},
+			Categories:       []string{"all"},
+		},
+		{
+			Resource:         "foos",
+			ResponseKind:     &metav1.GroupVersionKind{Group: "foo.example.com", Version: "v1", Kind: "Foo"},
+			Scope:            apidiscoveryv2.ScopeNamespace,
+			SingularResource: "foo",
+			Verbs:            []string{"get", "list"},
+			ShortNames:       []string{"fo"},
+			Subresources: []apidiscoveryv2.APISubresourceDiscovery{{
+				Subresource:  "status",
+				ResponseKind: &metav1.GroupVersionKind{Group: "foo.example.com", Version: "v1", Kind: "Foo"},
+				Verbs:        []string{"get", "update"},
+			}},
+		},
+	}
+	fooV2Resources := []apidiscoveryv2.APIResourceDiscovery{{
+		Resource:         "foos",
+		ResponseKind:     &metav1.GroupVersionKind{Group: "foo.example.com", Version: "v2", Kind: "Foo"},
+		Scope:            apidiscoveryv2.ScopeNamespace,
+		SingularResource: "foo",
+		Verbs:            []string{"get", "list"},
+		ShortNames:       []string{"fo"},
+	}}
+	expected := []apidiscoveryv2.APIGroupDiscovery{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo.example.com"},
+			Versions: []apidiscoveryv2.APIVersionDiscovery{
+				{Version: "v2", Resources: fooV2Resources, Freshness: apidiscoveryv2.DiscoveryFreshnessCurrent},
+				{Version: "v1", Resources: fooV1Resources, Freshness: apidiscoveryv2.DiscoveryFreshnessCurrent},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "local.example.com"},
+			Versions: []apidiscoveryv2.APIVersionDiscovery{{
+				Version: "v1",
+				Resources: []apidiscoveryv2.APIResourceDiscovery{{
+					Resource:         "bars",
+					ResponseKind:     &metav1.GroupVersionKind{Group: "local.example.com", Version: "v1", Kind: "Bar"},
+					Scope:            apidiscoveryv2.ScopeCluster,
+					SingularResource: "bar",
+					Verbs:            []string{"get"},
+				}},
+				Freshness: apidiscoveryv2.DiscoveryFreshnessCurrent,
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "broken.example.com"},
+			Versions:   []apidiscoveryv2.APIVersionDiscovery{{Version: "v1beta1", Freshness: apidiscoveryv2.DiscoveryFreshnessStale}},
+		},
+	}
+	if list.Kind != "APIGroupDiscoveryList" || list.APIVersion != "apidiscovery.k8s.io/v2" || !reflect.DeepEqual(list.Items, expected) {
+		t.Errorf("expected groups %#v, got %#v", expected, list)
+	}
+}
+
+func TestAggregatedDiscoveryCache(t *testing.T) {
+	f := newDiscoveryFixture(t)
+
+	f.get(t, kubectlAccept, nil)
+	if requests := f.backend.requests.Load(); requests != 2 {
+		t.Fatalf("expected both foo.example.com versions to be fetched, got %d requests", requests)
+	}
+	f.get(t, kubectlAccept, nil)
+	if requests := f.backend.requests.Load(); requests != 2 {
+		t.Errorf("expected resources to be served from the cache, got %d requests", requests)
+	}
+
+	// A changed APIService is fetched again.
+	obj, _, _ := f.indexer.GetByKey("v1.foo.example.com")
+	fooV1 := obj.(*registrationv1.APIService).DeepCopy()
+	fooV1.ResourceVersion = "2"
+	f.add(t, fooV1)
+	f.get(t, kubectlAccept, nil)
+	if requests := f.backend.requests.Load(); requests != 3 {
+		t.Errorf("expected the changed APIService to be fetched again, got %d requests", requests)
+	}
+
+	// So are all of them once the cache expires.
+	f.aggregator.apis.discovery.refreshInterval = 0
+	f.get(t, kubectlAccept, nil)
+	if requests := f.backend.requests.Load(); requests != 5 {
+		t.Errorf("expected expired resources to be fetched again, got %d requests", requests)
+	}
+}
+
+func TestStaleDiscoveryKeepsResources(t *testing.T) {
+	f := newDiscoveryFixture(t)
+	f.get(t, kubectlAccept, nil)
+
+	// The backend stops serving v2, the last resources are served as stale.
+	delete(f.backend.resources, "foo.example.com/v2")
+	f.aggregator.apis.discovery.refreshInterval = 0
+	list := &apidiscoveryv2.APIGroupDiscoveryList{}
+	f.get(t, kubectlAccept, list)
+
+	v2 := list.Items[0].Versions[0]
+	if v2.Version != "v2" || v2.Freshness != apidiscoveryv2.DiscoveryFreshnessStale || len(v2.Resources) != 1 {
+		t.Errorf("expected v2 to keep its resources as stale, got %#v", v2)
+	}
+}
+
+func TestDiscoveryNotAcceptable(t *testing.T) {
+	f := newDiscoveryFixture(t)
+	if w := f.get(t, "application/yaml", nil); w.Code != http.StatusNotAcceptable {
+		t.Errorf("expected status %d, got %d: %s", http.StatusNotAcceptable, w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,216 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+)
+
+// proxyHandler provides a http.Handler which will proxy traffic to the service
+// backing a single APIService, or hand it to localDelegate when the APIService
+// is served in-process. Requests fail fast while the circuit of the APIService
+// is open.
+type proxyHandler struct {
+	serviceResolver ServiceResolver
+	trustProvider   *TrustProvider
+	circuitBreakers *CircuitBreakers
+	localDelegate   http.Handler
+
+	// handlingInfo holds the *proxyHandlingInfo of the current revision of the
+	// APIService, swapped as a whole whenever the APIService changes.
+	handlingInfo atomic.Pointer[proxyHandlingInfo]
+}
+
+// proxyHandlingInfo is everything needed to proxy to the service of an APIService.
+type proxyHandlingInfo struct {
+	// name is the name of the APIService
+	name string
+	// local is set for APIServices without a service, which are served by the
+	// local delegate
+	local bool
+	// transport is the transport used to reach the service, verifying its
+	// serving certificate with trust
+	transport *http.Transport
+	trust     *servingCertTrust
+
+	serviceNamespace string
+	serviceName      string
+	servicePort      int32
+}
+
+var _ http.Handler = &proxyHandler{}
+
+func (r *proxyHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	handlingInfo := r.handlingInfo.Load()
+	if handlingInfo == nil {
+		proxyError(w, "service unavailable", 0)
+		return
+	}
+	if handlingInfo.local {
+		r.localDelegate.ServeHTTP(w, req)
+		return
+	}
+	trial, retryAfter, err := r.circuitBreakers.allow(handlingInfo.name)
+	if err != nil {
+		klog.V(4).InfoS("Failing request fast", "apiService", handlingInfo.name, "err", err)
+		proxyError(w, fmt.Sprintf("service of APIService %q unavailable, %v", handlingInfo.name, err), retryAfter)
+		return
+	}
+	// The outcome of a trial request closes the half-open circuit or opens it again.
+	record := r.circuitBreakers.Record
+	if trial {
+		record = r.circuitBreakers.recordTrial
+	}
+
+	location, err := r.serviceResolver.ResolveEndpoint(handlingInfo.serviceNamespace, handlingInfo.serviceName, handlingInfo.servicePort)
+	if err != nil {
+		klog.ErrorS(err, "Error resolving service", "apiService", handlingInfo.name,
+			"service", klog.KRef(handlingInfo.serviceNamespace, handlingInfo.serviceName))
+		if trial {
+			r.circuitBreakers.releaseTrial(handlingInfo.name)
+		}
+		proxyError(w, "service unavailable", 0)
+		return
+	}
+
+	start := r.circuitBreakers.clock.Now()
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(location)
+			pr.SetXForwarded()
+			setFrontProxyHeaders(pr.Out)
+		},
+		Transport: handlingInfo.transport,
+		// The response headers are in, long-running requests such as watches
+		// only count for how long the service took to answer them.
+		ModifyResponse: func(resp *http.Response) error {
+			record(handlingInfo.name, resp.StatusCode >= http.StatusInternalServerError, r.circuitBreakers.clock.Since(start))
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			klog.ErrorS(err, "Error proxying request", "apiService", handlingInfo.name, "url", req.URL.String())
+			// A client going away is no fault of the service.
+			switch {
+			case !errors.Is(err, context.Canceled):
+				record(handlingInfo.name, true, r.circuitBreakers.clock.Since(start))
+			case trial:
+				r.circuitBreakers.releaseTrial(handlingInfo.name)
+			}
+			proxyError(w, "service unavailable", 0)
+		},
+	}
+	proxy.ServeHTTP(w, req)
+}
+
+// setFrontProxyHeaders removes the credentials and the impersonation of the
+// client from req, which the service is not meant to see, and replaces any
+// X-Remote- header sent by the client with the user authenticated by the
+// aggregator, if any.
+func setFrontProxyHeaders(req *http.Request) {
+	for name := range req.Header {
+		if strings.HasPrefix(name, "Impersonate-") || strings.HasPrefix(name, "X-Remote-") {
+			req.Header.Del(name)
+		}
+	}
+	req.Header.Del("Authorization")
+
+	user, ok := genericapirequest.UserFrom(req.Context())
+	if !ok {
+		return
+	}
+	req.Header.Set("X-Remote-User", user.GetName())
+	for _, group := range user.GetGroups() {
+		req.Header.Add("X-Remote-Group", group)
+	}
+	for key, values := range user.GetExtra() {
+		for _, value := range values {
+			req.Header.Add("X-Remote-Extra-"+url.PathEscape(key), value)
+		}
+	}
+}
+
+// updateAPIService switches the proxyHandler to the given revision of an
+// APIService. Requests are rejected until the next update when the APIService
+// cannot be proxied to.
+func (r *proxyHandler) updateAPIService(apiService *registrationv1.APIService) error {
+	if apiService.Spec.Service == nil {
+		r.trustProvider.Forget(apiService.Name)
+		r.circuitBreakers.Forget(apiService.Name)
+		r.setHandlingInfo(&proxyHandlingInfo{name: apiService.Name, local: true})
+		return nil
+	}
+
+	trust, err := r.trustProvider.trustFor(apiService)
+	if err != nil {
+		r.setHandlingInfo(nil)
+		return err
+	}
+	handlingInfo := &proxyHandlingInfo{
+		name:             apiService.Name,
+		trust:            trust,
+		serviceNamespace: apiService.Spec.Service.Namespace,
+		serviceName:      apiService.Spec.Service.Name,
+		servicePort:      ptr.Deref(apiService.Spec.Service.Port, registrationv1.DefaultServicePort),
+	}
+	// Keep the connections to the service when neither it nor its trust changed.
+	if current := r.handlingInfo.Load(); current != nil && current.transport != nil && current.trust == trust &&
+		current.serviceNamespace == handlingInfo.serviceNamespace && current.serviceName == handlingInfo.serviceName &&
+		current.servicePort == handlingInfo.servicePort {
+		return nil
+	}
+	handlingInfo.transport = utilnet.SetTransportDefaults(&http.Transport{TLSClientConfig: trust.tlsConfig()})
+	// Failures of the previous service, or of reaching it with the previous
+	// trust, do not count against this one.
+	r.circuitBreakers.Forget(apiService.Name)
+	r.setHandlingInfo(handlingInfo)
+	return nil
+}
+
+// setHandlingInfo swaps in the given handling info, releasing the connections
+// held by the previous one.
+func (r *proxyHandler) setHandlingInfo(handlingInfo *proxyHandlingInfo) {
+	if old := r.handlingInfo.Swap(handlingInfo); old != nil && old.transport != nil {
+		old.transport.CloseIdleConnections()
+	}
+}
+
+// proxyError responds with a 503 metav1.Status, asking the client to retry
+// after retryAfter when it is set.
+func proxyError(w http.ResponseWriter, msg string, retryAfter time.Duration) {
+	status := apierrors.NewServiceUnavailable(msg).ErrStatus
+	status.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Status"}
+	if retryAfter > 0 {
+		seconds := int32(math.Ceil(retryAfter.Seconds()))
+		status.Details = &metav1.StatusDetails{RetryAfterSeconds: seconds}
+		w.Header().Set("Retry-After", strconv.Itoa(int(seconds)))
+	}
+	body, err := json.Marshal(&status)
+	if err != nil {
+		http.Error(w, msg, http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", runtime.ContentTypeJSON)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(int(status.Code))
+	if _, err := w.Write(body); err != nil {
+		klog.ErrorS(err, "Error writing proxy error response")
+	}
+}
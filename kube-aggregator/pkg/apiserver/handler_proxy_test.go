@@ -0,0 +1,509 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/clientset_generated/clientset/fake"
+	informers "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/informers/externalversions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/authentication/request/headerrequest"
+	"k8s.io/apiserver/pkg/authentication/user"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	testServiceNamespace = "test-ns"
+	testServiceName      = "api"
+)
+
+// echoHandler responds with the method and URI of the request, and the host it
+// was sent to in the X-Backend-Host header.
+var echoHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("X-Backend-Host", req.Host)
+	_, _ = io.WriteString(w, req.Method+" "+req.URL.RequestURI())
+})
+
+// newBackend starts a TLS server standing in for the service test-ns/api,
+// serving a self-signed certificate for its cluster DNS name. It returns the
+// server and the PEM bundle trusting it.
+func newBackend(t *testing.T, handler http.Handler) (*httptest.Server, []byte) {
+	t.Helper()
+	return newBackendWithClientCAs(t, handler, nil)
+}
+
+// newBackendWithClientCAs starts a backend like newBackend which, as a
+// kube-apiserver, verifies the client certificates presented to it against
+// clientCAs.
+func newBackendWithClientCAs(t *testing.T, handler http.Handler, clientCAs *x509.CertPool) (*httptest.Server, []byte) {
+	t.Helper()
+	certPEM, keyPEM, err := certutil.GenerateSelfSignedCertKey(serviceDNSName(testServiceNamespace, testServiceName), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend := httptest.NewUnstartedServer(handler)
+	backend.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	if clientCAs != nil {
+		backend.TLS.ClientAuth = tls.VerifyClientCertIfGiven
+		backend.TLS.ClientCAs = clientCAs
+	}
+	backend.StartTLS()
+	t.Cleanup(backend.Close)
+	return backend, certPEM
+}
+
+// staticResolver resolves every service to the same URL and records the last
+// service it resolved.
+type staticResolver struct {
+	url *url.URL
+
+	lock            sync.Mutex
+	namespace, name string
+	port            int32
+}
+
+func (r *staticResolver) ResolveEndpoint(namespace, name string, port int32) (*url.URL, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.namespace, r.name, r.port = namespace, name, port
+	return r.url, nil
+}
+
+func newResolver(t *testing.T, backend *httptest.Server) *staticResolver {
+	t.Helper()
+	backendURL, err := url.Parse(backend.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &staticResolver{url: backendURL}
+}
+
+func newAPIService(group, version string, service *registrationv1.ServiceReference) *registrationv1.APIService {
+	return &registrationv1.APIService{
+		ObjectMeta: metav1.ObjectMeta{Name: version + "." + group},
+		Spec: registrationv1.APIServiceSpec{
+			Service:              service,
+			Group:                group,
+			Version:              version,
+			GroupPriorityMinimum: 1000,
+			VersionPriority:      15,
+		},
+	}
+}
+
+func testService(port *int32) *registrationv1.ServiceReference {
+	return &registrationv1.ServiceReference{Namespace: testServiceNamespace, Name: testServiceName, Port: port}
+}
+
+// notFoundDelegate answers every request with 404 and a recognizable body.
+var notFoundDelegate = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+	http.Error(w, "delegated", http.StatusNotFound)
+})
+
+// newAggregator returns an APIAggregator whose APIService informer is not
+// started, APIServices are added to the returned indexer by the test.
+func newAggregator(t *testing.T, resolver ServiceResolver, delegate http.Handler) (*APIAggregator, cache.Indexer) {
+	t.Helper()
+	apiServiceInformer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Registration().V1().APIServices()
+	aggregator, err := NewAPIAggregator(apiServiceInformer, resolver, delegate, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return aggregator, apiServiceInformer.Informer().GetIndexer()
+}
+
+func serve(handler http.Handler, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestProxyHandler(t *testing.T) {
+	backend, caBundle := newBackend(t, echoHandler)
+	_, otherCABundle := newBackend(t, echoHandler)
+
+	tests := []struct {
+		name       string
+		mutate     func(apiService *registrationv1.APIService)
+		expectErr  bool
+		expectCode int
+		expectPort int32
+	}{
+		{
+			name:       "ca bundle",
+			mutate:     func(apiService *registrationv1.APIService) { apiService.Spec.CABundle = caBundle },
+			expectCode: http.StatusOK,
+			expectPort: 443,
+		},
+		{
+			name: "explicit port",
+			mutate: func(apiService *registrationv1.APIService) {
+				apiService.Spec.Service.Port = ptr.To[int32](8443)
+				apiService.Spec.CABundle = caBundle
+			},
+			expectCode: http.StatusOK,
+			expectPort: 8443,
+		},
+		{
+			name:       "insecure skip tls verify",
+			mutate:     func(apiService *registrationv1.APIService) { apiService.Spec.InsecureSkipTLSVerify = true },
+			expectCode: http.StatusOK,
+			expectPort: 443,
+		},
+		{
+			name:       "system trust roots",
+			mutate:     func(apiService *registrationv1.APIService) {},
+			expectCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "other ca bundle",
+			mutate:     func(apiService *registrationv1.APIService) { apiService.Spec.CABundle = otherCABundle },
+			expectCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "invalid ca bundle",
+			mutate:     func(apiService *registrationv1.APIService) { apiService.Spec.CABundle = []byte("not a certificate") },
+			expectErr:  true,
+			expectCode: http.StatusServiceUnavailable,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := newResolver(t, backend)
+			aggregator, _ := newAggregator(t, resolver, notFoundDelegate)
+			apiService := newAPIService("foo.example.com", "v1", testService(nil))
+			tc.mutate(apiService)
+
+			err := aggregator.AddAPIService(apiService)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+
+			w := serve(aggregator, http.MethodGet, "/apis/foo.example.com/v1/namespaces/default/bars?limit=1")
+			if w.Code != tc.expectCode {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectCode, w.Code, w.Body.String())
+			}
+			if tc.expectCode != http.StatusOK {
+				return
+			}
+			if got, expected := w.Body.String(), "GET /apis/foo.example.com/v1/namespaces/default/bars?limit=1"; got != expected {
+				t.Errorf("expected backend to receive %q, got %q", expected, got)
+			}
+			if got := w.Header().Get("X-Backend-Host"); got != resolver.url.Host {
+				t.Errorf("expected backend to receive host %q, got %q", resolver.url.Host, got)
+			}
+			if resolver.namespace != testServiceNamespace || resolver.name != testServiceName || resolver.port != tc.expectPort {
+				t.Errorf("expected to resolve %s/%s:%d, got %s/%s:%d", testServiceNamespace, testServiceName, tc.expectPort,
+					resolver.namespace, resolver.name, resolver.port)
+			}
+		})
+	}
+}
+
+func TestProxyHandlerFrontProxyHeaders(t *testing.T) {
+	var received http.Header
+	backend, caBundle := newBackend(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received = req.Header.Clone()
+	}))
+	aggregator, _ := newAggregator(t, newResolver(t, backend), notFoundDelegate)
+	apiService := newAPIService("foo.example.com", "v1", testService(nil))
+	apiService.Spec.CABundle = caBundle
+	if err := aggregator.AddAPIService(apiService); err != nil {
+		t.Fatal(err)
+	}
+
+	newRequest := func(ctx context.Context) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/apis/foo.example.com/v1/bars", nil).WithContext(ctx)
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("Impersonate-User", "admin")
+		req.Header.Add("Impersonate-Group", "system:masters")
+		req.Header.Set("Impersonate-Extra-Scopes", "all")
+		req.Header.Set("X-Remote-User", "spoofed")
+		req.Header.Set("Accept", "application/json")
+		return req
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		expect http.Header
+	}{
+		{
+			name:   "anonymous",
+			ctx:    context.Background(),
+			expect: http.Header{},
+		},
+		{
+			name: "authenticated user",
+			ctx: genericapirequest.WithUser(context.Background(), &user.DefaultInfo{
+				Name:   "alice",
+				Groups: []string{"developers", "system:authenticated"},
+				Extra:  map[string][]string{"example.com/team": {"greeting"}},
+			}),
+			expect: http.Header{
+				"X-Remote-User":                     {"alice"},
+				"X-Remote-Group":                    {"developers", "system:authenticated"},
+				"X-Remote-Extra-Example.com%2fteam": {"greeting"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			received = nil
+			w := httptest.NewRecorder()
+			aggregator.ServeHTTP(w, newRequest(tc.ctx))
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			identity := http.Header{}
+			for name, values := range received {
+				switch {
+				case name == "Authorization" || strings.HasPrefix(name, "Impersonate-"):
+					t.Errorf("expected the backend not to receive %s, got %v", name, values)
+				case strings.HasPrefix(name, "X-Remote-"):
+					identity[name] = values
+				}
+			}
+			if !reflect.DeepEqual(identity, tc.expect) {
+				t.Errorf("expected identity headers %v, got %v", tc.expect, identity)
+			}
+			if received.Get("Accept") != "application/json" {
+				t.Errorf("expected other headers to be kept, got %v", received)
+			}
+		})
+	}
+}
+
+// TestProxyHandlerFrontProxyClientCert checks that a service authenticating
+// users with requestheader authentication, as aggregated apiservers do, sees
+// the user only when the aggregator presents its proxy client certificate.
+func TestProxyHandlerFrontProxyClientCert(t *testing.T) {
+	const proxyClientName = "front-proxy-client"
+	frontProxyCA := newTestCA(t, "front-proxy-ca")
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(frontProxyCA.cert)
+	authenticator := headerrequest.NewDynamicVerifyOptionsSecure(
+		func() (x509.VerifyOptions, bool) {
+			return x509.VerifyOptions{Roots: clientCAs, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}, true
+		},
+		headerrequest.StaticStringSlice{proxyClientName},
+		headerrequest.StaticStringSlice{"X-Remote-User"},
+		headerrequest.StaticStringSlice{"X-Remote-Uid"},
+		headerrequest.StaticStringSlice{"X-Remote-Group"},
+		headerrequest.StaticStringSlice{"X-Remote-Extra-"})
+	backend, caBundle := newBackendWithClientCAs(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		resp, ok, err := authenticator.AuthenticateRequest(req)
+		if err != nil || !ok {
+			http.Error(w, "anonymous", http.StatusUnauthorized)
+			return
+		}
+		_, _ = io.WriteString(w, resp.User.GetName()+" "+strings.Join(resp.User.GetGroups(), ","))
+	}), clientCAs)
+
+	dir := t.TempDir()
+	certPEM, keyPEM := frontProxyCA.clientCert(t, proxyClientName)
+	certFile, keyFile := filepath.Join(dir, "proxy-client.crt"), filepath.Join(dir, "proxy-client.key")
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		options    Options
+		expectCode int
+		expectBody string
+	}{
+		{
+			name:       "with proxy client certificate",
+			options:    Options{ProxyClientCertFile: certFile, ProxyClientKeyFile: keyFile},
+			expectCode: http.StatusOK,
+			expectBody: "alice developers,system:authenticated",
+		},
+		{
+			name:       "without proxy client certificate",
+			expectCode: http.StatusUnauthorized,
+			expectBody: "anonymous\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			apiServiceInformer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Registration().V1().APIServices()
+			aggregator, err := NewAPIAggregator(apiServiceInformer, newResolver(t, backend), notFoundDelegate, tc.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			apiService := newAPIService("foo.example.com", "v1", testService(nil))
+			apiService.Spec.CABundle = caBundle
+			if err := aggregator.AddAPIService(apiService); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := genericapirequest.WithUser(context.Background(), &user.DefaultInfo{
+				Name:   "alice",
+				Groups: []string{"developers", "system:authenticated"},
+			})
+			w := httptest.NewRecorder()
+			aggregator.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/apis/foo.example.com/v1/bars", nil).WithContext(ctx))
+			if w.Code != tc.expectCode || w.Body.String() != tc.expectBody {
+				t.Errorf("expected %d %q, got %d %q", tc.expectCode, tc.expectBody, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewAPIAggregatorInvalidProxyClientCert(t *testing.T) {
+	apiServiceInformer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Registration().V1().APIServices()
+	options := Options{ProxyClientCertFile: filepath.Join(t.TempDir(), "missing.crt")}
+	if _, err := NewAPIAggregator(apiServiceInformer, &staticResolver{}, notFoundDelegate, options); err == nil {
+		t.Error("expected an error for a missing proxy client certificate")
+	}
+}
+
+func TestAPIAggregatorRouting(t *testing.T) {
+	backend, caBundle := newBackend(t, echoHandler)
+	aggregator, _ := newAggregator(t, newResolver(t, backend), notFoundDelegate)
+
+	proxied := newAPIService("foo.example.com", "v1", testService(nil))
+	proxied.Spec.CABundle = caBundle
+	local := newAPIService("local.example.com", "v1", nil)
+	for _, apiService := range []*registrationv1.APIService{proxied, local} {
+		if err := aggregator.AddAPIService(apiService); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		path          string
+		expectProxied bool
+	}{
+		{path: "/apis/foo.example.com/v1", expectProxied: true},
+		{path: "/apis/foo.example.com/v1/", expectProxied: true},
+		{path: "/apis/foo.example.com/v1/bars/baz/status", expectProxied: true},
+		{path: "/apis/foo.example.com/v2"},
+		{path: "/apis/foo.example.com"},
+		{path: "/apis/foo.example.com/"},
+		{path: "/apis/local.example.com/v1/bars"},
+		{path: "/api/v1/namespaces"},
+		{path: "/healthz"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			w := serve(aggregator, http.MethodGet, tc.path)
+			if proxied := w.Code == http.StatusOK; proxied != tc.expectProxied {
+				t.Errorf("expected proxied %v, got status %d: %s", tc.expectProxied, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	aggregator.RemoveAPIService(proxied.Name)
+	if w := serve(aggregator, http.MethodGet, "/apis/foo.example.com/v1"); w.Code != http.StatusNotFound {
+		t.Errorf("expected removed APIService to be delegated, got status %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAPIAggregatorFollowsInformer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	backend, caBundle := newBackend(t, echoHandler)
+	apiService := newAPIService("foo.example.com", "v1", testService(nil))
+	apiService.Spec.CABundle = caBundle
+	client := fake.NewSimpleClientset(apiService)
+	informerFactory := informers.NewSharedInformerFactory(client, 0)
+
+	aggregator, err := NewAPIAggregator(informerFactory.Registration().V1().APIServices(), newResolver(t, backend),
+		notFoundDelegate, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	informerFactory.Start(ctx.Done())
+	informerFactory.WaitForCacheSync(ctx.Done())
+
+	expectStatus := func(expected int) {
+		t.Helper()
+		var got *httptest.ResponseRecorder
+		err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+			got = serve(aggregator, http.MethodPost, "/apis/foo.example.com/v1/bars")
+			return got.Code == expected, nil
+		})
+		if err != nil {
+			t.Fatalf("expected status %d, got %d: %s", expected, got.Code, got.Body.String())
+		}
+	}
+
+	expectStatus(http.StatusOK)
+
+	apiService = apiService.DeepCopy()
+	apiService.Spec.CABundle = nil
+	if _, err := client.RegistrationV1().APIServices().Update(ctx, apiService, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(http.StatusServiceUnavailable)
+
+	if err := client.RegistrationV1().APIServices().Delete(ctx, apiService.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(http.StatusNotFound)
+	if w := serve(aggregator, http.MethodGet, "/apis/foo.example.com/v1"); !strings.Contains(w.Body.String(), "delegated") {
+		t.Errorf("expected deleted APIService to be delegated, got %q", w.Body.String())
+	}
+}
+
+func TestLocalAPIServiceDelegation(t *testing.T) {
+	backend, caBundle := newBackend(t, echoHandler)
+	delegate := http.NewServeMux()
+	delegate.HandleFunc("/apis/local.example.com/v1/", func(w http.ResponseWriter, req *http.Request) {
+		_, _ = io.WriteString(w, "local "+req.URL.Path)
+	})
+	aggregator, _ := newAggregator(t, newResolver(t, backend), delegate)
+
+	local := newAPIService("local.example.com", "v1", nil)
+	if err := aggregator.AddAPIService(local); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(aggregator, http.MethodGet, "/apis/local.example.com/v1/bars"); w.Code != http.StatusOK || w.Body.String() != "local /apis/local.example.com/v1/bars" {
+		t.Fatalf("expected local APIService to be served by the delegate, got status %d: %s", w.Code, w.Body.String())
+	}
+
+	// The group version moves to a service, then back in-process.
+	proxied := local.DeepCopy()
+	proxied.Spec.Service = testService(nil)
+	proxied.Spec.CABundle = caBundle
+	if err := aggregator.AddAPIService(proxied); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(aggregator, http.MethodGet, "/apis/local.example.com/v1/bars"); w.Body.String() != "GET /apis/local.example.com/v1/bars" {
+		t.Fatalf("expected APIService with a service to be proxied, got status %d: %s", w.Code, w.Body.String())
+	}
+	if err := aggregator.AddAPIService(local); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(aggregator, http.MethodGet, "/apis/local.example.com/v1/bars"); w.Body.String() != "local /apis/local.example.com/v1/bars" {
+		t.Fatalf("expected local APIService to be served by the delegate, got status %d: %s", w.Code, w.Body.String())
+	}
+}
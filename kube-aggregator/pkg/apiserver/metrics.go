@@ -0,0 +1,28 @@
+package apiserver
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// servingCertExpiration is the expiry of the serving certificate last presented
+// by the service of each APIService.
+var servingCertExpiration = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "aggregator",
+		Name:      "apiservice_serving_cert_expiration_timestamp_seconds",
+		Help:      "Expiry, in seconds since the Unix epoch, of the serving certificate last presented by the service of an APIService.",
+	},
+	[]string{"apiservice"},
+)
+
+// RegisterMetrics registers the metrics of the aggregator with registerer.
+// Registering them again is not an error.
+func RegisterMetrics(registerer prometheus.Registerer) error {
+	err := registerer.Register(servingCertExpiration)
+	if are := (prometheus.AlreadyRegisteredError{}); errors.As(err, &are) && are.ExistingCollector == servingCertExpiration {
+		return nil
+	}
+	return err
+}
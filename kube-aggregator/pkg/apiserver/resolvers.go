@@ -0,0 +1,33 @@
+package apiserver
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+)
+
+// ServiceResolver knows how to get a URL given a service.
+type ServiceResolver interface {
+	ResolveEndpoint(namespace, name string, port int32) (*url.URL, error)
+}
+
+// NewClusterDNSServiceResolver returns a ServiceResolver that directly calls the
+// service's cluster DNS name, leaving the choice of endpoint to kube-proxy.
+func NewClusterDNSServiceResolver() ServiceResolver {
+	return &clusterDNSServiceResolver{}
+}
+
+type clusterDNSServiceResolver struct{}
+
+func (r *clusterDNSServiceResolver) ResolveEndpoint(namespace, name string, port int32) (*url.URL, error) {
+	return &url.URL{
+		Scheme: "https",
+		Host:   net.JoinHostPort(serviceDNSName(namespace, name), strconv.Itoa(int(port))),
+	}, nil
+}
+
+// serviceDNSName returns the in-cluster DNS name of a service, which is also the
+// name its serving certificate is verified against.
+func serviceDNSName(namespace, name string) string {
+	return name + "." + namespace + ".svc"
+}
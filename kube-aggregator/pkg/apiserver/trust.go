@@ -0,0 +1,179 @@
+package apiserver
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	"k8s.io/utils/clock"
+)
+
+// defaultRotationGracePeriod is how long the CABundle an APIService replaced
+// stays trusted, giving its service time to switch to a serving certificate of
+// the new CA.
+const defaultRotationGracePeriod = 10 * time.Minute
+
+// TrustProvider hands out the TLS config used to reach the service of every
+// APIService, following the revisions of the APIService. When the CABundle
+// changes, the replaced bundle stays trusted for a grace period, so the CA can
+// be rotated without failing requests. The serving certificate of each service
+// is verified on every handshake, and its expiry recorded.
+type TrustProvider struct {
+	clock       clock.PassiveClock
+	gracePeriod time.Duration
+	// proxyClientCert is presented to every service, nil for none.
+	proxyClientCert *tls.Certificate
+
+	lock sync.Mutex
+	// trusts are keyed by APIService name.
+	trusts map[string]*servingCertTrust
+}
+
+// NewTrustProvider returns an empty TrustProvider.
+func NewTrustProvider() *TrustProvider {
+	return &TrustProvider{
+		clock:       clock.RealClock{},
+		gracePeriod: defaultRotationGracePeriod,
+		trusts:      map[string]*servingCertTrust{},
+	}
+}
+
+// LoadProxyClientCert makes the provider present the client certificate and
+// key in the given PEM files to every service. Services only trust the
+// X-Remote- headers identifying the user from a front proxy authenticated by
+// such a certificate. It must be called before the provider is used.
+func (p *TrustProvider) LoadProxyClientCert(certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the proxy client certificate: %w", err)
+	}
+	p.proxyClientCert = &cert
+	return nil
+}
+
+// servingCertTrust verifies the serving certificate of the service of a single
+// revision of an APIService. It is immutable, a new one replaces it when the
+// APIService changes how its service is trusted.
+type servingCertTrust struct {
+	clock          clock.PassiveClock
+	apiServiceName string
+	serverName     string
+	insecure       bool
+	caBundle       []byte
+	clientCert     *tls.Certificate
+
+	// roots are the CAs of caBundle, nil for the system trust roots.
+	roots *x509.CertPool
+	// previousRoots are the CAs of the replaced CABundle, trusted until
+	// previousUntil.
+	previousRoots *x509.CertPool
+	previousUntil time.Time
+}
+
+// TLSConfig returns the client TLS config used to reach the service of
+// apiService, which must reference a service, and records apiService as the
+// current revision.
+func (p *TrustProvider) TLSConfig(apiService *registrationv1.APIService) (*tls.Config, error) {
+	trust, err := p.trustFor(apiService)
+	if err != nil {
+		return nil, err
+	}
+	return trust.tlsConfig(), nil
+}
+
+// Forget drops the trust of the named APIService along with its metrics.
+func (p *TrustProvider) Forget(apiServiceName string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.trusts, apiServiceName)
+	servingCertExpiration.DeleteLabelValues(apiServiceName)
+}
+
+// trustFor returns the trust of apiService, which is the one of the previous
+// revision as long as its service is trusted the same way.
+func (p *TrustProvider) trustFor(apiService *registrationv1.APIService) (*servingCertTrust, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	serverName := serviceDNSName(apiService.Spec.Service.Namespace, apiService.Spec.Service.Name)
+	old := p.trusts[apiService.Name]
+	if old != nil && old.serverName == serverName && old.insecure == apiService.Spec.InsecureSkipTLSVerify &&
+		bytes.Equal(old.caBundle, apiService.Spec.CABundle) {
+		return old, nil
+	}
+
+	trust := &servingCertTrust{
+		clock:          p.clock,
+		apiServiceName: apiService.Name,
+		serverName:     serverName,
+		insecure:       apiService.Spec.InsecureSkipTLSVerify,
+		caBundle:       apiService.Spec.CABundle,
+		clientCert:     p.proxyClientCert,
+	}
+	if !trust.insecure && len(trust.caBundle) > 0 {
+		trust.roots = x509.NewCertPool()
+		if !trust.roots.AppendCertsFromPEM(trust.caBundle) {
+			delete(p.trusts, apiService.Name)
+			return nil, fmt.Errorf("APIService %q has no valid certificate in its CABundle", apiService.Name)
+		}
+		// Keep trusting the replaced bundle while the service rotates to the new one.
+		if old != nil && !old.insecure && old.roots != nil && old.serverName == serverName {
+			trust.previousRoots = old.roots
+			trust.previousUntil = p.clock.Now().Add(p.gracePeriod)
+		}
+	}
+	p.trusts[apiService.Name] = trust
+	return trust, nil
+}
+
+// tlsConfig returns a client TLS config verifying the serving certificate with
+// verifyConnection rather than the built-in verification, which only knows a
+// single set of roots. The proxy client certificate is presented whatever CAs
+// the service asks for, it is up to the service to trust it.
+func (t *servingCertTrust) tlsConfig() *tls.Config {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         t.serverName,
+		InsecureSkipVerify: true, //nolint:gosec // verified in VerifyConnection
+		VerifyConnection:   t.verifyConnection,
+	}
+	if cert := t.clientCert; cert != nil {
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) { return cert, nil }
+	}
+	return config
+}
+
+// verifyConnection records the expiry of the serving certificate and verifies
+// it against the current CAs, or the replaced ones during their grace period.
+func (t *servingCertTrust) verifyConnection(state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("no serving certificate")
+	}
+	leaf := state.PeerCertificates[0]
+	servingCertExpiration.WithLabelValues(t.apiServiceName).Set(float64(leaf.NotAfter.Unix()))
+	if t.insecure {
+		return nil
+	}
+
+	opts := x509.VerifyOptions{
+		DNSName:       t.serverName,
+		Roots:         t.roots,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range state.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := leaf.Verify(opts)
+	if err != nil && t.previousRoots != nil && t.clock.Now().Before(t.previousUntil) {
+		opts.Roots = t.previousRoots
+		if _, previousErr := leaf.Verify(opts); previousErr == nil {
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,254 @@
+package apiserver
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+// testCA is a locally generated certificate authority.
+type testCA struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := certutil.NewSelfSignedCACert(certutil.Config{CommonName: name}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: certutil.CertificateBlockType, Bytes: cert.Raw})}
+}
+
+// servingCert issues a serving certificate of the service test-ns/api expiring
+// at notAfter.
+func (ca *testCA) servingCert(t *testing.T, notAfter time.Time) *tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: serviceDNSName(testServiceNamespace, testServiceName)},
+		DNSNames:     []string{serviceDNSName(testServiceNamespace, testServiceName)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, key.Public(), ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// clientCert issues a client certificate for commonName, returning its PEM
+// encoded certificate and key.
+func (ca *testCA) clientCert(t *testing.T, commonName string) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, key.Public(), ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM, err := keyutil.MarshalPrivateKeyToPEM(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: certutil.CertificateBlockType, Bytes: der}), keyPEM
+}
+
+// rotatingBackend is a backend whose serving certificate can be swapped.
+type rotatingBackend struct {
+	*httptest.Server
+	cert atomic.Pointer[tls.Certificate]
+}
+
+func newRotatingBackend(t *testing.T, cert *tls.Certificate) *rotatingBackend {
+	t.Helper()
+	b := &rotatingBackend{}
+	b.cert.Store(cert)
+	b.Server = httptest.NewUnstartedServer(echoHandler)
+	b.TLS = &tls.Config{GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return b.cert.Load(), nil }}
+	b.StartTLS()
+	t.Cleanup(b.Close)
+	return b
+}
+
+// rotate makes the backend serve cert on new connections and drops the
+// existing ones, which were verified with the previous certificate.
+func (b *rotatingBackend) rotate(cert *tls.Certificate) {
+	b.cert.Store(cert)
+	b.CloseClientConnections()
+}
+
+func TestCABundleRotation(t *testing.T) {
+	oldCA, newCA := newTestCA(t, "old-ca"), newTestCA(t, "new-ca")
+	notAfter := time.Now().Add(24 * time.Hour)
+	backend := newRotatingBackend(t, oldCA.servingCert(t, notAfter))
+
+	aggregator, _ := newAggregator(t, newResolver(t, backend.Server), notFoundDelegate)
+	fakeClock := testingclock.NewFakePassiveClock(time.Now())
+	aggregator.trustProvider.clock = fakeClock
+
+	apiService := newAPIService("foo.example.com", "v1", testService(nil))
+	expectStatus := func(expected int) {
+		t.Helper()
+		backend.CloseClientConnections()
+		if w := serve(aggregator, http.MethodGet, "/apis/foo.example.com/v1"); w.Code != expected {
+			t.Fatalf("expected status %d, got %d: %s", expected, w.Code, w.Body.String())
+		}
+	}
+	update := func(caBundle []byte) {
+		t.Helper()
+		apiService = apiService.DeepCopy()
+		apiService.Spec.CABundle = caBundle
+		if err := aggregator.AddAPIService(apiService); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	update(oldCA.pem)
+	expectStatus(http.StatusOK)
+
+	// A bundle of both CAs trusts serving certificates of either.
+	update(append(append([]byte{}, oldCA.pem...), newCA.pem...))
+	expectStatus(http.StatusOK)
+	backend.rotate(newCA.servingCert(t, notAfter))
+	expectStatus(http.StatusOK)
+
+	// Publishing the new CA alone before the service rotates keeps the old one
+	// trusted during the grace period.
+	backend.rotate(oldCA.servingCert(t, notAfter))
+	update(oldCA.pem)
+	update(newCA.pem)
+	expectStatus(http.StatusOK)
+	fakeClock.SetTime(fakeClock.Now().Add(defaultRotationGracePeriod + time.Second))
+	expectStatus(http.StatusServiceUnavailable)
+	backend.rotate(newCA.servingCert(t, notAfter))
+	expectStatus(http.StatusOK)
+
+	// Serving certificates of an unrelated CA are never trusted.
+	backend.rotate(newTestCA(t, "other-ca").servingCert(t, notAfter))
+	expectStatus(http.StatusServiceUnavailable)
+}
+
+func TestTransportKeptWhenTrustUnchanged(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	backend := newRotatingBackend(t, ca.servingCert(t, time.Now().Add(time.Hour)))
+	aggregator, _ := newAggregator(t, newResolver(t, backend.Server), notFoundDelegate)
+
+	apiService := newAPIService("foo.example.com", "v1", testService(nil))
+	apiService.Spec.CABundle = ca.pem
+	if err := aggregator.AddAPIService(apiService); err != nil {
+		t.Fatal(err)
+	}
+	transport := aggregator.proxyHandlerFor("/apis/foo.example.com/v1").handlingInfo.Load().transport
+
+	// Only the status changes.
+	apiService = apiService.DeepCopy()
+	apiService.Status.Conditions = []registrationv1.APIServiceCondition{{Type: registrationv1.Available, Status: registrationv1.ConditionTrue}}
+	if err := aggregator.AddAPIService(apiService); err != nil {
+		t.Fatal(err)
+	}
+	if got := aggregator.proxyHandlerFor("/apis/foo.example.com/v1").handlingInfo.Load().transport; got != transport {
+		t.Errorf("expected the transport to be kept")
+	}
+
+	apiService = apiService.DeepCopy()
+	apiService.Spec.CABundle = append(append([]byte{}, ca.pem...), newTestCA(t, "next-ca").pem...)
+	if err := aggregator.AddAPIService(apiService); err != nil {
+		t.Fatal(err)
+	}
+	if got := aggregator.proxyHandlerFor("/apis/foo.example.com/v1").handlingInfo.Load().transport; got == transport {
+		t.Errorf("expected the transport to be rebuilt for the new CABundle")
+	}
+}
+
+func TestServingCertExpirationMetric(t *testing.T) {
+	ca := newTestCA(t, "ca")
+	notAfter := time.Now().Add(90 * 24 * time.Hour).Truncate(time.Second)
+	backend := newRotatingBackend(t, ca.servingCert(t, notAfter))
+	aggregator, _ := newAggregator(t, newResolver(t, backend.Server), notFoundDelegate)
+
+	apiService := newAPIService("metrics.example.com", "v1", testService(nil))
+	apiService.Spec.CABundle = ca.pem
+	if err := aggregator.AddAPIService(apiService); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(aggregator, http.MethodGet, "/apis/metrics.example.com/v1"); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if got := testutil.ToFloat64(servingCertExpiration.WithLabelValues(apiService.Name)); got != float64(notAfter.Unix()) {
+		t.Errorf("expected serving certificate expiry %d, got %v", notAfter.Unix(), got)
+	}
+	// The aggregator exports the metric on the default registry.
+	if got, err := gatheredServingCertExpiration(apiService.Name); err != nil || got != float64(notAfter.Unix()) {
+		t.Errorf("expected serving certificate expiry %d to be exported, got %v: %v", notAfter.Unix(), got, err)
+	}
+
+	aggregator.RemoveAPIService(apiService.Name)
+	if servingCertExpiration.DeleteLabelValues(apiService.Name) {
+		t.Errorf("expected the metric of the removed APIService to be deleted")
+	}
+}
+
+// gatheredServingCertExpiration returns the serving certificate expiry of the
+// named APIService exported on the default registry.
+func gatheredServingCertExpiration(apiServiceName string) (float64, error) {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		return 0, err
+	}
+	for _, family := range families {
+		if family.GetName() != "aggregator_apiservice_serving_cert_expiration_timestamp_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "apiservice" && label.GetValue() == apiServiceName {
+					return metric.GetGauge().GetValue(), nil
+				}
+			}
+		}
+	}
+	return 0, fmt.Errorf("no series for APIService %q", apiServiceName)
+}
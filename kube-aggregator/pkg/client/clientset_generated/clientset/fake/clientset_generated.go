@@ -18,6 +18,7 @@ limitations under the License.
 package fake
 
 import (
+	applyconfiguration "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/applyconfiguration"
 	clientset "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/clientset_generated/clientset"
 	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/clientset_generated/clientset/typed/registration/v1"
 	fakeregistrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/clientset_generated/clientset/typed/registration/v1/fake"
@@ -79,6 +80,38 @@ func (c *Clientset) Tracker() testing.ObjectTracker {
 	return c.tracker
 }
 
+// NewClientset returns a clientset that will respond with the provided objects.
+// It's backed by a very simple object tracker that processes creates, updates and deletions as-is,
+// without applying any validations and/or defaults. It shouldn't be considered a replacement
+// for a real clientset and is mostly useful in simple unit tests.
+func NewClientset(objects ...runtime.Object) *Clientset {
+	o := testing.NewFieldManagedObjectTracker(
+		scheme,
+		codecs.UniversalDecoder(),
+		applyconfiguration.NewTypeConverter(scheme),
+	)
+	for _, obj := range objects {
+		if err := o.Add(obj); err != nil {
+			panic(err)
+		}
+	}
+
+	cs := &Clientset{tracker: o}
+	cs.discovery = &fakediscovery.FakeDiscovery{Fake: &cs.Fake}
+	cs.AddReactor("*", "*", testing.ObjectReaction(o))
+	cs.AddWatchReactor("*", func(action testing.Action) (handled bool, ret watch.Interface, err error) {
+		gvr := action.GetResource()
+		ns := action.GetNamespace()
+		watch, err := o.Watch(gvr, ns)
+		if err != nil {
+			return false, nil, err
+		}
+		return true, watch, nil
+	})
+
+	return cs
+}
+
 var (
 	_ clientset.Interface = &Clientset{}
 	_ testing.FakeClient  = &Clientset{}
@@ -21,6 +21,7 @@ import (
 	context "context"
 
 	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	applyconfigurationregistrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/applyconfiguration/registration/v1"
 	scheme "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/clientset_generated/clientset/scheme"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	types "k8s.io/apimachinery/pkg/types"
@@ -46,18 +47,21 @@ type APIServiceInterface interface {
 	List(ctx context.Context, opts metav1.ListOptions) (*registrationv1.APIServiceList, error)
 	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
 	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *registrationv1.APIService, err error)
+	Apply(ctx context.Context, aPIService *applyconfigurationregistrationv1.APIServiceApplyConfiguration, opts metav1.ApplyOptions) (result *registrationv1.APIService, err error)
+	// Add a +genclient:noStatus comment above the type to avoid generating ApplyStatus().
+	ApplyStatus(ctx context.Context, aPIService *applyconfigurationregistrationv1.APIServiceApplyConfiguration, opts metav1.ApplyOptions) (result *registrationv1.APIService, err error)
 	APIServiceExpansion
 }
 
 // aPIServices implements APIServiceInterface
 type aPIServices struct {
-	*gentype.ClientWithList[*registrationv1.APIService, *registrationv1.APIServiceList]
+	*gentype.ClientWithListAndApply[*registrationv1.APIService, *registrationv1.APIServiceList, *applyconfigurationregistrationv1.APIServiceApplyConfiguration]
 }
 
 // newAPIServices returns a APIServices
 func newAPIServices(c *RegistrationV1Client) *aPIServices {
 	return &aPIServices{
-		gentype.NewClientWithList[*registrationv1.APIService, *registrationv1.APIServiceList](
+		gentype.NewClientWithListAndApply[*registrationv1.APIService, *registrationv1.APIServiceList, *applyconfigurationregistrationv1.APIServiceApplyConfiguration](
 			"apiservices",
 			c.RESTClient(),
 			scheme.ParameterCodec,
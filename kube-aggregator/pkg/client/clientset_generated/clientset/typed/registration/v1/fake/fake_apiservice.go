@@ -19,19 +19,20 @@ package fake
 
 import (
 	v1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
-	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/clientset_generated/clientset/typed/registration/v1"
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/applyconfiguration/registration/v1"
+	typedregistrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/clientset_generated/clientset/typed/registration/v1"
 	gentype "k8s.io/client-go/gentype"
 )
 
 // fakeAPIServices implements APIServiceInterface
 type fakeAPIServices struct {
-	*gentype.FakeClientWithList[*v1.APIService, *v1.APIServiceList]
+	*gentype.FakeClientWithListAndApply[*v1.APIService, *v1.APIServiceList, *registrationv1.APIServiceApplyConfiguration]
 	Fake *FakeRegistrationV1
 }
 
-func newFakeAPIServices(fake *FakeRegistrationV1) registrationv1.APIServiceInterface {
+func newFakeAPIServices(fake *FakeRegistrationV1) typedregistrationv1.APIServiceInterface {
 	return &fakeAPIServices{
-		gentype.NewFakeClientWithList[*v1.APIService, *v1.APIServiceList](
+		gentype.NewFakeClientWithListAndApply[*v1.APIService, *v1.APIServiceList, *registrationv1.APIServiceApplyConfiguration](
 			fake.Fake,
 			"",
 			v1.SchemeGroupVersion.WithResource("apiservices"),
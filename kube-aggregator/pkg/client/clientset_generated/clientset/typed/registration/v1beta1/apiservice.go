@@ -21,6 +21,7 @@ import (
 	context "context"
 
 	registrationv1beta1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1beta1"
+	applyconfigurationregistrationv1beta1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/applyconfiguration/registration/v1beta1"
 	scheme "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/clientset_generated/clientset/scheme"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	types "k8s.io/apimachinery/pkg/types"
@@ -46,18 +47,21 @@ type APIServiceInterface interface {
 	List(ctx context.Context, opts v1.ListOptions) (*registrationv1beta1.APIServiceList, error)
 	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
 	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *registrationv1beta1.APIService, err error)
+	Apply(ctx context.Context, aPIService *applyconfigurationregistrationv1beta1.APIServiceApplyConfiguration, opts v1.ApplyOptions) (result *registrationv1beta1.APIService, err error)
+	// Add a +genclient:noStatus comment above the type to avoid generating ApplyStatus().
+	ApplyStatus(ctx context.Context, aPIService *applyconfigurationregistrationv1beta1.APIServiceApplyConfiguration, opts v1.ApplyOptions) (result *registrationv1beta1.APIService, err error)
 	APIServiceExpansion
 }
 
 // aPIServices implements APIServiceInterface
 type aPIServices struct {
-	*gentype.ClientWithList[*registrationv1beta1.APIService, *registrationv1beta1.APIServiceList]
+	*gentype.ClientWithListAndApply[*registrationv1beta1.APIService, *registrationv1beta1.APIServiceList, *applyconfigurationregistrationv1beta1.APIServiceApplyConfiguration]
 }
 
 // newAPIServices returns a APIServices
 func newAPIServices(c *RegistrationV1beta1Client) *aPIServices {
 	return &aPIServices{
-		gentype.NewClientWithList[*registrationv1beta1.APIService, *registrationv1beta1.APIServiceList](
+		gentype.NewClientWithListAndApply[*registrationv1beta1.APIService, *registrationv1beta1.APIServiceList, *applyconfigurationregistrationv1beta1.APIServiceApplyConfiguration](
 			"apiservices",
 			c.RESTClient(),
 			scheme.ParameterCodec,
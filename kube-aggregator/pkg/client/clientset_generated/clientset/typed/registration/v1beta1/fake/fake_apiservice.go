@@ -19,19 +19,20 @@ package fake
 
 import (
 	v1beta1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1beta1"
-	registrationv1beta1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/clientset_generated/clientset/typed/registration/v1beta1"
+	registrationv1beta1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/applyconfiguration/registration/v1beta1"
+	typedregistrationv1beta1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/clientset_generated/clientset/typed/registration/v1beta1"
 	gentype "k8s.io/client-go/gentype"
 )
 
 // fakeAPIServices implements APIServiceInterface
 type fakeAPIServices struct {
-	*gentype.FakeClientWithList[*v1beta1.APIService, *v1beta1.APIServiceList]
+	*gentype.FakeClientWithListAndApply[*v1beta1.APIService, *v1beta1.APIServiceList, *registrationv1beta1.APIServiceApplyConfiguration]
 	Fake *FakeRegistrationV1beta1
 }
 
-func newFakeAPIServices(fake *FakeRegistrationV1beta1) registrationv1beta1.APIServiceInterface {
+func newFakeAPIServices(fake *FakeRegistrationV1beta1) typedregistrationv1beta1.APIServiceInterface {
 	return &fakeAPIServices{
-		gentype.NewFakeClientWithList[*v1beta1.APIService, *v1beta1.APIServiceList](
+		gentype.NewFakeClientWithListAndApply[*v1beta1.APIService, *v1beta1.APIServiceList, *registrationv1beta1.APIServiceApplyConfiguration](
 			fake.Fake,
 			"",
 			v1beta1.SchemeGroupVersion.WithResource("apiservices"),
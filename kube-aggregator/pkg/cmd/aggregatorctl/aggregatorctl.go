@@ -0,0 +1,97 @@
+package aggregatorctl
+
+import (
+	"fmt"
+	"io"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1/helper"
+	clientset "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/clientset_generated/clientset"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// clientFactory builds the registration clientset from the kubeconfig flags.
+type clientFactory struct {
+	kubeconfig string
+	context    string
+
+	client clientset.Interface
+}
+
+func (f *clientFactory) AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&f.kubeconfig, "kubeconfig", f.kubeconfig, "Path to the kubeconfig file to use.")
+	flags.StringVar(&f.context, "context", f.context, "The name of the kubeconfig context to use.")
+}
+
+// Client returns the registration clientset, built on first use.
+func (f *clientFactory) Client() (clientset.Interface, error) {
+	if f.client != nil {
+		return f.client, nil
+	}
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = f.kubeconfig
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules,
+		&clientcmd.ConfigOverrides{CurrentContext: f.context}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error building kubeconfig: %w", err)
+	}
+	f.client, err = clientset.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("error building registration clientset: %w", err)
+	}
+	return f.client, nil
+}
+
+// NewCommand returns the aggregatorctl command, managing the registration.foen.ye/v1
+// APIServices of the cluster.
+func NewCommand(out, errOut io.Writer) *cobra.Command {
+	return newCommand(&clientFactory{}, out, errOut)
+}
+
+func newCommand(f *clientFactory, out, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "aggregatorctl",
+		Short:        "Manage the APIServices registered with kube-aggregator",
+		SilenceUsage: true,
+	}
+	cmd.SetOut(out)
+	cmd.SetErr(errOut)
+	f.AddFlags(cmd.PersistentFlags())
+
+	cmd.AddCommand(
+		newRegisterCommand(f, out),
+		newUnregisterCommand(f, out),
+		newListCommand(f, out),
+		newDescribeCommand(f, out),
+		newCheckCommand(f, out),
+	)
+	return cmd
+}
+
+// serviceString returns the namespace/name:port of the service of apiService,
+// or Local when it has none.
+func serviceString(apiService *registrationv1.APIService) string {
+	service := apiService.Spec.Service
+	if service == nil {
+		return "Local"
+	}
+	if service.Port == nil {
+		return service.Namespace + "/" + service.Name
+	}
+	return fmt.Sprintf("%s/%s:%d", service.Namespace, service.Name, *service.Port)
+}
+
+// availableString returns the status of the Available condition of apiService,
+// followed by its reason unless it is True.
+func availableString(apiService *registrationv1.APIService) string {
+	condition := helper.GetAPIServiceConditionByType(apiService, registrationv1.Available)
+	if condition == nil {
+		return string(registrationv1.ConditionUnknown)
+	}
+	if condition.Status == registrationv1.ConditionTrue || len(condition.Reason) == 0 {
+		return string(condition.Status)
+	}
+	return fmt.Sprintf("%s (%s)", condition.Status, condition.Reason)
+}
@@ -0,0 +1,255 @@
+package aggregatorctl
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/clientset_generated/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+// run runs aggregatorctl with args against client and returns its output.
+func run(t *testing.T, client *fake.Clientset, args ...string) (string, error) {
+	t.Helper()
+	out := &bytes.Buffer{}
+	cmd := newCommand(&clientFactory{client: client}, out, out)
+	cmd.SetArgs(args)
+	err := cmd.ExecuteContext(context.Background())
+	return out.String(), err
+}
+
+func newAPIService(name string, created time.Time, conditions ...registrationv1.APIServiceCondition) *registrationv1.APIService {
+	version, group, _ := strings.Cut(name, ".")
+	return &registrationv1.APIService{
+		ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(created)},
+		Spec: registrationv1.APIServiceSpec{
+			Service:              &registrationv1.ServiceReference{Namespace: "test-ns", Name: "api"},
+			Group:                group,
+			Version:              version,
+			GroupPriorityMinimum: 1000,
+			VersionPriority:      15,
+		},
+		Status: registrationv1.APIServiceStatus{Conditions: conditions},
+	}
+}
+
+func TestRegister(t *testing.T) {
+	caBundle, _, err := certutil.GenerateSelfSignedCertKey("api.test-ns.svc", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(caFile, caBundle, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client := fake.NewSimpleClientset()
+	args := []string{"register", "--group", "foo.example.com", "--version", "v1",
+		"--service-namespace", "test-ns", "--service-name", "api", "--ca-file", caFile}
+
+	out, err := run(t, client, args...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v: %s", err, out)
+	}
+	if out != "apiservice/v1.foo.example.com registered\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+	apiService, err := client.RegistrationV1().APIServices().Get(context.TODO(), "v1.foo.example.com", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(apiService.Spec.CABundle, caBundle) {
+		t.Errorf("expected the CABundle to be read from --ca-file, got %q", apiService.Spec.CABundle)
+	}
+	if service := apiService.Spec.Service; service.Namespace != "test-ns" || service.Name != "api" || *service.Port != 443 {
+		t.Errorf("unexpected service %+v", service)
+	}
+
+	// Registering again updates the APIService.
+	out, err = run(t, client, append(args, "--version-priority", "20")...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v: %s", err, out)
+	}
+	if out != "apiservice/v1.foo.example.com configured\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+	apiService, err = client.RegistrationV1().APIServices().Get(context.TODO(), "v1.foo.example.com", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if apiService.Spec.VersionPriority != 20 {
+		t.Errorf("expected version priority 20, got %d", apiService.Spec.VersionPriority)
+	}
+}
+
+func TestRegisterInvalid(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	out, err := run(t, client, "register", "--group", "foo.example.com", "--version", "v1",
+		"--service-namespace", "test-ns", "--service-name", "api", "--version-priority", "0")
+	if err == nil || !strings.Contains(err.Error(), "spec.versionPriority") {
+		t.Fatalf("expected a versionPriority error, got %v: %s", err, out)
+	}
+	if len(client.Actions()) != 0 {
+		t.Errorf("expected no requests, got %v", client.Actions())
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	client := fake.NewSimpleClientset(newAPIService("v1.foo.example.com", time.Now()))
+	out, err := run(t, client, "unregister", "v1.foo.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v: %s", err, out)
+	}
+	if out != "apiservice/v1.foo.example.com unregistered\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+	if _, err := run(t, client, "unregister", "v1.foo.example.com"); err == nil {
+		t.Errorf("expected unregistering a missing APIService to fail")
+	}
+}
+
+func TestPrintAPIServices(t *testing.T) {
+	now := time.Date(2025, time.January, 10, 0, 0, 0, 0, time.UTC)
+	local := newAPIService("v1.local.example.com", now.Add(-3*24*time.Hour),
+		registrationv1.APIServiceCondition{Type: registrationv1.Available, Status: registrationv1.ConditionTrue, Reason: "Local"})
+	local.Spec.Service = nil
+	failing := newAPIService("v1.foo.example.com", now.Add(-90*time.Minute),
+		registrationv1.APIServiceCondition{Type: registrationv1.Available, Status: registrationv1.ConditionFalse, Reason: "FailedDiscoveryCheck"})
+	unknown := newAPIService("v2.foo.example.com", now.Add(-30*time.Second))
+
+	out := &bytes.Buffer{}
+	if err := printAPIServices(out, []registrationv1.APIService{*local, *failing, *unknown}, now); err != nil {
+		t.Fatal(err)
+	}
+	expected := `NAME                   SERVICE       AVAILABLE                      AGE
+v1.local.example.com   Local         True                           3d
+v1.foo.example.com     test-ns/api   False (FailedDiscoveryCheck)   90m
+v2.foo.example.com     test-ns/api   Unknown                        30s
+`
+	if out.String() != expected {
+		t.Errorf("unexpected table:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	client := fake.NewSimpleClientset(newAPIService("v1.foo.example.com", time.Now(),
+		registrationv1.APIServiceCondition{Type: registrationv1.Available, Status: registrationv1.ConditionTrue, Reason: "Passed", Message: "all checks passed"}))
+	out, err := run(t, client, "describe", "v1.foo.example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v: %s", err, out)
+	}
+	for _, expected := range []string{"Group Version:", "foo.example.com/v1", "test-ns/api", "CA Bundle:", "<none>", "Available", "all checks passed"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in:\n%s", expected, out)
+		}
+	}
+}
+
+// newBackend starts a TLS server serving the discovery of foo.example.com/v1
+// with a certificate for dnsName. It returns the server and the PEM bundle
+// trusting it.
+func newBackend(t *testing.T, dnsName string) (*httptest.Server, []byte) {
+	t.Helper()
+	certPEM, keyPEM, err := certutil.GenerateSelfSignedCertKey(dnsName, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/apis/foo.example.com/v1" {
+			http.NotFound(w, req)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(&metav1.APIResourceList{
+			GroupVersion: "foo.example.com/v1",
+			APIResources: []metav1.APIResource{{Name: "bars", Kind: "Bar"}, {Name: "bars/status", Kind: "Bar"}},
+		})
+	}))
+	backend.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	backend.StartTLS()
+	t.Cleanup(backend.Close)
+	return backend, certPEM
+}
+
+func TestCheck(t *testing.T) {
+	backend, caBundle := newBackend(t, "api.test-ns.svc")
+	_, otherCABundle := newBackend(t, "api.test-ns.svc")
+	misnamedBackend, misnamedCABundle := newBackend(t, "other.test-ns.svc")
+
+	testCases := map[string]struct {
+		backend      *httptest.Server
+		mutate       func(apiService *registrationv1.APIService)
+		expectErr    string
+		expectOutput string
+	}{
+		"trusted": {
+			backend:      backend,
+			mutate:       func(apiService *registrationv1.APIService) { apiService.Spec.CABundle = caBundle },
+			expectOutput: "Discovery: /apis/foo.example.com/v1 serves 2 resources",
+		},
+		"insecure skip tls verify": {
+			backend:      backend,
+			mutate:       func(apiService *registrationv1.APIService) { apiService.Spec.InsecureSkipTLSVerify = true },
+			expectOutput: "the serving certificate is not verified",
+		},
+		"unknown authority": {
+			backend:   backend,
+			mutate:    func(apiService *registrationv1.APIService) { apiService.Spec.CABundle = otherCABundle },
+			expectErr: "which is not a CA of the caBundle",
+		},
+		"wrong hostname": {
+			backend:   misnamedBackend,
+			mutate:    func(apiService *registrationv1.APIService) { apiService.Spec.CABundle = misnamedCABundle },
+			expectErr: "not valid for api.test-ns.svc",
+		},
+		"discovery not served": {
+			backend: backend,
+			mutate: func(apiService *registrationv1.APIService) {
+				apiService.Spec.CABundle = caBundle
+				apiService.Spec.Version = "v2"
+			},
+			expectErr: "bad status 404",
+		},
+		"local": {
+			mutate:       func(apiService *registrationv1.APIService) { apiService.Spec.Service = nil },
+			expectOutput: "is served locally",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			apiService := newAPIService("v1.foo.example.com", time.Now())
+			tc.mutate(apiService)
+			args := []string{"check", apiService.Name}
+			if tc.backend != nil {
+				args = append(args, "--endpoint", tc.backend.URL)
+			}
+
+			out, err := run(t, fake.NewSimpleClientset(apiService), args...)
+			if len(tc.expectErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+					t.Fatalf("expected error containing %q, got %v: %s", tc.expectErr, err, out)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v: %s", err, out)
+			}
+			if !strings.Contains(out, tc.expectOutput) {
+				t.Errorf("expected %q in:\n%s", tc.expectOutput, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package aggregatorctl
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apiserver"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// expiryWarning is how long before the expiry of a serving certificate check
+// warns about it.
+const expiryWarning = 30 * 24 * time.Hour
+
+// CheckOptions configure how the service of an APIService is reached.
+type CheckOptions struct {
+	// Endpoint overrides the URL of the service, e.g. a port-forward to it when
+	// cluster DNS names do not resolve. The serving certificate is still
+	// verified for the cluster DNS name of the service.
+	Endpoint string
+	Timeout  time.Duration
+}
+
+func newCheckCommand(f *clientFactory, out io.Writer) *cobra.Command {
+	o := &CheckOptions{Timeout: 10 * time.Second}
+	cmd := &cobra.Command{
+		Use:   "check NAME",
+		Short: "Probe the discovery endpoint of an APIService through its service",
+		Long: "Probe the discovery endpoint of an APIService through its service the way the aggregator does, " +
+			"reporting why the serving certificate of the service is not trusted.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			client, err := f.Client()
+			if err != nil {
+				return err
+			}
+			apiService, err := client.RegistrationV1().APIServices().Get(c.Context(), args[0], metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			return o.Run(c.Context(), out, apiService)
+		},
+	}
+	cmd.Flags().StringVar(&o.Endpoint, "endpoint", o.Endpoint,
+		"The https URL to reach the service at instead of its cluster DNS name, e.g. https://127.0.0.1:8443 when port-forwarding.")
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", o.Timeout, "How long to wait for the service.")
+	return cmd
+}
+
+// Run probes the service of apiService, reporting each step to out.
+func (o *CheckOptions) Run(ctx context.Context, out io.Writer, apiService *registrationv1.APIService) error {
+	service := apiService.Spec.Service
+	if service == nil {
+		_, err := fmt.Fprintf(out, "apiservice/%s is served locally by the aggregator\n", apiService.Name)
+		return err
+	}
+
+	endpoint, err := o.endpoint(service)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := apiserver.NewTrustProvider().TLSConfig(apiService)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Service:   %s\n", serviceString(apiService))
+	fmt.Fprintf(out, "Endpoint:  %s\n", endpoint)
+
+	cert, err := handshake(ctx, endpoint.Host, tlsConfig, o.Timeout)
+	if err != nil {
+		return fmt.Errorf("TLS handshake with %s failed: %s", endpoint.Host, explainTLSError(err, tlsConfig.ServerName))
+	}
+	fmt.Fprintf(out, "TLS:       serving certificate %q issued by %q, expires %s\n",
+		cert.Subject, cert.Issuer, cert.NotAfter.UTC().Format(time.RFC3339))
+	if apiService.Spec.InsecureSkipTLSVerify {
+		fmt.Fprintf(out, "Warning:   the serving certificate is not verified, insecureSkipTLSVerify is set\n")
+	}
+	if remaining := time.Until(cert.NotAfter); remaining < expiryWarning {
+		fmt.Fprintf(out, "Warning:   the serving certificate expires in %s\n", remaining.Round(time.Minute))
+	}
+
+	discoveryURL := endpoint.JoinPath("apis", apiService.Spec.Group, apiService.Spec.Version)
+	resources, err := discover(ctx, tlsConfig, discoveryURL.String(), o.Timeout)
+	if err != nil {
+		return fmt.Errorf("discovery at %s failed: %w", discoveryURL, err)
+	}
+	_, err = fmt.Fprintf(out, "Discovery: %s serves %d resources\n", discoveryURL.Path, resources)
+	return err
+}
+
+// endpoint returns the URL the service is reached at.
+func (o *CheckOptions) endpoint(service *registrationv1.ServiceReference) (*url.URL, error) {
+	if len(o.Endpoint) == 0 {
+		return &url.URL{
+			Scheme: "https",
+			Host:   net.JoinHostPort(service.Name+"."+service.Namespace+".svc", fmt.Sprint(ptr.Deref(service.Port, registrationv1.DefaultServicePort))),
+			Path:   "/",
+		}, nil
+	}
+	endpoint, err := url.Parse(o.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --endpoint: %w", err)
+	}
+	if endpoint.Scheme != "https" || len(endpoint.Host) == 0 {
+		return nil, fmt.Errorf("invalid --endpoint %q: must be an https URL", o.Endpoint)
+	}
+	if len(endpoint.Port()) == 0 {
+		endpoint.Host = net.JoinHostPort(endpoint.Hostname(), "443")
+	}
+	if len(endpoint.Path) == 0 {
+		endpoint.Path = "/"
+	}
+	return endpoint, nil
+}
+
+// handshake dials address and returns the serving certificate once verified
+// with tlsConfig.
+func handshake(ctx context.Context, address string, tlsConfig *tls.Config, timeout time.Duration) (*x509.Certificate, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	dialer := &tls.Dialer{Config: tlsConfig}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+	return conn.(*tls.Conn).ConnectionState().PeerCertificates[0], nil
+}
+
+// explainTLSError describes why a handshake failed in terms of the APIService.
+func explainTLSError(err error, serverName string) string {
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	var recordHeader tls.RecordHeaderError
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return fmt.Sprintf("the serving certificate is signed by %q, which is not a CA of the caBundle", unknownAuthority.Cert.Issuer)
+	case errors.As(err, &hostname):
+		return fmt.Sprintf("the serving certificate is not valid for %s, the cluster DNS name of the service: %v", serverName, err)
+	case errors.As(err, &invalid) && invalid.Reason == x509.Expired:
+		return fmt.Sprintf("the serving certificate has expired or is not yet valid: %v", err)
+	case errors.As(err, &recordHeader):
+		return "the service does not serve TLS"
+	default:
+		return err.Error()
+	}
+}
+
+// discover fetches the APIResourceList at discoveryURL and returns the number
+// of resources it lists.
+func discover(ctx context.Context, tlsConfig *tls.Config, discoveryURL string, timeout time.Duration) (int, error) {
+	transport := &http.Transport{TLSClientConfig: tlsConfig}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport, Timeout: timeout}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("bad status %d", resp.StatusCode)
+	}
+	list := &metav1.APIResourceList{}
+	if err := json.NewDecoder(resp.Body).Decode(list); err != nil {
+		return 0, fmt.Errorf("invalid APIResourceList: %w", err)
+	}
+	return len(list.APIResources), nil
+}
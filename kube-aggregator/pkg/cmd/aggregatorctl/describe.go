@@ -0,0 +1,69 @@
+package aggregatorctl
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+func newDescribeCommand(f *clientFactory, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:   "describe NAME",
+		Short: "Show the details of an APIService",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			client, err := f.Client()
+			if err != nil {
+				return err
+			}
+			apiService, err := client.RegistrationV1().APIServices().Get(c.Context(), args[0], metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			return describeAPIService(out, apiService)
+		},
+	}
+}
+
+func describeAPIService(out io.Writer, apiService *registrationv1.APIService) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	spec := apiService.Spec
+	fmt.Fprintf(w, "Name:\t%s\n", apiService.Name)
+	fmt.Fprintf(w, "Group Version:\t%s/%s\n", spec.Group, spec.Version)
+	fmt.Fprintf(w, "Service:\t%s\n", serviceString(apiService))
+	fmt.Fprintf(w, "Group Priority Minimum:\t%d\n", spec.GroupPriorityMinimum)
+	fmt.Fprintf(w, "Version Priority:\t%d\n", spec.VersionPriority)
+	fmt.Fprintf(w, "Insecure Skip TLS Verify:\t%t\n", spec.InsecureSkipTLSVerify)
+	switch {
+	case len(spec.CABundle) == 0:
+		fmt.Fprintf(w, "CA Bundle:\t<none>\n")
+	default:
+		certs, err := certutil.ParseCertsPEM(spec.CABundle)
+		if err != nil {
+			fmt.Fprintf(w, "CA Bundle:\t<invalid: %v>\n", err)
+			break
+		}
+		fmt.Fprintf(w, "CA Bundle:\n")
+		for _, cert := range certs {
+			fmt.Fprintf(w, "  %s\texpires %s\n", cert.Subject, cert.NotAfter.UTC().Format("2006-01-02T15:04:05Z"))
+		}
+	}
+
+	fmt.Fprintf(w, "Conditions:\n")
+	if len(apiService.Status.Conditions) == 0 {
+		fmt.Fprintf(w, "  <none>\n")
+		return w.Flush()
+	}
+	fmt.Fprintf(w, "  Type\tStatus\tLastTransitionTime\tReason\tMessage\n")
+	fmt.Fprintf(w, "  ----\t------\t------------------\t------\t-------\n")
+	for _, condition := range apiService.Status.Conditions {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", condition.Type, condition.Status,
+			condition.LastTransitionTime.UTC().Format("2006-01-02T15:04:05Z"), condition.Reason, condition.Message)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,51 @@
+package aggregatorctl
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+func newListCommand(f *clientFactory, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the registered APIServices with their availability",
+		Args:  cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			client, err := f.Client()
+			if err != nil {
+				return err
+			}
+			list, err := client.RegistrationV1().APIServices().List(c.Context(), metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+			return printAPIServices(out, list.Items, time.Now())
+		},
+	}
+}
+
+// printAPIServices prints apiServices as a table, with their age as of now.
+func printAPIServices(out io.Writer, apiServices []registrationv1.APIService, now time.Time) error {
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	if _, err := fmt.Fprintln(w, "NAME\tSERVICE\tAVAILABLE\tAGE"); err != nil {
+		return err
+	}
+	for i := range apiServices {
+		apiService := &apiServices[i]
+		age := "<unknown>"
+		if !apiService.CreationTimestamp.IsZero() {
+			age = duration.HumanDuration(now.Sub(apiService.CreationTimestamp.Time))
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", apiService.Name, serviceString(apiService), availableString(apiService), age); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,138 @@
+package aggregatorctl
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration"
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/install"
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/validation"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/ptr"
+)
+
+// RegisterOptions holds the APIService to register, the service backing a
+// group version.
+type RegisterOptions struct {
+	Group                 string
+	Version               string
+	ServiceNamespace      string
+	ServiceName           string
+	ServicePort           int32
+	CAFile                string
+	InsecureSkipTLSVerify bool
+	GroupPriorityMinimum  int32
+	VersionPriority       int32
+
+	apiService *registrationv1.APIService
+}
+
+// NewRegisterOptions returns RegisterOptions with the default priorities and
+// port.
+func NewRegisterOptions() *RegisterOptions {
+	return &RegisterOptions{
+		ServicePort:          registrationv1.DefaultServicePort,
+		GroupPriorityMinimum: 1000,
+		VersionPriority:      15,
+	}
+}
+
+func newRegisterCommand(f *clientFactory, out io.Writer) *cobra.Command {
+	o := NewRegisterOptions()
+	cmd := &cobra.Command{
+		Use:   "register --group GROUP --version VERSION --service-namespace NAMESPACE --service-name NAME",
+		Short: "Register the service backing a group version",
+		Long: "Register the service backing a group version, creating or updating the APIService named version.group. " +
+			"The serving certificate of the service is verified with the CAs of --ca-file.",
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			if err := o.Complete(); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			client, err := f.Client()
+			if err != nil {
+				return err
+			}
+			apiServices := client.RegistrationV1().APIServices()
+
+			existing, err := apiServices.Get(c.Context(), o.apiService.Name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				if _, err := apiServices.Create(c.Context(), o.apiService, metav1.CreateOptions{}); err != nil {
+					return err
+				}
+				_, err = fmt.Fprintf(out, "apiservice/%s registered\n", o.apiService.Name)
+				return err
+			}
+			if err != nil {
+				return err
+			}
+			existing = existing.DeepCopy()
+			existing.Spec = o.apiService.Spec
+			if _, err := apiServices.Update(c.Context(), existing, metav1.UpdateOptions{}); err != nil {
+				return err
+			}
+			_, err = fmt.Fprintf(out, "apiservice/%s configured\n", o.apiService.Name)
+			return err
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&o.Group, "group", o.Group, "The API group served by the service.")
+	flags.StringVar(&o.Version, "version", o.Version, "The API version served by the service.")
+	flags.StringVar(&o.ServiceNamespace, "service-namespace", o.ServiceNamespace, "The namespace of the service.")
+	flags.StringVar(&o.ServiceName, "service-name", o.ServiceName, "The name of the service.")
+	flags.Int32Var(&o.ServicePort, "service-port", o.ServicePort, "The port of the service.")
+	flags.StringVar(&o.CAFile, "ca-file", o.CAFile, "Path to a PEM encoded CA bundle verifying the serving certificate of the service.")
+	flags.BoolVar(&o.InsecureSkipTLSVerify, "insecure-skip-tls-verify", o.InsecureSkipTLSVerify,
+		"If true, the serving certificate of the service is not verified. Strongly discouraged.")
+	flags.Int32Var(&o.GroupPriorityMinimum, "group-priority-minimum", o.GroupPriorityMinimum, "The minimum priority of the group in discovery.")
+	flags.Int32Var(&o.VersionPriority, "version-priority", o.VersionPriority, "The priority of the version within its group.")
+	return cmd
+}
+
+// Complete builds the APIService to register, reading the CA bundle.
+func (o *RegisterOptions) Complete() error {
+	o.apiService = &registrationv1.APIService{
+		ObjectMeta: metav1.ObjectMeta{Name: o.Version + "." + o.Group},
+		Spec: registrationv1.APIServiceSpec{
+			Service: &registrationv1.ServiceReference{
+				Namespace: o.ServiceNamespace,
+				Name:      o.ServiceName,
+				Port:      ptr.To(o.ServicePort),
+			},
+			Group:                 o.Group,
+			Version:               o.Version,
+			InsecureSkipTLSVerify: o.InsecureSkipTLSVerify,
+			GroupPriorityMinimum:  o.GroupPriorityMinimum,
+			VersionPriority:       o.VersionPriority,
+		},
+	}
+	if len(o.CAFile) > 0 {
+		caBundle, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read --ca-file: %w", err)
+		}
+		o.apiService.Spec.CABundle = caBundle
+	}
+	return nil
+}
+
+// Validate validates the APIService to register as the server would.
+func (o *RegisterOptions) Validate() error {
+	internal := &registration.APIService{}
+	if err := install.Scheme.Convert(o.apiService, internal, nil); err != nil {
+		return err
+	}
+	if errs := validation.ValidateAPIService(internal, sets.New[string]()); len(errs) > 0 {
+		return errs.ToAggregate()
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package aggregatorctl
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newUnregisterCommand(f *clientFactory, out io.Writer) *cobra.Command {
+	return &cobra.Command{
+		Use:   "unregister NAME...",
+		Short: "Unregister APIServices by name, which is version.group",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(c *cobra.Command, names []string) error {
+			client, err := f.Client()
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				if err := client.RegistrationV1().APIServices().Delete(c.Context(), name, metav1.DeleteOptions{}); err != nil {
+					return err
+				}
+				if _, err := fmt.Fprintf(out, "apiservice/%s unregistered\n", name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
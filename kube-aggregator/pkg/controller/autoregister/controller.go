@@ -0,0 +1,289 @@
+package autoregister
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	registrationclient "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/clientset_generated/clientset/typed/registration/v1"
+	registrationinformers "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/informers/externalversions/registration/v1"
+	registrationlisters "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/listers/registration/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+const controllerName = "apiservice-autoregister-controller"
+
+const (
+	// AutoManagedLabel marks the local APIServices created by this controller.
+	// APIServices without it are never modified or deleted.
+	AutoManagedLabel = "registration.foen.ye/automanaged"
+
+	// defaultResyncInterval is how often the groups of the delegate are read.
+	defaultResyncInterval = time.Minute
+	// discoveryTimeout bounds reading the groups of the delegate.
+	discoveryTimeout = 5 * time.Second
+
+	// groupPriorityMinimum is the GroupPriorityMinimum of every registered group.
+	groupPriorityMinimum int32 = 1000
+	// maxVersionPriority is the VersionPriority of the preferred version of a
+	// group, later versions get one less each.
+	maxVersionPriority int32 = 100
+)
+
+// Controller registers a local APIService, without a service, for every group
+// version served by the in-process delegate, and removes the ones it
+// registered once the delegate stops serving them.
+type Controller struct {
+	apiServiceClient registrationclient.APIServicesGetter
+
+	apiServiceLister  registrationlisters.APIServiceLister
+	apiServicesSynced cache.InformerSynced
+
+	delegate http.Handler
+	// resyncInterval is how long to wait before reading the groups of the
+	// delegate again.
+	resyncInterval time.Duration
+
+	lock sync.RWMutex
+	// desired holds the APIServices to register, keyed by name.
+	desired map[string]*registrationv1.APIService
+	// desiredSynced is set once the groups of the delegate have been read.
+	// Until then desired is empty and no APIService is removed.
+	desiredSynced bool
+
+	queue workqueue.TypedRateLimitingInterface[string]
+}
+
+// NewController returns a new auto-registration controller for the groups
+// delegate serves at /apis.
+func NewController(
+	apiServiceClient registrationclient.APIServicesGetter,
+	apiServiceInformer registrationinformers.APIServiceInformer,
+	delegate http.Handler) (*Controller, error) {
+	c := &Controller{
+		apiServiceClient:  apiServiceClient,
+		apiServiceLister:  apiServiceInformer.Lister(),
+		apiServicesSynced: apiServiceInformer.Informer().HasSynced,
+		delegate:          delegate,
+		resyncInterval:    defaultResyncInterval,
+		desired:           map[string]*registrationv1.APIService{},
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.DefaultTypedControllerRateLimiter[string](),
+			workqueue.TypedRateLimitingQueueConfig[string]{Name: controllerName},
+		),
+	}
+
+	if _, err := apiServiceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueueAPIService,
+		UpdateFunc: func(_, newObj interface{}) { c.enqueueAPIService(newObj) },
+		DeleteFunc: c.enqueueAPIService,
+	}); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run waits for the informer cache to sync, then reads the groups of the
+// delegate every resync interval and starts workers registering them until ctx
+// is done.
+func (c *Controller) Run(ctx context.Context, workers int) error {
+	defer utilruntime.HandleCrash()
+	defer c.queue.ShutDown()
+	logger := klog.FromContext(ctx)
+
+	logger.Info("Starting APIService autoregister controller")
+	if ok := cache.WaitForCacheSync(ctx.Done(), c.apiServicesSynced); !ok {
+		return errors.New("failed to wait for caches to sync")
+	}
+
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := c.syncDelegateGroups(ctx); err != nil {
+			utilruntime.HandleErrorWithContext(ctx, err, "Error reading the groups of the delegate")
+		}
+	}, c.resyncInterval)
+
+	logger.Info("Starting workers", "count", workers)
+	for i := 0; i < workers; i++ {
+		go wait.UntilWithContext(ctx, c.runWorker, time.Second)
+	}
+
+	<-ctx.Done()
+	logger.Info("Shutting down workers")
+	return nil
+}
+
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	key, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	if err := c.syncHandler(ctx, key); err != nil {
+		utilruntime.HandleErrorWithContext(ctx, err, "Error syncing; requeuing for later retry", "apiService", key)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+// syncDelegateGroups reads the groups served by the delegate and queues the
+// APIServices to register as well as the registered ones no longer served.
+func (c *Controller) syncDelegateGroups(ctx context.Context) error {
+	groupList, err := c.delegateGroups(ctx)
+	if err != nil {
+		return err
+	}
+
+	desired := map[string]*registrationv1.APIService{}
+	for _, group := range groupList.Groups {
+		for i, version := range group.Versions {
+			apiService := newLocalAPIService(group.Name, version.Version, max(maxVersionPriority-int32(i), 1))
+			desired[apiService.Name] = apiService
+		}
+	}
+	c.lock.Lock()
+	c.desired = desired
+	c.desiredSynced = true
+	c.lock.Unlock()
+
+	for name := range desired {
+		c.queue.Add(name)
+	}
+	managed, err := c.apiServiceLister.List(labels.SelectorFromSet(labels.Set{AutoManagedLabel: "true"}))
+	if err != nil {
+		return err
+	}
+	for _, apiService := range managed {
+		if _, ok := desired[apiService.Name]; !ok {
+			c.queue.Add(apiService.Name)
+		}
+	}
+	return nil
+}
+
+// delegateGroups returns the APIGroupList the delegate serves at /apis.
+func (c *Controller) delegateGroups(ctx context.Context) (*metav1.APIGroupList, error) {
+	ctx, cancel := context.WithTimeout(ctx, discoveryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/apis", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	w := httptest.NewRecorder()
+	c.delegate.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		return nil, fmt.Errorf("bad status %d from the groups of the delegate", w.Code)
+	}
+	groupList := &metav1.APIGroupList{}
+	if err := json.Unmarshal(w.Body.Bytes(), groupList); err != nil {
+		return nil, fmt.Errorf("failed to decode the groups of the delegate: %w", err)
+	}
+	return groupList, nil
+}
+
+// syncHandler creates, updates or deletes the named APIService to match the
+// groups of the delegate. APIServices not managed by this controller are left
+// alone, and none are deleted before the groups of the delegate have been read
+// once.
+func (c *Controller) syncHandler(ctx context.Context, name string) error {
+	logger := klog.LoggerWithValues(klog.FromContext(ctx), "apiService", name)
+
+	c.lock.RLock()
+	desired, wanted := c.desired[name]
+	desiredSynced := c.desiredSynced
+	c.lock.RUnlock()
+
+	apiService, err := c.apiServiceLister.Get(name)
+	if apierrors.IsNotFound(err) {
+		if !wanted {
+			return nil
+		}
+		logger.V(2).Info("Registering local APIService")
+		_, err = c.apiServiceClient.APIServices().Create(ctx, desired, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			return nil
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if apiService.Labels[AutoManagedLabel] != "true" {
+		return nil
+	}
+	if !wanted {
+		if !desiredSynced {
+			return nil
+		}
+		logger.V(2).Info("Removing local APIService no longer served by the delegate")
+		err = c.apiServiceClient.APIServices().Delete(ctx, name, metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &apiService.UID},
+		})
+		if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+			return nil
+		}
+		return err
+	}
+	if equality.Semantic.DeepEqual(apiService.Spec, desired.Spec) {
+		return nil
+	}
+
+	logger.V(2).Info("Updating local APIService")
+	apiServiceCopy := apiService.DeepCopy()
+	apiServiceCopy.Spec = desired.Spec
+	_, err = c.apiServiceClient.APIServices().Update(ctx, apiServiceCopy, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *Controller) enqueueAPIService(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	apiService, ok := obj.(*registrationv1.APIService)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("error decoding object, invalid type %T", obj))
+		return
+	}
+	c.queue.Add(apiService.Name)
+}
+
+// newLocalAPIService returns the managed APIService of a group version served
+// by the delegate.
+func newLocalAPIService(group, version string, versionPriority int32) *registrationv1.APIService {
+	return &registrationv1.APIService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   version + "." + group,
+			Labels: map[string]string{AutoManagedLabel: "true"},
+		},
+		Spec: registrationv1.APIServiceSpec{
+			Group:                group,
+			Version:              version,
+			GroupPriorityMinimum: groupPriorityMinimum,
+			VersionPriority:      versionPriority,
+		},
+	}
+}
@@ -0,0 +1,273 @@
+package autoregister
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"testing"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/clientset_generated/clientset/fake"
+	informers "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/informers/externalversions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2/ktesting"
+)
+
+// delegateServing returns a delegate serving the given group versions at /apis,
+// preferred versions first.
+func delegateServing(groups map[string][]string) http.Handler {
+	groupList := metav1.APIGroupList{}
+	for name, versions := range groups {
+		group := metav1.APIGroup{Name: name}
+		for _, version := range versions {
+			group.Versions = append(group.Versions, metav1.GroupVersionForDiscovery{GroupVersion: name + "/" + version, Version: version})
+		}
+		groupList.Groups = append(groupList.Groups, group)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/apis" {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(groupList)
+	})
+}
+
+func newUnmanagedAPIService(group, version string) *registrationv1.APIService {
+	return &registrationv1.APIService{
+		ObjectMeta: metav1.ObjectMeta{Name: version + "." + group},
+		Spec: registrationv1.APIServiceSpec{
+			Service:              &registrationv1.ServiceReference{Namespace: "test-ns", Name: "api"},
+			Group:                group,
+			Version:              version,
+			GroupPriorityMinimum: 2000,
+			VersionPriority:      15,
+		},
+	}
+}
+
+// run syncs the groups of delegate against the given APIServices and returns
+// the APIServices afterwards, sorted by name, along with the number of writes.
+func run(t *testing.T, delegate http.Handler, objects ...*registrationv1.APIService) ([]registrationv1.APIService, int) {
+	t.Helper()
+	_, ctx := ktesting.NewTestContext(t)
+
+	var runtimeObjects []runtime.Object
+	for _, apiService := range objects {
+		runtimeObjects = append(runtimeObjects, apiService)
+	}
+	client := fake.NewSimpleClientset(runtimeObjects...)
+	informerFactory := informers.NewSharedInformerFactory(client, 0)
+	c, err := NewController(client.RegistrationV1(), informerFactory.Registration().V1().APIServices(), delegate)
+	if err != nil {
+		t.Fatalf("error creating autoregister controller: %v", err)
+	}
+	for _, apiService := range objects {
+		_ = informerFactory.Registration().V1().APIServices().Informer().GetIndexer().Add(apiService)
+	}
+	client.ClearActions()
+
+	if err := c.syncDelegateGroups(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for c.queue.Len() > 0 {
+		key, _ := c.queue.Get()
+		if err := c.syncHandler(ctx, key); err != nil {
+			t.Fatalf("unexpected error syncing %q: %v", key, err)
+		}
+		c.queue.Done(key)
+	}
+
+	writes := 0
+	for _, action := range client.Actions() {
+		if action.GetVerb() != "list" && action.GetVerb() != "watch" && action.GetVerb() != "get" {
+			writes++
+		}
+	}
+	list, err := client.RegistrationV1().APIServices().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+	return list.Items, writes
+}
+
+func expectNames(t *testing.T, apiServices []registrationv1.APIService, expected ...string) {
+	t.Helper()
+	var names []string
+	for _, apiService := range apiServices {
+		names = append(names, apiService.Name)
+	}
+	if len(names) != len(expected) {
+		t.Fatalf("expected APIServices %v, got %v", expected, names)
+	}
+	for i := range names {
+		if names[i] != expected[i] {
+			t.Fatalf("expected APIServices %v, got %v", expected, names)
+		}
+	}
+}
+
+func TestRegistersDelegateGroups(t *testing.T) {
+	delegate := delegateServing(map[string][]string{
+		"foo.example.com": {"v2", "v1"},
+		"bar.example.com": {"v1"},
+	})
+
+	apiServices, writes := run(t, delegate)
+	expectNames(t, apiServices, "v1.bar.example.com", "v1.foo.example.com", "v2.foo.example.com")
+	if writes != 3 {
+		t.Errorf("expected 3 writes, got %d", writes)
+	}
+	priorities := map[string]int32{"v1.bar.example.com": 100, "v1.foo.example.com": 99, "v2.foo.example.com": 100}
+	for _, apiService := range apiServices {
+		if apiService.Spec.Service != nil {
+			t.Errorf("expected %s to be local, got service %+v", apiService.Name, apiService.Spec.Service)
+		}
+		if apiService.Labels[AutoManagedLabel] != "true" {
+			t.Errorf("expected %s to be labeled %s, got %v", apiService.Name, AutoManagedLabel, apiService.Labels)
+		}
+		if apiService.Spec.VersionPriority != priorities[apiService.Name] {
+			t.Errorf("expected %s to have version priority %d, got %d", apiService.Name, priorities[apiService.Name], apiService.Spec.VersionPriority)
+		}
+	}
+
+	// Registered APIServices are left as they are.
+	var existing []*registrationv1.APIService
+	for i := range apiServices {
+		existing = append(existing, &apiServices[i])
+	}
+	if _, writes := run(t, delegate, existing...); writes != 0 {
+		t.Errorf("expected no writes, got %d", writes)
+	}
+}
+
+func TestRemovesGroupsNoLongerServed(t *testing.T) {
+	delegate := delegateServing(map[string][]string{"foo.example.com": {"v1"}})
+
+	apiServices, writes := run(t, delegate,
+		newLocalAPIService("foo.example.com", "v1", 100),
+		newLocalAPIService("foo.example.com", "v1alpha1", 99),
+		newUnmanagedAPIService("baz.example.com", "v1"),
+	)
+	expectNames(t, apiServices, "v1.baz.example.com", "v1.foo.example.com")
+	if writes != 1 {
+		t.Errorf("expected 1 write, got %d", writes)
+	}
+}
+
+func TestUpdatesOnlyManagedAPIServices(t *testing.T) {
+	delegate := delegateServing(map[string][]string{
+		"foo.example.com": {"v1"},
+		"bar.example.com": {"v1"},
+	})
+	drifted := newLocalAPIService("foo.example.com", "v1", 100)
+	drifted.Spec.GroupPriorityMinimum = 5
+	unmanaged := newUnmanagedAPIService("bar.example.com", "v1")
+
+	apiServices, writes := run(t, delegate, drifted, unmanaged)
+	expectNames(t, apiServices, "v1.bar.example.com", "v1.foo.example.com")
+	if writes != 1 {
+		t.Errorf("expected 1 write, got %d", writes)
+	}
+	if got := apiServices[1].Spec.GroupPriorityMinimum; got != groupPriorityMinimum {
+		t.Errorf("expected managed APIService to be restored to group priority minimum %d, got %d", groupPriorityMinimum, got)
+	}
+	if apiServices[0].Spec.Service == nil {
+		t.Errorf("expected unmanaged APIService to keep its service")
+	}
+}
+
+func TestDelegateFailing(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	client := fake.NewSimpleClientset()
+	informerFactory := informers.NewSharedInformerFactory(client, 0)
+	delegate := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	c, err := NewController(client.RegistrationV1(), informerFactory.Registration().V1().APIServices(), delegate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.desired = map[string]*registrationv1.APIService{"v1.foo.example.com": newLocalAPIService("foo.example.com", "v1", 100)}
+
+	if err := c.syncDelegateGroups(ctx); err == nil {
+		t.Fatal("expected an error")
+	}
+	// A failing delegate must not unregister its groups.
+	if _, ok := c.desired["v1.foo.example.com"]; !ok || c.queue.Len() != 0 {
+		t.Errorf("expected the registered groups to be kept, got %v with %d queued", c.desired, c.queue.Len())
+	}
+}
+
+func TestKeepsManagedAPIServicesUntilDelegateSynced(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	managed := []*registrationv1.APIService{
+		newLocalAPIService("foo.example.com", "v1", 100),
+		newLocalAPIService("bar.example.com", "v1", 100),
+	}
+	client := fake.NewSimpleClientset(managed[0], managed[1])
+	informerFactory := informers.NewSharedInformerFactory(client, 0)
+	failing := true
+	delegate := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if failing {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		delegateServing(map[string][]string{"foo.example.com": {"v1"}}).ServeHTTP(w, req)
+	})
+	c, err := NewController(client.RegistrationV1(), informerFactory.Registration().V1().APIServices(), delegate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, apiService := range managed {
+		_ = informerFactory.Registration().V1().APIServices().Informer().GetIndexer().Add(apiService)
+	}
+	client.ClearActions()
+
+	deletes := func() int {
+		count := 0
+		for _, action := range client.Actions() {
+			if action.GetVerb() == "delete" {
+				count++
+			}
+		}
+		return count
+	}
+	// The informer queues every APIService at startup, before and after a
+	// failing first read of the groups of the delegate.
+	for _, apiService := range managed {
+		if err := c.syncHandler(ctx, apiService.Name); err != nil {
+			t.Fatalf("unexpected error syncing %q: %v", apiService.Name, err)
+		}
+	}
+	if err := c.syncDelegateGroups(ctx); err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, apiService := range managed {
+		if err := c.syncHandler(ctx, apiService.Name); err != nil {
+			t.Fatalf("unexpected error syncing %q: %v", apiService.Name, err)
+		}
+	}
+	if n := deletes(); n != 0 {
+		t.Fatalf("expected no deletes before the delegate was read, got %d", n)
+	}
+
+	failing = false
+	if err := c.syncDelegateGroups(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for c.queue.Len() > 0 {
+		key, _ := c.queue.Get()
+		if err := c.syncHandler(ctx, key); err != nil {
+			t.Fatalf("unexpected error syncing %q: %v", key, err)
+		}
+		c.queue.Done(key)
+	}
+	if n := deletes(); n != 1 {
+		t.Errorf("expected the group no longer served to be removed once the delegate was read, got %d deletes", n)
+	}
+}
@@ -0,0 +1,262 @@
+package available
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1/helper"
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apiserver"
+	registrationclient "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/clientset_generated/clientset/typed/registration/v1"
+	registrationinformers "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/informers/externalversions/registration/v1"
+	registrationlisters "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/listers/registration/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+)
+
+const controllerName = "apiservice-availability-controller"
+
+const (
+	// defaultProbeInterval is how often the discovery endpoint of every
+	// APIService is probed.
+	defaultProbeInterval = 30 * time.Second
+	// probeTimeout bounds a single discovery probe.
+	probeTimeout = 5 * time.Second
+)
+
+// Reasons of the Available condition.
+const (
+	// ReasonLocal is used for APIServices served by the aggregator itself.
+	ReasonLocal = "Local"
+	// ReasonPassed is used when the discovery endpoint of the service responded.
+	ReasonPassed = "Passed"
+	// ReasonServiceAccessError is used when the service could not be resolved
+	// or no client could be set up for it.
+	ReasonServiceAccessError = "ServiceAccessError"
+	// ReasonFailedDiscoveryCheck is used when probing the discovery endpoint of
+	// the service failed.
+	ReasonFailedDiscoveryCheck = "FailedDiscoveryCheck"
+	// ReasonServiceUnavailable is used while the circuit of the APIService is
+	// open, too many requests proxied to the service failed.
+	ReasonServiceUnavailable = "ServiceUnavailable"
+)
+
+// Controller probes the discovery endpoint of the service backing every
+// APIService and reports the result in its Available condition. APIServices
+// whose circuit is open are reported unavailable without probing, once their
+// circuit is half-open the probe decides whether it closes.
+type Controller struct {
+	apiServiceClient registrationclient.APIServicesGetter
+
+	apiServiceLister  registrationlisters.APIServiceLister
+	apiServicesSynced cache.InformerSynced
+
+	serviceResolver apiserver.ServiceResolver
+	trustProvider   *apiserver.TrustProvider
+	circuitBreakers *apiserver.CircuitBreakers
+	// probeInterval is how long to wait before probing an APIService again.
+	probeInterval time.Duration
+
+	queue workqueue.TypedRateLimitingInterface[string]
+}
+
+// NewController returns a new availability controller resolving services with
+// serviceResolver, trusting them with trustProvider and following the circuits
+// of circuitBreakers. Pass the ones of the APIAggregator, so services are
+// probed the way requests are proxied to them.
+func NewController(
+	apiServiceClient registrationclient.APIServicesGetter,
+	apiServiceInformer registrationinformers.APIServiceInformer,
+	serviceResolver apiserver.ServiceResolver,
+	trustProvider *apiserver.TrustProvider,
+	circuitBreakers *apiserver.CircuitBreakers) (*Controller, error) {
+	c := &Controller{
+		apiServiceClient:  apiServiceClient,
+		apiServiceLister:  apiServiceInformer.Lister(),
+		apiServicesSynced: apiServiceInformer.Informer().HasSynced,
+		serviceResolver:   serviceResolver,
+		trustProvider:     trustProvider,
+		circuitBreakers:   circuitBreakers,
+		probeInterval:     defaultProbeInterval,
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.DefaultTypedControllerRateLimiter[string](),
+			workqueue.TypedRateLimitingQueueConfig[string]{Name: controllerName},
+		),
+	}
+
+	if _, err := apiServiceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueueAPIService,
+		UpdateFunc: func(_, newObj interface{}) { c.enqueueAPIService(newObj) },
+	}); err != nil {
+		return nil, err
+	}
+	// Report circuits opening and closing right away.
+	circuitBreakers.AddListener(c.queue.Add)
+	return c, nil
+}
+
+// Run waits for the informer cache to sync and starts workers probing
+// APIServices until ctx is done.
+func (c *Controller) Run(ctx context.Context, workers int) error {
+	defer utilruntime.HandleCrash()
+	defer c.queue.ShutDown()
+	logger := klog.FromContext(ctx)
+
+	logger.Info("Starting APIService availability controller")
+	if ok := cache.WaitForCacheSync(ctx.Done(), c.apiServicesSynced); !ok {
+		return errors.New("failed to wait for caches to sync")
+	}
+
+	logger.Info("Starting workers", "count", workers)
+	for i := 0; i < workers; i++ {
+		go wait.UntilWithContext(ctx, c.runWorker, time.Second)
+	}
+
+	<-ctx.Done()
+	logger.Info("Shutting down workers")
+	return nil
+}
+
+func (c *Controller) runWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	key, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	if err := c.syncHandler(ctx, key); err != nil {
+		utilruntime.HandleErrorWithContext(ctx, err, "Error syncing; requeuing for later retry", "apiService", key)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	// Probe again later, the service may go away without the APIService changing.
+	c.queue.AddAfter(key, c.probeInterval)
+	return true
+}
+
+// syncHandler probes the service of the named APIService and updates its
+// Available condition, skipping the write when nothing changed.
+func (c *Controller) syncHandler(ctx context.Context, name string) error {
+	logger := klog.LoggerWithValues(klog.FromContext(ctx), "apiService", name)
+
+	apiService, err := c.apiServiceLister.Get(name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			logger.V(4).Info("APIService no longer exists")
+			return nil
+		}
+		return err
+	}
+
+	apiServiceCopy := apiService.DeepCopy()
+	helper.SetAPIServiceCondition(apiServiceCopy, c.availableCondition(ctx, apiService))
+	if equality.Semantic.DeepEqual(apiService.Status, apiServiceCopy.Status) {
+		return nil
+	}
+
+	logger.V(2).Info("Updating availability", "available", helper.GetAPIServiceConditionByType(apiServiceCopy, registrationv1.Available))
+	_, err = c.apiServiceClient.APIServices().UpdateStatus(ctx, apiServiceCopy, metav1.UpdateOptions{})
+	return err
+}
+
+// availableCondition returns the Available condition of apiService. While its
+// circuit is open the service is not probed, it is probed again once the
+// circuit is half-open and the circuit closes when the probe passes.
+func (c *Controller) availableCondition(ctx context.Context, apiService *registrationv1.APIService) registrationv1.APIServiceCondition {
+	circuit := c.circuitBreakers.Status(apiService.Name)
+	switch circuit.State {
+	case apiserver.CircuitClosed:
+		return c.checkAvailability(ctx, apiService)
+	case apiserver.CircuitHalfOpen:
+		condition := c.checkAvailability(ctx, apiService)
+		circuit = c.circuitBreakers.RecordProbe(apiService.Name, condition.Status == registrationv1.ConditionTrue, condition.Message)
+		if circuit.State == apiserver.CircuitClosed {
+			return condition
+		}
+	}
+	// Probe as soon as the circuit is half-open.
+	c.queue.AddAfter(apiService.Name, time.Until(circuit.HalfOpenAt))
+	return helper.NewAPIServiceCondition(registrationv1.Available, registrationv1.ConditionFalse,
+		ReasonServiceUnavailable, circuit.Message)
+}
+
+// checkAvailability returns the Available condition of apiService.
+func (c *Controller) checkAvailability(ctx context.Context, apiService *registrationv1.APIService) registrationv1.APIServiceCondition {
+	service := apiService.Spec.Service
+	if service == nil {
+		return helper.NewAPIServiceCondition(registrationv1.Available, registrationv1.ConditionTrue,
+			ReasonLocal, "Local APIServices are always available")
+	}
+
+	location, err := c.serviceResolver.ResolveEndpoint(service.Namespace, service.Name, ptr.Deref(service.Port, registrationv1.DefaultServicePort))
+	if err != nil {
+		return helper.NewAPIServiceCondition(registrationv1.Available, registrationv1.ConditionFalse,
+			ReasonServiceAccessError, fmt.Sprintf("service %s/%s could not be resolved: %v", service.Namespace, service.Name, err))
+	}
+	tlsConfig, err := c.trustProvider.TLSConfig(apiService)
+	if err != nil {
+		return helper.NewAPIServiceCondition(registrationv1.Available, registrationv1.ConditionFalse,
+			ReasonServiceAccessError, err.Error())
+	}
+
+	discoveryURL := location.JoinPath("apis", apiService.Spec.Group, apiService.Spec.Version)
+	if err := probe(ctx, tlsConfig, discoveryURL.String()); err != nil {
+		return helper.NewAPIServiceCondition(registrationv1.Available, registrationv1.ConditionFalse,
+			ReasonFailedDiscoveryCheck, fmt.Sprintf("failing or missing response from %s: %v", discoveryURL, err))
+	}
+	return helper.NewAPIServiceCondition(registrationv1.Available, registrationv1.ConditionTrue,
+		ReasonPassed, "all checks passed")
+}
+
+func (c *Controller) enqueueAPIService(obj interface{}) {
+	apiService, ok := obj.(*registrationv1.APIService)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("error decoding object, invalid type %T", obj))
+		return
+	}
+	c.queue.Add(apiService.Name)
+}
+
+// probe fetches the discovery document at url. The probe is anonymous, so a
+// server rejecting it as unauthenticated or unauthorized is still serving.
+func probe(ctx context.Context, tlsConfig *tls.Config, url string) error {
+	transport := utilnet.SetTransportDefaults(&http.Transport{TLSClientConfig: tlsConfig})
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport, Timeout: probeTimeout}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices,
+		resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		return nil
+	default:
+		return fmt.Errorf("bad status %d", resp.StatusCode)
+	}
+}
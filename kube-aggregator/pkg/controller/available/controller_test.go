@@ -0,0 +1,350 @@
+package available
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	registrationv1 "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1"
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apis/registration/v1/helper"
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/apiserver"
+	"github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/clientset_generated/clientset/fake"
+	informers "github.com/foenye/cloud-native-tour/kube-aggregator/pkg/client/informers/externalversions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2/ktesting"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+// backend stands in for the service test-ns/api, answering discovery with code
+// and recording the last path it served.
+type backend struct {
+	*httptest.Server
+	caBundle []byte
+
+	code int
+	path string
+}
+
+func newBackend(t *testing.T, code int) *backend {
+	t.Helper()
+	certPEM, keyPEM, err := certutil.GenerateSelfSignedCertKey("api.test-ns.svc", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &backend{caBundle: certPEM, code: code}
+	b.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b.path = req.URL.Path
+		w.WriteHeader(b.code)
+	}))
+	b.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	b.StartTLS()
+	t.Cleanup(b.Close)
+	return b
+}
+
+// staticResolver resolves every service to url, or fails with err.
+type staticResolver struct {
+	url *url.URL
+	err error
+}
+
+func (r *staticResolver) ResolveEndpoint(_, _ string, _ int32) (*url.URL, error) {
+	return r.url, r.err
+}
+
+func newAPIService(b *backend) *registrationv1.APIService {
+	return &registrationv1.APIService{
+		ObjectMeta: metav1.ObjectMeta{Name: "v1.foo.example.com"},
+		Spec: registrationv1.APIServiceSpec{
+			Service:              &registrationv1.ServiceReference{Namespace: "test-ns", Name: "api"},
+			Group:                "foo.example.com",
+			Version:              "v1",
+			CABundle:             b.caBundle,
+			GroupPriorityMinimum: 1000,
+			VersionPriority:      15,
+		},
+	}
+}
+
+type fixture struct {
+	t *testing.T
+
+	client   *fake.Clientset
+	resolver *staticResolver
+	clock    *testingclock.FakePassiveClock
+	trust    *apiserver.TrustProvider
+	breakers *apiserver.CircuitBreakers
+
+	controller *Controller
+}
+
+func newFixture(t *testing.T, b *backend) *fixture {
+	backendURL, err := url.Parse(b.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeClock := testingclock.NewFakePassiveClock(time.Now())
+	return &fixture{t: t, resolver: &staticResolver{url: backendURL}, clock: fakeClock,
+		trust: apiserver.NewTrustProvider(), breakers: apiserver.NewCircuitBreakers(fakeClock)}
+}
+
+// run syncs apiService once and returns it as last written through the fake
+// clientset, along with the number of status updates.
+func (f *fixture) run(apiService *registrationv1.APIService) (*registrationv1.APIService, int) {
+	f.t.Helper()
+	_, ctx := ktesting.NewTestContext(f.t)
+	f.client = fake.NewSimpleClientset(apiService)
+	informerFactory := informers.NewSharedInformerFactory(f.client, 0)
+	c, err := NewController(f.client.RegistrationV1(), informerFactory.Registration().V1().APIServices(), f.resolver, f.trust, f.breakers)
+	if err != nil {
+		f.t.Fatalf("error creating availability controller: %v", err)
+	}
+	f.controller = c
+	_ = informerFactory.Registration().V1().APIServices().Informer().GetIndexer().Add(apiService)
+	f.client.ClearActions()
+
+	if err := c.syncHandler(ctx, apiService.Name); err != nil {
+		f.t.Fatalf("unexpected error: %v", err)
+	}
+
+	updates := 0
+	for _, action := range f.client.Actions() {
+		if action.Matches("update", "apiservices") && action.GetSubresource() == "status" {
+			updates++
+		}
+	}
+	got, err := f.client.RegistrationV1().APIServices().Get(context.TODO(), apiService.Name, metav1.GetOptions{})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return got, updates
+}
+
+func expectAvailable(t *testing.T, apiService *registrationv1.APIService, status registrationv1.ConditionStatus, reason string) {
+	t.Helper()
+	condition := helper.GetAPIServiceConditionByType(apiService, registrationv1.Available)
+	if condition == nil {
+		t.Fatalf("expected an Available condition, got %#v", apiService.Status.Conditions)
+	}
+	if condition.Status != status || condition.Reason != reason {
+		t.Errorf("expected Available %s/%s, got %s/%s: %s", status, reason, condition.Status, condition.Reason, condition.Message)
+	}
+	if condition.LastTransitionTime.IsZero() || condition.Message == "" {
+		t.Errorf("expected Available to have a transition time and message, got %#v", condition)
+	}
+}
+
+func TestAvailability(t *testing.T) {
+	testCases := map[string]struct {
+		code         int
+		mutate       func(apiService *registrationv1.APIService, f *fixture)
+		expectStatus registrationv1.ConditionStatus
+		expectReason string
+	}{
+		"local": {
+			code:         http.StatusInternalServerError,
+			mutate:       func(apiService *registrationv1.APIService, _ *fixture) { apiService.Spec.Service = nil },
+			expectStatus: registrationv1.ConditionTrue,
+			expectReason: ReasonLocal,
+		},
+		"discovery ok": {
+			code:         http.StatusOK,
+			expectStatus: registrationv1.ConditionTrue,
+			expectReason: ReasonPassed,
+		},
+		"discovery forbidden": {
+			code:         http.StatusForbidden,
+			expectStatus: registrationv1.ConditionTrue,
+			expectReason: ReasonPassed,
+		},
+		"discovery failing": {
+			code:         http.StatusServiceUnavailable,
+			expectStatus: registrationv1.ConditionFalse,
+			expectReason: ReasonFailedDiscoveryCheck,
+		},
+		"untrusted serving certificate": {
+			code:         http.StatusOK,
+			mutate:       func(apiService *registrationv1.APIService, _ *fixture) { apiService.Spec.CABundle = nil },
+			expectStatus: registrationv1.ConditionFalse,
+			expectReason: ReasonFailedDiscoveryCheck,
+		},
+		"insecure skip tls verify": {
+			code: http.StatusOK,
+			mutate: func(apiService *registrationv1.APIService, _ *fixture) {
+				apiService.Spec.CABundle = nil
+				apiService.Spec.InsecureSkipTLSVerify = true
+			},
+			expectStatus: registrationv1.ConditionTrue,
+			expectReason: ReasonPassed,
+		},
+		"invalid ca bundle": {
+			code: http.StatusOK,
+			mutate: func(apiService *registrationv1.APIService, _ *fixture) {
+				apiService.Spec.CABundle = []byte("not a certificate")
+			},
+			expectStatus: registrationv1.ConditionFalse,
+			expectReason: ReasonServiceAccessError,
+		},
+		"unresolvable service": {
+			code:         http.StatusOK,
+			mutate:       func(_ *registrationv1.APIService, f *fixture) { f.resolver.err = errors.New("no endpoints") },
+			expectStatus: registrationv1.ConditionFalse,
+			expectReason: ReasonServiceAccessError,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			b := newBackend(t, tc.code)
+			f := newFixture(t, b)
+			apiService := newAPIService(b)
+			if tc.mutate != nil {
+				tc.mutate(apiService, f)
+			}
+
+			got, updates := f.run(apiService)
+			expectAvailable(t, got, tc.expectStatus, tc.expectReason)
+			if updates != 1 {
+				t.Errorf("expected one status update, got %d", updates)
+			}
+			if tc.expectReason == ReasonPassed && b.path != "/apis/foo.example.com/v1" {
+				t.Errorf("expected discovery of /apis/foo.example.com/v1 to be probed, got %q", b.path)
+			}
+		})
+	}
+}
+
+func TestProbeTrustsLikeTheProxy(t *testing.T) {
+	b := newBackend(t, http.StatusOK)
+	f := newFixture(t, b)
+	// The proxy has reached the service with the current CABundle when it is
+	// replaced by one of a new CA the service has not switched to yet.
+	if _, err := f.trust.TLSConfig(newAPIService(b)); err != nil {
+		t.Fatal(err)
+	}
+	newCA, _, err := certutil.GenerateSelfSignedCertKey("api.test-ns.svc", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiService := newAPIService(b)
+	apiService.Spec.CABundle = newCA
+
+	got, _ := f.run(apiService)
+	expectAvailable(t, got, registrationv1.ConditionTrue, ReasonPassed)
+}
+
+func TestNoUpdateWhenUnchanged(t *testing.T) {
+	b := newBackend(t, http.StatusOK)
+	f := newFixture(t, b)
+	apiService, _ := f.run(newAPIService(b))
+
+	got, updates := f.run(apiService)
+	if updates != 0 {
+		t.Errorf("expected no status update, got %d", updates)
+	}
+	expectAvailable(t, got, registrationv1.ConditionTrue, ReasonPassed)
+}
+
+func TestTransitionTime(t *testing.T) {
+	b := newBackend(t, http.StatusOK)
+	f := newFixture(t, b)
+	earlier := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	apiService := newAPIService(b)
+	apiService.Status.Conditions = []registrationv1.APIServiceCondition{{
+		Type:               registrationv1.Available,
+		Status:             registrationv1.ConditionTrue,
+		LastTransitionTime: earlier,
+		Reason:             ReasonPassed,
+		Message:            "an older check passed",
+	}}
+
+	// Only the message changes, the condition keeps its transition time.
+	got, updates := f.run(apiService)
+	if updates != 1 {
+		t.Errorf("expected one status update, got %d", updates)
+	}
+	if condition := helper.GetAPIServiceConditionByType(got, registrationv1.Available); !condition.LastTransitionTime.Equal(&earlier) {
+		t.Errorf("expected transition time %v to be kept, got %v", earlier, condition.LastTransitionTime)
+	}
+
+	// The backend starts failing, the condition transitions.
+	b.code = http.StatusInternalServerError
+	got, _ = f.run(got)
+	expectAvailable(t, got, registrationv1.ConditionFalse, ReasonFailedDiscoveryCheck)
+	if condition := helper.GetAPIServiceConditionByType(got, registrationv1.Available); !condition.LastTransitionTime.After(earlier.Time) {
+		t.Errorf("expected transition time after %v, got %v", earlier, condition.LastTransitionTime)
+	}
+}
+
+// tripCircuit opens the circuit of the named APIService as failing requests
+// proxied to its service would.
+func (f *fixture) tripCircuit(name string) {
+	for i := 0; i < 20 && f.breakers.Status(name).State == apiserver.CircuitClosed; i++ {
+		f.breakers.Record(name, true, time.Millisecond)
+	}
+	if state := f.breakers.Status(name).State; state != apiserver.CircuitOpen {
+		f.t.Fatalf("expected the circuit to open, got %s", state)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	b := newBackend(t, http.StatusOK)
+	f := newFixture(t, b)
+	apiService, _ := f.run(newAPIService(b))
+	expectAvailable(t, apiService, registrationv1.ConditionTrue, ReasonPassed)
+
+	// Requests to the backend fail, the open circuit is reported without probing.
+	f.tripCircuit(apiService.Name)
+	b.path = ""
+	apiService, _ = f.run(apiService)
+	expectAvailable(t, apiService, registrationv1.ConditionFalse, ReasonServiceUnavailable)
+	if b.path != "" {
+		t.Errorf("expected no probe while the circuit is open, got one of %q", b.path)
+	}
+
+	// The backend keeps failing once half-open, the probe opens the circuit again.
+	b.code = http.StatusServiceUnavailable
+	f.clock.SetTime(f.clock.Now().Add(time.Minute))
+	apiService, _ = f.run(apiService)
+	expectAvailable(t, apiService, registrationv1.ConditionFalse, ReasonServiceUnavailable)
+	if state := f.breakers.Status(apiService.Name).State; state != apiserver.CircuitOpen {
+		t.Errorf("expected the failed probe to open the circuit, got %s", state)
+	}
+
+	// The backend recovers, the probe closes the circuit.
+	b.code = http.StatusOK
+	f.clock.SetTime(f.clock.Now().Add(time.Minute))
+	apiService, _ = f.run(apiService)
+	expectAvailable(t, apiService, registrationv1.ConditionTrue, ReasonPassed)
+	if state := f.breakers.Status(apiService.Name).State; state != apiserver.CircuitClosed {
+		t.Errorf("expected the passed probe to close the circuit, got %s", state)
+	}
+}
+
+func TestCircuitOpeningEnqueues(t *testing.T) {
+	b := newBackend(t, http.StatusOK)
+	f := newFixture(t, b)
+	apiService, _ := f.run(newAPIService(b))
+	for f.controller.queue.Len() > 0 {
+		key, _ := f.controller.queue.Get()
+		f.controller.queue.Done(key)
+	}
+
+	f.tripCircuit(apiService.Name)
+	if f.controller.queue.Len() != 1 {
+		t.Fatalf("expected the APIService to be enqueued when its circuit opened, got %d items", f.controller.queue.Len())
+	}
+	if key, _ := f.controller.queue.Get(); key != apiService.Name {
+		t.Errorf("expected %q to be enqueued, got %q", apiService.Name, key)
+	}
+}
@@ -19,28 +19,153 @@ package v1
 import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// ApplicationNameLabel is the label the pods of an Application carry, set to
+// the name of the Application.
+const ApplicationNameLabel = "apps.foen.ye/application"
+
+// TemplateHashLabel is the label the pods of an Application carry, set to the
+// hash of the template they were created from.
+const TemplateHashLabel = "apps.foen.ye/template-hash"
+
+// ApplicationFinalizer is the finalizer the Application controller adds to
+// Applications, to tear down their pods following their TerminationPolicy
+// before they are deleted.
+const ApplicationFinalizer = "apps.foen.ye/finalizer"
+
+// TerminationPolicy is what happens to the pods of an Application when it is
+// deleted.
+// +kubebuilder:validation:Enum=Delete;Orphan
+type TerminationPolicy string
+
+const (
+	// DeleteTerminationPolicy deletes the pods, and waits for them to
+	// terminate gracefully before the Application is deleted.
+	DeleteTerminationPolicy TerminationPolicy = "Delete"
+	// OrphanTerminationPolicy leaves the pods running, no longer controlled
+	// by the Application, e.g. to migrate them off the operator.
+	OrphanTerminationPolicy TerminationPolicy = "Orphan"
+)
+
+// ApplicationStrategyType is how the pods of an Application are replaced when
+// its template changes.
+// +kubebuilder:validation:Enum=RollingUpdate;Recreate
+type ApplicationStrategyType string
+
+const (
+	// RollingUpdateApplicationStrategyType replaces the pods a few at a time,
+	// as bounded by RollingUpdateApplication.
+	RollingUpdateApplicationStrategyType ApplicationStrategyType = "RollingUpdate"
+	// RecreateApplicationStrategyType deletes all the pods before creating
+	// the new ones.
+	RecreateApplicationStrategyType ApplicationStrategyType = "Recreate"
+)
+
+// ApplicationStrategy describes how the pods of an Application are replaced.
+type ApplicationStrategy struct {
+	// Type is RollingUpdate or Recreate. Defaults to RollingUpdate.
+	// +optional
+	Type ApplicationStrategyType `json:"type,omitempty"`
+	// RollingUpdate bounds the rolling update, only set when Type is RollingUpdate.
+	// +optional
+	RollingUpdate *RollingUpdateApplication `json:"rollingUpdate,omitempty"`
+}
+
+// RollingUpdateApplication bounds how many pods a rolling update replaces at a time.
+type RollingUpdateApplication struct {
+	// MaxUnavailable is how many of the replicas may be unavailable during the
+	// update, a number or a percentage of the replicas rounded down. Defaults
+	// to 25%.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	// MaxSurge is how many pods may be created beyond the replicas during the
+	// update, a number or a percentage of the replicas rounded up. Defaults to
+	// 25%.
+	// +optional
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+}
+
 // ApplicationSpec defines the desired state of Application.
 type ApplicationSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	Replicas int32                  `json:"replicas,omitempty"`
+	// Replicas is the number of pods of the Application. Defaults to 1.
+	// +optional
+	Replicas *int32                 `json:"replicas,omitempty"`
 	Template corev1.PodTemplateSpec `json:"template,omitempty"`
+
+	// MinReadySeconds is how long a pod must have been ready before it counts
+	// as available. Defaults to 0, pods are available as soon as they are ready.
+	// +kubebuilder:validation:Minimum=0
+	MinReadySeconds int32 `json:"minReadySeconds,omitempty"`
+
+	// Strategy is how the pods are replaced when the template changes.
+	// +optional
+	Strategy ApplicationStrategy `json:"strategy,omitempty"`
+
+	// TerminationPolicy is what happens to the pods when the Application is
+	// deleted, Delete or Orphan. Defaults to Delete.
+	// +optional
+	TerminationPolicy TerminationPolicy `json:"terminationPolicy,omitempty"`
 }
 
+// Condition types of an Application.
+const (
+	// ApplicationAvailable means all the replicas of the Application are ready.
+	ApplicationAvailable = "Available"
+	// ApplicationProgressing means pods of the Application are being created,
+	// deleted or are not ready yet.
+	ApplicationProgressing = "Progressing"
+	// ApplicationDegraded means the pods of the Application could not be
+	// reconciled, or some of them failed.
+	ApplicationDegraded = "Degraded"
+)
+
 // ApplicationStatus defines the observed state of Application.
 type ApplicationStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// Replicas is the number of pods of the Application.
+	Replicas int32 `json:"replicas,omitempty"`
+	// ReadyReplicas is the number of pods of the Application with a Ready condition.
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// AvailableReplicas is the number of pods of the Application which have been
+	// ready for at least MinReadySeconds.
+	AvailableReplicas int32 `json:"availableReplicas,omitempty"`
+	// UpdatedReplicas is the number of pods of the Application created from its
+	// current template.
+	UpdatedReplicas int32 `json:"updatedReplicas,omitempty"`
+	// TemplateHash is the hash of the current template of the Application.
+	TemplateHash string `json:"templateHash,omitempty"`
+	// ObservedGeneration is the generation of the Application last reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Selector is the label selector of the pods of the Application, as used by
+	// the scale subresource.
+	Selector string `json:"selector,omitempty"`
+
+	// Conditions are the Available, Progressing and Degraded conditions of the
+	// Application.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+// +kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=`.spec.replicas`
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.readyReplicas`
+// +kubebuilder:printcolumn:name="Up-to-date",type=integer,JSONPath=`.status.updatedReplicas`
+// +kubebuilder:printcolumn:name="Available",type=integer,JSONPath=`.status.availableReplicas`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Application is the Schema for the applications API.
 type Application struct {
@@ -39,6 +39,7 @@ import (
 
 	appsv1 "github.com/foenye/cloud-native-tour/operators/application-operator/api/v1"
 	"github.com/foenye/cloud-native-tour/operators/application-operator/internal/controller"
+	webhookappsv1 "github.com/foenye/cloud-native-tour/operators/application-operator/internal/webhook/v1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -203,12 +204,20 @@ func main() {
 	}
 
 	if err := (&controller.ApplicationReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("application-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Application")
 		os.Exit(1)
 	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err := webhookappsv1.SetupApplicationWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Application")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
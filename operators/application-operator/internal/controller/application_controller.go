@@ -19,14 +19,17 @@ package controller
 import (
 	"context"
 	"fmt"
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"time"
-
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	crappsv1 "github.com/foenye/cloud-native-tour/operators/application-operator/api/v1"
@@ -35,19 +38,28 @@ import (
 // ApplicationReconciler reconciles a Application object
 type ApplicationReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=apps.foen.ye,resources=applications,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps.foen.ye,resources=applications/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=apps.foen.ye,resources=applications/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;delete;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the Application object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
+//
+// The Application owns the pods `<name>-<template hash>-0..N`, labeled with its
+// name and the hash of the template they were created from, and controlled by
+// it through their OwnerReferences. Missing pods are created and surplus ones
+// deleted, highest ordinal first, so reconciling is idempotent. When the
+// template changes, the pods of the previous templates are replaced following
+// the strategy of the Application. The replica counts and conditions computed
+// from the pods are written to the status of the Application. Once the
+// Application is deleted, its finalizer holds it until its pods are torn down
+// following its TerminationPolicy.
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.21.0/pkg/reconcile
@@ -65,31 +77,139 @@ func (r *ApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{RequeueAfter: 1 * time.Minute}, err
 	}
 
-	// Create pods
-	for i := 0; i < int(crapp.Spec.Replicas); i++ {
-		pod := &corev1.Pod{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      fmt.Sprintf("%s-%d", crapp.Name, i),
-				Namespace: crapp.Namespace,
-				Labels:    crapp.Labels,
-			},
-			Spec: crapp.Spec.Template.Spec,
+	if !crapp.DeletionTimestamp.IsZero() {
+		return r.finalize(ctx, crapp)
+	}
+	if controllerutil.AddFinalizer(crapp, crappsv1.ApplicationFinalizer) {
+		if err := r.Update(ctx, crapp); err != nil {
+			logger.Error(err, "Failed to add the finalizer to the Application")
+			return ctrl.Result{}, err
+		}
+	}
+
+	pods, err := r.ownedPods(ctx, crapp)
+	if err != nil {
+		logger.Error(err, "Failed to list the Pods of the Application")
+		return ctrl.Result{RequeueAfter: 1 * time.Minute}, err
+	}
+
+	rollout, err := newRollout(crapp, pods, time.Now())
+	if err != nil {
+		logger.Error(err, "Failed to plan the rollout of the Application")
+		return ctrl.Result{}, err
+	}
+	reconcileErr := r.reconcilePods(ctx, rollout)
+	status, availableIn := computeStatus(rollout, reconcileErr)
+	if !equality.Semantic.DeepEqual(crapp.Status, status) {
+		crapp.Status = status
+		if err := r.Status().Update(ctx, crapp); err != nil {
+			logger.Error(err, "Failed to update the status of the Application")
+			return ctrl.Result{}, err
+		}
+	}
+	if reconcileErr != nil {
+		return ctrl.Result{RequeueAfter: 1 * time.Minute}, reconcileErr
+	}
+	// No event tells when a ready pod has been ready for MinReadySeconds.
+	return ctrl.Result{RequeueAfter: availableIn}, nil
+}
+
+// reconcilePods creates and deletes the pods of the rollout, updating it to match.
+func (r *ApplicationReconciler) reconcilePods(ctx context.Context, rollout *rollout) error {
+	logger := logf.FromContext(ctx)
+
+	for _, ordinal := range rollout.podsToCreate() {
+		pod, err := r.newPod(rollout.crapp, rollout.hash, ordinal)
+		if err != nil {
+			logger.Error(err, "Failed to build Pod", "ordinal", ordinal)
+			return err
 		}
 		if err := r.Create(ctx, pod); err != nil {
-			logger.Error(err, "Failed to create Pod")
-			return ctrl.Result{RequeueAfter: 1 * time.Minute}, err
+			// The cache may not have caught up with a pod created by an
+			// earlier reconcile yet, any other pod takes the name of this one.
+			if errors.IsAlreadyExists(err) {
+				if err := r.checkControlled(ctx, rollout.crapp, pod); err != nil {
+					logger.Error(err, "Failed to create Pod", "pod", pod.Name)
+					return err
+				}
+				continue
+			}
+			logger.Error(err, "Failed to create Pod", "pod", pod.Name)
+			return err
+		}
+		rollout.created(pod)
+		logger.Info("The Pod has been created", "pod", pod.Name)
+	}
+
+	for _, pod := range rollout.podsToDelete() {
+		if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "Failed to delete Pod", "pod", pod.Name)
+			return err
+		}
+		rollout.deleted(pod)
+		logger.Info("The Pod has been deleted", "pod", pod.Name)
+	}
+	return nil
+}
+
+// checkControlled returns an error unless the existing pod named like pod is
+// controlled by crapp, or not in the cache yet.
+func (r *ApplicationReconciler) checkControlled(ctx context.Context, crapp *crappsv1.Application, pod *corev1.Pod) error {
+	existing := &corev1.Pod{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(pod), existing); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(existing, crapp) {
+		return fmt.Errorf("pod %s already exists and is not controlled by the Application", pod.Name)
+	}
+	return nil
+}
+
+// ownedPods returns the pods controlled by crapp, including the ones being deleted.
+func (r *ApplicationReconciler) ownedPods(ctx context.Context, crapp *crappsv1.Application) ([]*corev1.Pod, error) {
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList, client.InNamespace(crapp.Namespace),
+		client.MatchingLabels{crappsv1.ApplicationNameLabel: crapp.Name}); err != nil {
+		return nil, err
+	}
+	var pods []*corev1.Pod
+	for i := range podList.Items {
+		if pod := &podList.Items[i]; metav1.IsControlledBy(pod, crapp) {
+			pods = append(pods, pod)
 		}
-		logger.Info(fmt.Sprintf("The Pod (%s) has created", pod.Name))
 	}
-	logger.Info("All Pods has created")
+	return pods, nil
+}
 
-	return ctrl.Result{}, nil
+// newPod returns the pod of crapp with the given ordinal, built from its
+// template hashing to hash.
+func (r *ApplicationReconciler) newPod(crapp *crappsv1.Application, hash string, ordinal int) (*corev1.Pod, error) {
+	template := crapp.Spec.Template.DeepCopy()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        podName(crapp, hash, ordinal),
+			Namespace:   crapp.Namespace,
+			Labels:      template.Labels,
+			Annotations: template.Annotations,
+		},
+		Spec: template.Spec,
+	}
+	if pod.Labels == nil {
+		pod.Labels = map[string]string{}
+	}
+	pod.Labels[crappsv1.ApplicationNameLabel] = crapp.Name
+	pod.Labels[crappsv1.TemplateHashLabel] = hash
+	if err := controllerutil.SetControllerReference(crapp, pod, r.Scheme); err != nil {
+		return nil, err
+	}
+	return pod, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ApplicationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&crappsv1.Application{}).
+		Owns(&corev1.Pod{}).
 		Named("application").
 		Complete(r)
 }
@@ -18,11 +18,19 @@ package controller
 
 import (
 	"context"
+	"sort"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -41,8 +49,91 @@ var _ = Describe("Application Controller", func() {
 			Namespace: "default", // TODO(user):Modify as needed
 		}
 		application := &appsv1.Application{}
+		var recorder *record.FakeRecorder
+
+		// reconcileApplication runs a reconcile of the Application.
+		reconcileApplication := func() {
+			controllerReconciler := &ApplicationReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: recorder,
+			}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		// podNames returns the sorted names of the pods labeled with the Application.
+		podNames := func() []string {
+			pods := &corev1.PodList{}
+			Expect(k8sClient.List(ctx, pods, client.InNamespace(typeNamespacedName.Namespace),
+				client.MatchingLabels{appsv1.ApplicationNameLabel: resourceName})).To(Succeed())
+			var names []string
+			for _, pod := range pods.Items {
+				if pod.DeletionTimestamp == nil {
+					names = append(names, pod.Name)
+				}
+			}
+			sort.Strings(names)
+			return names
+		}
+
+		// updatedPodNames returns the names of the pods of the Application with the
+		// given ordinals, created from its current template.
+		updatedPodNames := func(ordinals ...int) []string {
+			resource := &appsv1.Application{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			hash, err := templateHash(&resource.Spec.Template)
+			Expect(err).NotTo(HaveOccurred())
+			var names []string
+			for _, ordinal := range ordinals {
+				names = append(names, podName(resource, hash, ordinal))
+			}
+			sort.Strings(names)
+			return names
+		}
+
+		// setPodsReady marks the pods of the Application ready, as the kubelet would.
+		setPodsReady := func() {
+			pods := &corev1.PodList{}
+			Expect(k8sClient.List(ctx, pods, client.InNamespace(typeNamespacedName.Namespace),
+				client.MatchingLabels{appsv1.ApplicationNameLabel: resourceName})).To(Succeed())
+			for i := range pods.Items {
+				pod := &pods.Items[i]
+				pod.Status.Phase = corev1.PodRunning
+				pod.Status.Conditions = []corev1.PodCondition{{
+					Type:               corev1.PodReady,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.Now(),
+				}}
+				Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+			}
+		}
+
+		getStatus := func() appsv1.ApplicationStatus {
+			resource := &appsv1.Application{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			return resource.Status
+		}
+
+		scale := func(replicas int32) {
+			resource := &appsv1.Application{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.Replicas = &replicas
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+		}
+
+		updateSpec := func(mutate func(spec *appsv1.ApplicationSpec)) {
+			resource := &appsv1.Application{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			mutate(&resource.Spec)
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+		}
 
 		BeforeEach(func() {
+			recorder = record.NewFakeRecorder(100)
+
 			By("creating the custom resource for the Kind Application")
 			err := k8sClient.Get(ctx, typeNamespacedName, application)
 			if err != nil && errors.IsNotFound(err) {
@@ -51,7 +142,15 @@ var _ = Describe("Application Controller", func() {
 						Name:      resourceName,
 						Namespace: "default",
 					},
-					// TODO(user): Specify other spec details if needed.
+					Spec: appsv1.ApplicationSpec{
+						Replicas: ptr.To[int32](3),
+						Template: corev1.PodTemplateSpec{
+							ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "nginx"}},
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{{Name: "nginx", Image: "nginx:1.29.0"}},
+							},
+						},
+					},
 				}
 				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
 			}
@@ -61,24 +160,287 @@ var _ = Describe("Application Controller", func() {
 			// TODO(user): Cleanup logic after each test, like removing the resource instance.
 			resource := &appsv1.Application{}
 			err := k8sClient.Get(ctx, typeNamespacedName, resource)
-			Expect(err).NotTo(HaveOccurred())
+			if !errors.IsNotFound(err) {
+				Expect(err).NotTo(HaveOccurred())
 
-			By("Cleanup the specific resource instance Application")
-			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+				By("Cleanup the specific resource instance Application")
+				Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+				// The finalizer holds the Application until its pods are torn down.
+				Eventually(func() error {
+					reconcileApplication()
+					return k8sClient.Get(ctx, typeNamespacedName, &appsv1.Application{})
+				}).Should(Satisfy(errors.IsNotFound))
+			}
+
+			// envtest runs no garbage collector, the pods are deleted by hand.
+			Expect(k8sClient.DeleteAllOf(ctx, &corev1.Pod{}, client.InNamespace(typeNamespacedName.Namespace),
+				client.MatchingLabels{appsv1.ApplicationNameLabel: resourceName})).To(Succeed())
 		})
+
 		It("should successfully reconcile the resource", func() {
 			By("Reconciling the created resource")
-			controllerReconciler := &ApplicationReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+			reconcileApplication()
+			Expect(podNames()).To(Equal(updatedPodNames(0, 1, 2)))
+
+			By("Checking the pods are built from the template and controlled by the Application")
+			resource := &appsv1.Application{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			pod := &corev1.Pod{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: updatedPodNames(0)[0], Namespace: "default"}, pod)).To(Succeed())
+			Expect(pod.Labels).To(HaveKeyWithValue("app", "nginx"))
+			Expect(pod.Labels).To(HaveKeyWithValue(appsv1.ApplicationNameLabel, resourceName))
+			Expect(pod.Labels).To(HaveKeyWithValue(appsv1.TemplateHashLabel, resource.Status.TemplateHash))
+			Expect(pod.Spec.Containers[0].Image).To(Equal("nginx:1.29.0"))
+			Expect(metav1.IsControlledBy(pod, resource)).To(BeTrue())
+		})
+
+		It("should be idempotent", func() {
+			reconcileApplication()
+			reconcileApplication()
+			Expect(podNames()).To(Equal(updatedPodNames(0, 1, 2)))
+		})
+
+		It("should recreate missing pods", func() {
+			reconcileApplication()
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: updatedPodNames(1)[0], Namespace: "default"}}
+			Expect(k8sClient.Delete(ctx, pod)).To(Succeed())
+
+			reconcileApplication()
+			Expect(podNames()).To(Equal(updatedPodNames(0, 1, 2)))
+		})
+
+		It("should delete surplus pods when scaling down", func() {
+			reconcileApplication()
+			scale(1)
+			reconcileApplication()
+			Expect(podNames()).To(Equal(updatedPodNames(0)))
+
+			scale(2)
+			reconcileApplication()
+			Expect(podNames()).To(Equal(updatedPodNames(0, 1)))
+		})
+
+		It("should report the replicas and conditions in the status", func() {
+			reconcileApplication()
+			resource := &appsv1.Application{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			status := resource.Status
+			Expect(status.Replicas).To(Equal(int32(3)))
+			Expect(status.ReadyReplicas).To(BeZero())
+			Expect(status.AvailableReplicas).To(BeZero())
+			Expect(status.ObservedGeneration).To(Equal(resource.Generation))
+			Expect(status.Selector).To(Equal(appsv1.ApplicationNameLabel + "=" + resourceName))
+			Expect(meta.IsStatusConditionFalse(status.Conditions, appsv1.ApplicationAvailable)).To(BeTrue())
+			Expect(meta.FindStatusCondition(status.Conditions, appsv1.ApplicationProgressing).Reason).To(Equal(ReasonWaitingForAvailablePods))
+			Expect(meta.IsStatusConditionFalse(status.Conditions, appsv1.ApplicationDegraded)).To(BeTrue())
+
+			By("Marking the pods ready")
+			setPodsReady()
+			reconcileApplication()
+			status = getStatus()
+			Expect(status.ReadyReplicas).To(Equal(int32(3)))
+			Expect(status.AvailableReplicas).To(Equal(int32(3)))
+			Expect(meta.IsStatusConditionTrue(status.Conditions, appsv1.ApplicationAvailable)).To(BeTrue())
+			Expect(meta.IsStatusConditionFalse(status.Conditions, appsv1.ApplicationProgressing)).To(BeTrue())
+		})
+
+		It("should report failed pods as degraded", func() {
+			reconcileApplication()
+			pod := &corev1.Pod{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: updatedPodNames(2)[0], Namespace: "default"}, pod)).To(Succeed())
+			pod.Status.Phase = corev1.PodFailed
+			Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+
+			reconcileApplication()
+			degraded := meta.FindStatusCondition(getStatus().Conditions, appsv1.ApplicationDegraded)
+			Expect(degraded).NotTo(BeNil())
+			Expect(degraded.Status).To(Equal(metav1.ConditionTrue))
+			Expect(degraded.Reason).To(Equal(ReasonPodFailed))
+			Expect(degraded.Message).To(ContainSubstring(updatedPodNames(2)[0]))
+		})
+
+		It("should be scaled through the scale subresource", func() {
+			reconcileApplication()
+			resource := &appsv1.Application{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+			scale := &autoscalingv1.Scale{}
+			Expect(k8sClient.SubResource("scale").Get(ctx, resource, scale)).To(Succeed())
+			Expect(scale.Spec.Replicas).To(Equal(int32(3)))
+			Expect(scale.Status.Replicas).To(Equal(int32(3)))
+			Expect(scale.Status.Selector).To(Equal(appsv1.ApplicationNameLabel + "=" + resourceName))
+
+			scale.Spec.Replicas = 1
+			Expect(k8sClient.SubResource("scale").Update(ctx, resource, client.WithSubResourceBody(scale))).To(Succeed())
+			reconcileApplication()
+			Expect(podNames()).To(Equal(updatedPodNames(0)))
+			Expect(getStatus().Replicas).To(Equal(int32(1)))
+		})
+
+		It("should leave pods it does not control alone", func() {
+			foreign := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-resource-5",
+					Namespace: "default",
+					Labels:    map[string]string{appsv1.ApplicationNameLabel: resourceName},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "nginx:1.29.0"}}},
 			}
+			Expect(k8sClient.Create(ctx, foreign)).To(Succeed())
 
-			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
-				NamespacedName: typeNamespacedName,
+			reconcileApplication()
+			Expect(podNames()).To(Equal(append(updatedPodNames(0, 1, 2), "test-resource-5")))
+		})
+
+		It("should roll the pods to a new template without going below the replicas", func() {
+			updateSpec(func(spec *appsv1.ApplicationSpec) {
+				maxSurge, maxUnavailable := intstr.FromInt32(1), intstr.FromInt32(0)
+				spec.Strategy = appsv1.ApplicationStrategy{
+					Type:          appsv1.RollingUpdateApplicationStrategyType,
+					RollingUpdate: &appsv1.RollingUpdateApplication{MaxSurge: &maxSurge, MaxUnavailable: &maxUnavailable},
+				}
 			})
-			Expect(err).NotTo(HaveOccurred())
-			// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
-			// Example: If you expect a certain status condition after reconciliation, verify it here.
+			reconcileApplication()
+			setPodsReady()
+			reconcileApplication()
+			oldPodNames := updatedPodNames(0, 1, 2)
+
+			By("Changing the template")
+			updateSpec(func(spec *appsv1.ApplicationSpec) {
+				spec.Template.Spec.Containers[0].Image = "nginx:1.29.1"
+			})
+			reconcileApplication()
+			Expect(podNames()).To(ConsistOf(append(oldPodNames, updatedPodNames(0)...)))
+			Expect(getStatus().UpdatedReplicas).To(Equal(int32(1)))
+			Expect(meta.FindStatusCondition(getStatus().Conditions, appsv1.ApplicationProgressing).Reason).To(Equal(ReasonUpdating))
+
+			By("Deleting an old pod only once the new one is available")
+			reconcileApplication()
+			Expect(podNames()).To(ConsistOf(append(oldPodNames, updatedPodNames(0)...)))
+			setPodsReady()
+			reconcileApplication()
+			Expect(podNames()).To(ConsistOf(oldPodNames[0], oldPodNames[1], updatedPodNames(0)[0]))
+			reconcileApplication()
+			Expect(podNames()).To(ConsistOf(oldPodNames[0], oldPodNames[1], updatedPodNames(0)[0], updatedPodNames(1)[0]))
+
+			By("Rolling the remaining pods")
+			for i := 0; i < 10 && getStatus().UpdatedReplicas < 3; i++ {
+				setPodsReady()
+				reconcileApplication()
+				Expect(len(podNames())).To(BeNumerically(">=", 3))
+				Expect(len(podNames())).To(BeNumerically("<=", 4))
+			}
+			setPodsReady()
+			reconcileApplication()
+			Expect(podNames()).To(Equal(updatedPodNames(0, 1, 2)))
+			status := getStatus()
+			Expect(status.UpdatedReplicas).To(Equal(int32(3)))
+			Expect(status.AvailableReplicas).To(Equal(int32(3)))
+			Expect(meta.FindStatusCondition(status.Conditions, appsv1.ApplicationProgressing).Reason).To(Equal(ReasonComplete))
+		})
+
+		It("should delete all old pods before creating new ones when recreating", func() {
+			updateSpec(func(spec *appsv1.ApplicationSpec) {
+				spec.Strategy = appsv1.ApplicationStrategy{Type: appsv1.RecreateApplicationStrategyType}
+			})
+			reconcileApplication()
+			setPodsReady()
+			reconcileApplication()
+
+			By("Changing the template")
+			updateSpec(func(spec *appsv1.ApplicationSpec) {
+				spec.Template.Spec.Containers[0].Image = "nginx:1.29.1"
+			})
+			reconcileApplication()
+			Expect(podNames()).To(BeEmpty())
+			Expect(meta.IsStatusConditionFalse(getStatus().Conditions, appsv1.ApplicationAvailable)).To(BeTrue())
+
+			reconcileApplication()
+			Expect(podNames()).To(Equal(updatedPodNames(0, 1, 2)))
+		})
+
+		It("should delete its pods before being deleted", func() {
+			reconcileApplication()
+			resource := &appsv1.Application{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Finalizers).To(ContainElement(appsv1.ApplicationFinalizer))
+
+			By("Deleting the Application")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			By("Scaling down one pod at a time, highest ordinal first")
+			for _, remaining := range [][]int{{0, 1}, {0}, {}} {
+				reconcileApplication()
+				Expect(podNames()).To(Equal(updatedPodNames(remaining...)))
+				Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonDeletingPods)))
+			}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.DeletionTimestamp).NotTo(BeNil())
+
+			By("Removing the finalizer once the pods are gone")
+			reconcileApplication()
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, resource))).To(BeTrue())
+			Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonFinalized)))
+		})
+
+		It("should orphan its pods when its termination policy is Orphan", func() {
+			updateSpec(func(spec *appsv1.ApplicationSpec) {
+				spec.TerminationPolicy = appsv1.OrphanTerminationPolicy
+			})
+			reconcileApplication()
+			orphans := updatedPodNames(0, 1, 2)
+
+			By("Deleting the Application")
+			resource := &appsv1.Application{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			reconcileApplication()
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, resource))).To(BeTrue())
+			Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonOrphanedPods)))
+			Expect(recorder.Events).To(Receive(ContainSubstring(EventReasonFinalized)))
+
+			By("Checking the pods keep running without an owner or the labels selecting them")
+			Expect(podNames()).To(BeEmpty())
+			for _, name := range orphans {
+				pod := &corev1.Pod{}
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, pod)).To(Succeed())
+				Expect(pod.OwnerReferences).To(BeEmpty())
+				Expect(pod.Labels).NotTo(HaveKey(appsv1.ApplicationNameLabel))
+				Expect(pod.Labels).NotTo(HaveKey(appsv1.TemplateHashLabel))
+				Expect(pod.Labels).To(HaveKeyWithValue("app", "nginx"))
+				Expect(k8sClient.Delete(ctx, pod)).To(Succeed())
+			}
+		})
+
+		It("should report a pod it does not control taking the name of one of its pods as degraded", func() {
+			name := updatedPodNames(0)[0]
+			squatter := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "nginx:1.29.0"}}},
+			}
+			Expect(k8sClient.Create(ctx, squatter)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, squatter))).To(Succeed())
+			})
+
+			controllerReconciler := &ApplicationReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: recorder,
+			}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).To(MatchError(ContainSubstring(name)))
+			degraded := meta.FindStatusCondition(getStatus().Conditions, appsv1.ApplicationDegraded)
+			Expect(degraded).NotTo(BeNil())
+			Expect(degraded.Status).To(Equal(metav1.ConditionTrue))
+			Expect(degraded.Reason).To(Equal(ReasonReconcileError))
+			Expect(degraded.Message).To(ContainSubstring("not controlled by the Application"))
+
+			By("Recovering once the pod is gone")
+			Expect(k8sClient.Delete(ctx, squatter)).To(Succeed())
+			reconcileApplication()
+			Expect(podNames()).To(Equal(updatedPodNames(0, 1, 2)))
+			Expect(meta.IsStatusConditionFalse(getStatus().Conditions, appsv1.ApplicationDegraded)).To(BeTrue())
 		})
 	})
 })
@@ -0,0 +1,152 @@
+/*
+Copyright 2025 Foen.Ye.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	crappsv1 "github.com/foenye/cloud-native-tour/operators/application-operator/api/v1"
+)
+
+// Reasons of the events of an Application being deleted.
+const (
+	EventReasonDeletingPods   = "DeletingPods"
+	EventReasonOrphanedPods   = "OrphanedPods"
+	EventReasonFinalized      = "Finalized"
+	EventReasonFailedFinalize = "FailedFinalize"
+)
+
+// finalize tears down the pods of crapp, which is being deleted, following its
+// TerminationPolicy, and only then removes its finalizer so that it is gone.
+func (r *ApplicationReconciler) finalize(ctx context.Context, crapp *crappsv1.Application) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+	if !controllerutil.ContainsFinalizer(crapp, crappsv1.ApplicationFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	pods, err := r.ownedPods(ctx, crapp)
+	if err != nil {
+		logger.Error(err, "Failed to list the Pods of the Application")
+		return ctrl.Result{RequeueAfter: 1 * time.Minute}, err
+	}
+
+	var done bool
+	switch terminationPolicy(crapp) {
+	case crappsv1.OrphanTerminationPolicy:
+		done, err = r.orphanPods(ctx, crapp, pods)
+	default:
+		done, err = r.deletePods(ctx, crapp, pods)
+	}
+	if err != nil {
+		r.Recorder.Event(crapp, corev1.EventTypeWarning, EventReasonFailedFinalize, err.Error())
+		return ctrl.Result{RequeueAfter: 1 * time.Minute}, err
+	}
+	// The deletion of each pod enqueues the Application again.
+	if !done {
+		return ctrl.Result{}, nil
+	}
+
+	controllerutil.RemoveFinalizer(crapp, crappsv1.ApplicationFinalizer)
+	if err := r.Update(ctx, crapp); err != nil {
+		logger.Error(err, "Failed to remove the finalizer from the Application")
+		return ctrl.Result{}, err
+	}
+	r.Recorder.Eventf(crapp, corev1.EventTypeNormal, EventReasonFinalized,
+		"Finalized following the %s termination policy", terminationPolicy(crapp))
+	logger.Info("The Application has been finalized")
+	return ctrl.Result{}, nil
+}
+
+// deletePods scales crapp down one pod at a time, in the order its rollout
+// deletes pods, waiting for each pod to terminate gracefully within its grace
+// period before deleting the next one, and returns whether they are all gone.
+func (r *ApplicationReconciler) deletePods(ctx context.Context, crapp *crappsv1.Application, pods []*corev1.Pod) (bool, error) {
+	logger := logf.FromContext(ctx)
+	if len(pods) == 0 {
+		return true, nil
+	}
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			logger.Info("Waiting for the Pod to terminate", "pod", pod.Name)
+			return false, nil
+		}
+	}
+
+	order, err := teardownOrder(crapp, pods, time.Now())
+	if err != nil {
+		logger.Error(err, "Failed to plan the teardown of the Application")
+		return false, err
+	}
+	pod := order[0]
+	if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+		logger.Error(err, "Failed to delete Pod", "pod", pod.Name)
+		return false, fmt.Errorf("failed to delete pod %s: %w", pod.Name, err)
+	}
+	logger.Info("The Pod has been deleted", "pod", pod.Name)
+	r.Recorder.Eventf(crapp, corev1.EventTypeNormal, EventReasonDeletingPods,
+		"Deleting pod %s, %d pods remaining", pod.Name, len(pods)-1)
+	return false, nil
+}
+
+// orphanPods removes crapp from the OwnerReferences of its pods and drops the
+// labels selecting them, so that they keep running once it is deleted without
+// being taken for the pods of an Application of the same name, and returns
+// whether they all are orphans.
+func (r *ApplicationReconciler) orphanPods(ctx context.Context, crapp *crappsv1.Application, pods []*corev1.Pod) (bool, error) {
+	logger := logf.FromContext(ctx)
+
+	for _, pod := range pods {
+		patch := client.MergeFromWithOptions(pod.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		var ownerReferences []metav1.OwnerReference
+		for _, ownerReference := range pod.OwnerReferences {
+			if ownerReference.UID != crapp.UID {
+				ownerReferences = append(ownerReferences, ownerReference)
+			}
+		}
+		pod.OwnerReferences = ownerReferences
+		delete(pod.Labels, crappsv1.ApplicationNameLabel)
+		delete(pod.Labels, crappsv1.TemplateHashLabel)
+		if err := r.Patch(ctx, pod, patch); client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "Failed to orphan Pod", "pod", pod.Name)
+			return false, fmt.Errorf("failed to orphan pod %s: %w", pod.Name, err)
+		}
+		logger.Info("The Pod has been orphaned", "pod", pod.Name)
+	}
+	if len(pods) > 0 {
+		r.Recorder.Eventf(crapp, corev1.EventTypeNormal, EventReasonOrphanedPods,
+			"Orphaned %d pods, they keep running once the Application is deleted", len(pods))
+	}
+	return true, nil
+}
+
+// terminationPolicy returns the TerminationPolicy of crapp, Delete unless it
+// was set.
+func terminationPolicy(crapp *crappsv1.Application) crappsv1.TerminationPolicy {
+	if len(crapp.Spec.TerminationPolicy) == 0 {
+		return crappsv1.DeleteTerminationPolicy
+	}
+	return crapp.Spec.TerminationPolicy
+}
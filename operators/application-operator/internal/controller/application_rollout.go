@@ -0,0 +1,315 @@
+/*
+Copyright 2025 Foen.Ye.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/utils/ptr"
+
+	crappsv1 "github.com/foenye/cloud-native-tour/operators/application-operator/api/v1"
+)
+
+// defaultRollingUpdateBound is both the maxSurge and maxUnavailable of a
+// rolling update that does not set them.
+var defaultRollingUpdateBound = intstr.FromString("25%")
+
+// rollout holds the pods of an Application, split by whether they were created
+// from its current template, and decides which pods to create and delete to
+// scale the Application and replace the pods of its previous templates.
+type rollout struct {
+	crapp *crappsv1.Application
+	// hash is the hash of the current template.
+	hash     string
+	now      time.Time
+	minReady time.Duration
+
+	recreate       bool
+	maxSurge       int
+	maxUnavailable int
+
+	// current are the live pods created from the current template, keyed by name.
+	current map[string]*corev1.Pod
+	// old are the live pods created from previous templates, keyed by name.
+	old map[string]*corev1.Pod
+	// oldTerminating is how many pods of previous templates are being deleted.
+	oldTerminating int
+}
+
+// newRollout returns the rollout of crapp with the given pods at now.
+func newRollout(crapp *crappsv1.Application, pods []*corev1.Pod, now time.Time) (*rollout, error) {
+	hash, err := templateHash(&crapp.Spec.Template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash the template: %w", err)
+	}
+	r := &rollout{
+		crapp:    crapp,
+		hash:     hash,
+		now:      now,
+		minReady: time.Duration(crapp.Spec.MinReadySeconds) * time.Second,
+		current:  map[string]*corev1.Pod{},
+		old:      map[string]*corev1.Pod{},
+	}
+
+	strategy := crapp.Spec.Strategy
+	switch strategy.Type {
+	case crappsv1.RecreateApplicationStrategyType:
+		r.recreate = true
+	case crappsv1.RollingUpdateApplicationStrategyType, "":
+		maxSurge, maxUnavailable := &defaultRollingUpdateBound, &defaultRollingUpdateBound
+		if rollingUpdate := strategy.RollingUpdate; rollingUpdate != nil {
+			if rollingUpdate.MaxSurge != nil {
+				maxSurge = rollingUpdate.MaxSurge
+			}
+			if rollingUpdate.MaxUnavailable != nil {
+				maxUnavailable = rollingUpdate.MaxUnavailable
+			}
+		}
+		replicas := int(ptr.Deref(crapp.Spec.Replicas, 1))
+		if r.maxSurge, err = intstr.GetScaledValueFromIntOrPercent(maxSurge, replicas, true); err != nil {
+			return nil, fmt.Errorf("invalid maxSurge: %w", err)
+		}
+		if r.maxUnavailable, err = intstr.GetScaledValueFromIntOrPercent(maxUnavailable, replicas, false); err != nil {
+			return nil, fmt.Errorf("invalid maxUnavailable: %w", err)
+		}
+		// The update could never make progress otherwise.
+		if r.maxSurge == 0 && r.maxUnavailable == 0 {
+			r.maxUnavailable = 1
+		}
+	default:
+		return nil, fmt.Errorf("unknown strategy type %q", strategy.Type)
+	}
+
+	for _, pod := range pods {
+		isCurrent := pod.Labels[crappsv1.TemplateHashLabel] == hash
+		switch {
+		case pod.DeletionTimestamp != nil:
+			if !isCurrent {
+				r.oldTerminating++
+			}
+		case isCurrent:
+			r.current[pod.Name] = pod
+		default:
+			r.old[pod.Name] = pod
+		}
+	}
+	return r, nil
+}
+
+// replicas returns the desired number of pods, 1 unless the Application was
+// defaulted by its webhook.
+func (r *rollout) replicas() int {
+	return int(ptr.Deref(r.crapp.Spec.Replicas, 1))
+}
+
+// minAvailable returns how many pods must stay available while updating.
+func (r *rollout) minAvailable() int {
+	if r.recreate {
+		return r.replicas()
+	}
+	return r.replicas() - r.maxUnavailable
+}
+
+// updating returns whether pods of previous templates remain.
+func (r *rollout) updating() bool {
+	return len(r.old) > 0 || r.oldTerminating > 0
+}
+
+// podsToCreate returns the ordinals of the pods of the current template to
+// create, lowest first. A rolling update creates no more than maxSurge pods
+// beyond the replicas, Recreate waits for the pods of previous templates to be
+// gone.
+func (r *rollout) podsToCreate() []int {
+	kept, _ := r.splitCurrent()
+	target := r.replicas()
+	if r.recreate && r.updating() {
+		return nil
+	}
+	if !r.recreate && len(r.old) > 0 {
+		total := len(r.current) + len(r.old)
+		target = min(target, len(kept)+max(0, r.replicas()+r.maxSurge-total))
+	}
+
+	var ordinals []int
+	for ordinal := 0; ordinal < r.replicas() && len(kept)+len(ordinals) < target; ordinal++ {
+		if _, exists := kept[ordinal]; !exists {
+			ordinals = append(ordinals, ordinal)
+		}
+	}
+	return ordinals
+}
+
+// podsToDelete returns the pods to delete: the surplus pods of the current
+// template, highest ordinal first, then the pods of previous templates. A
+// rolling update deletes these as long as minAvailable pods remain available,
+// unavailable ones first, Recreate deletes them all at once.
+func (r *rollout) podsToDelete() []*corev1.Pod {
+	kept, surplus := r.splitCurrent()
+	sort.Slice(surplus, func(i, j int) bool {
+		return r.ordinal(surplus[i]) > r.ordinal(surplus[j])
+	})
+	pods := surplus
+
+	old := make([]*corev1.Pod, 0, len(r.old))
+	for _, pod := range r.old {
+		old = append(old, pod)
+	}
+	sort.Slice(old, func(i, j int) bool {
+		if availableI, availableJ := r.available(old[i]), r.available(old[j]); availableI != availableJ {
+			return !availableI
+		}
+		return r.ordinal(old[i]) > r.ordinal(old[j])
+	})
+	if r.recreate {
+		return append(pods, old...)
+	}
+
+	keptUnavailable, available := 0, 0
+	for _, pod := range kept {
+		if r.available(pod) {
+			available++
+		} else {
+			keptUnavailable++
+		}
+	}
+	for _, pod := range old {
+		if r.available(pod) {
+			available++
+		}
+	}
+	// Unavailable pods of previous templates go first, as long as they do not
+	// take the place of pods which could become available.
+	maxScaledDown := len(kept) + len(old) - r.minAvailable() - keptUnavailable
+	for _, pod := range old {
+		if maxScaledDown <= 0 || r.available(pod) {
+			break
+		}
+		pods = append(pods, pod)
+		maxScaledDown--
+	}
+	// Available ones go as long as minAvailable pods remain available.
+	for _, pod := range old {
+		if available <= r.minAvailable() {
+			break
+		}
+		if r.available(pod) {
+			pods = append(pods, pod)
+			available--
+		}
+	}
+	return pods
+}
+
+// teardownOrder returns the live pods of crapp in the order they are deleted
+// when it is deleted, the order its rollout scaled down to zero replicas
+// deletes them: the pods of the current template highest ordinal first, then
+// the pods of previous templates, unavailable ones first.
+func teardownOrder(crapp *crappsv1.Application, pods []*corev1.Pod, now time.Time) ([]*corev1.Pod, error) {
+	scaledDown := crapp.DeepCopy()
+	scaledDown.Spec.Replicas = ptr.To[int32](0)
+	scaledDown.Spec.Strategy = crappsv1.ApplicationStrategy{Type: crappsv1.RecreateApplicationStrategyType}
+	r, err := newRollout(scaledDown, pods, now)
+	if err != nil {
+		return nil, err
+	}
+	// Every live pod is surplus or of a previous template, so all are returned.
+	return r.podsToDelete(), nil
+}
+
+// created records that pod of the current template was created.
+func (r *rollout) created(pod *corev1.Pod) {
+	r.current[pod.Name] = pod
+}
+
+// deleted records that pod was deleted.
+func (r *rollout) deleted(pod *corev1.Pod) {
+	if _, exists := r.old[pod.Name]; exists {
+		delete(r.old, pod.Name)
+		r.oldTerminating++
+	}
+	delete(r.current, pod.Name)
+}
+
+// splitCurrent splits the pods of the current template into the ones to keep,
+// keyed by ordinal, and the surplus ones beyond the replicas or not named by
+// the Application.
+func (r *rollout) splitCurrent() (map[int]*corev1.Pod, []*corev1.Pod) {
+	kept := map[int]*corev1.Pod{}
+	var surplus []*corev1.Pod
+	for _, pod := range r.current {
+		if ordinal := r.ordinal(pod); ordinal >= 0 && ordinal < r.replicas() {
+			kept[ordinal] = pod
+		} else {
+			surplus = append(surplus, pod)
+		}
+	}
+	return kept, surplus
+}
+
+// ordinal returns the ordinal of pod, or -1 when its name is not one the
+// Application gives its pods.
+func (r *rollout) ordinal(pod *corev1.Pod) int {
+	return podOrdinal(r.crapp, pod.Labels[crappsv1.TemplateHashLabel], pod.Name)
+}
+
+// available returns whether pod has been ready for MinReadySeconds.
+func (r *rollout) available(pod *corev1.Pod) bool {
+	readySince, ready := podReadySince(pod)
+	return ready && !readySince.Add(r.minReady).After(r.now)
+}
+
+// templateHash returns the hash of template, which names and labels the pods
+// created from it.
+func templateHash(template *corev1.PodTemplateSpec) (string, error) {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return "", err
+	}
+	hasher := fnv.New32a()
+	_, _ = hasher.Write(data)
+	return rand.SafeEncodeString(strconv.FormatUint(uint64(hasher.Sum32()), 10)), nil
+}
+
+// podName returns the name of the pod of crapp with the given ordinal, created
+// from the template hashing to hash.
+func podName(crapp *crappsv1.Application, hash string, ordinal int) string {
+	return fmt.Sprintf("%s-%s-%d", crapp.Name, hash, ordinal)
+}
+
+// podOrdinal returns the ordinal of the pod of crapp named name, created from
+// the template hashing to hash, or -1 when the name is not one crapp gives
+// such pods.
+func podOrdinal(crapp *crappsv1.Application, hash, name string) int {
+	suffix, found := strings.CutPrefix(name, crapp.Name+"-"+hash+"-")
+	if !found || len(hash) == 0 {
+		return -1
+	}
+	ordinal, err := strconv.Atoi(suffix)
+	if err != nil || ordinal < 0 || podName(crapp, hash, ordinal) != name {
+		return -1
+	}
+	return ordinal
+}
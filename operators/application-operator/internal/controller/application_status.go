@@ -0,0 +1,139 @@
+/*
+Copyright 2025 Foen.Ye.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	crappsv1 "github.com/foenye/cloud-native-tour/operators/application-operator/api/v1"
+)
+
+// Reasons of the conditions of an Application.
+const (
+	ReasonMinimumReplicasAvailable   = "MinimumReplicasAvailable"
+	ReasonMinimumReplicasUnavailable = "MinimumReplicasUnavailable"
+	ReasonScaling                    = "Scaling"
+	ReasonUpdating                   = "Updating"
+	ReasonWaitingForAvailablePods    = "WaitingForAvailablePods"
+	ReasonComplete                   = "Complete"
+	ReasonReconcileError             = "ReconcileError"
+	ReasonPodFailed                  = "PodFailed"
+	ReasonAsExpected                 = "AsExpected"
+)
+
+// computeStatus returns the status of the Application of rollout given its
+// live pods and the error reconciling them, if any, along with how long until a
+// ready pod becomes available, zero when none is waiting to.
+func computeStatus(rollout *rollout, reconcileErr error) (crappsv1.ApplicationStatus, time.Duration) {
+	crapp := rollout.crapp
+	status := *crapp.Status.DeepCopy()
+	status.ObservedGeneration = crapp.Generation
+	status.Selector = labels.SelectorFromSet(labels.Set{crappsv1.ApplicationNameLabel: crapp.Name}).String()
+	status.TemplateHash = rollout.hash
+	status.Replicas = int32(len(rollout.current) + len(rollout.old))
+	status.UpdatedReplicas = int32(len(rollout.current))
+	status.ReadyReplicas, status.AvailableReplicas = 0, 0
+
+	var availableIn time.Duration
+	var failed []string
+	for _, pods := range []map[string]*corev1.Pod{rollout.current, rollout.old} {
+		for _, pod := range pods {
+			if pod.Status.Phase == corev1.PodFailed {
+				failed = append(failed, pod.Name)
+			}
+			readySince, ready := podReadySince(pod)
+			if !ready {
+				continue
+			}
+			status.ReadyReplicas++
+			if remaining := readySince.Add(rollout.minReady).Sub(rollout.now); remaining > 0 {
+				if availableIn == 0 || remaining < availableIn {
+					availableIn = remaining
+				}
+				continue
+			}
+			status.AvailableReplicas++
+		}
+	}
+
+	desired := int32(rollout.replicas())
+	setCondition := func(conditionType string, conditionStatus metav1.ConditionStatus, reason, message string) {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               conditionType,
+			Status:             conditionStatus,
+			ObservedGeneration: crapp.Generation,
+			Reason:             reason,
+			Message:            message,
+		})
+	}
+
+	// A rolling update keeps the Application available with maxUnavailable
+	// pods missing.
+	if status.AvailableReplicas >= int32(rollout.minAvailable()) {
+		setCondition(crappsv1.ApplicationAvailable, metav1.ConditionTrue, ReasonMinimumReplicasAvailable,
+			fmt.Sprintf("%d of %d replicas are available", status.AvailableReplicas, desired))
+	} else {
+		setCondition(crappsv1.ApplicationAvailable, metav1.ConditionFalse, ReasonMinimumReplicasUnavailable,
+			fmt.Sprintf("%d of %d replicas are available", status.AvailableReplicas, desired))
+	}
+
+	switch {
+	case rollout.updating():
+		setCondition(crappsv1.ApplicationProgressing, metav1.ConditionTrue, ReasonUpdating,
+			fmt.Sprintf("%d of %d replicas are updated, %d old replicas are left",
+				status.UpdatedReplicas, desired, len(rollout.old)+rollout.oldTerminating))
+	case status.Replicas != desired:
+		setCondition(crappsv1.ApplicationProgressing, metav1.ConditionTrue, ReasonScaling,
+			fmt.Sprintf("%d of %d replicas exist", status.Replicas, desired))
+	case status.AvailableReplicas < desired:
+		setCondition(crappsv1.ApplicationProgressing, metav1.ConditionTrue, ReasonWaitingForAvailablePods,
+			fmt.Sprintf("waiting for %d of %d replicas to be available", desired-status.AvailableReplicas, desired))
+	default:
+		setCondition(crappsv1.ApplicationProgressing, metav1.ConditionFalse, ReasonComplete,
+			fmt.Sprintf("all %d replicas are available", desired))
+	}
+
+	switch {
+	case reconcileErr != nil:
+		setCondition(crappsv1.ApplicationDegraded, metav1.ConditionTrue, ReasonReconcileError, reconcileErr.Error())
+	case len(failed) > 0:
+		sort.Strings(failed)
+		setCondition(crappsv1.ApplicationDegraded, metav1.ConditionTrue, ReasonPodFailed,
+			fmt.Sprintf("pods %s failed", strings.Join(failed, ", ")))
+	default:
+		setCondition(crappsv1.ApplicationDegraded, metav1.ConditionFalse, ReasonAsExpected, "all pods are reconciled")
+	}
+	return status, availableIn
+}
+
+// podReadySince returns since when pod is ready, and whether it is.
+func podReadySince(pod *corev1.Pod) (time.Time, bool) {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.LastTransitionTime.Time, condition.Status == corev1.ConditionTrue
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2025 Foen.Ye.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	appsv1 "github.com/foenye/cloud-native-tour/operators/application-operator/api/v1"
+)
+
+var _ = Describe("Application status", func() {
+	now := time.Now()
+	application := &appsv1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", Generation: 2},
+		Spec:       appsv1.ApplicationSpec{Replicas: ptr.To[int32](2), MinReadySeconds: 30},
+	}
+	var hash string
+	BeforeEach(func() {
+		var err error
+		hash, err = templateHash(&application.Spec.Template)
+		Expect(err).NotTo(HaveOccurred())
+	})
+	readyPod := func(name string, since time.Time) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{appsv1.TemplateHashLabel: hash}},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{
+					Type: corev1.PodReady, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(since),
+				}},
+			},
+		}
+	}
+
+	// computeStatusAt computes the status of crapp with pods at now.
+	computeStatusAt := func(crapp *appsv1.Application, pods []*corev1.Pod, reconcileErr error, now time.Time) (appsv1.ApplicationStatus, time.Duration) {
+		rollout, err := newRollout(crapp, pods, now)
+		Expect(err).NotTo(HaveOccurred())
+		return computeStatus(rollout, reconcileErr)
+	}
+
+	It("should count pods ready for MinReadySeconds as available", func() {
+		pods := []*corev1.Pod{
+			readyPod("app-"+hash+"-0", now.Add(-time.Minute)),
+			readyPod("app-"+hash+"-1", now.Add(-10*time.Second)),
+		}
+		status, availableIn := computeStatusAt(application, pods, nil, now)
+		Expect(status.Replicas).To(Equal(int32(2)))
+		Expect(status.ReadyReplicas).To(Equal(int32(2)))
+		Expect(status.AvailableReplicas).To(Equal(int32(1)))
+		Expect(status.UpdatedReplicas).To(Equal(int32(2)))
+		Expect(status.TemplateHash).To(Equal(hash))
+		Expect(status.ObservedGeneration).To(Equal(int64(2)))
+		Expect(availableIn).To(Equal(20 * time.Second))
+		Expect(meta.FindStatusCondition(status.Conditions, appsv1.ApplicationProgressing).Reason).To(Equal(ReasonWaitingForAvailablePods))
+
+		status, availableIn = computeStatusAt(application, pods, nil, now.Add(20*time.Second))
+		Expect(status.AvailableReplicas).To(Equal(int32(2)))
+		Expect(availableIn).To(BeZero())
+		Expect(meta.IsStatusConditionTrue(status.Conditions, appsv1.ApplicationAvailable)).To(BeTrue())
+		Expect(meta.FindStatusCondition(status.Conditions, appsv1.ApplicationProgressing).Reason).To(Equal(ReasonComplete))
+	})
+
+	It("should report errors reconciling the pods as degraded", func() {
+		status, _ := computeStatusAt(application, nil, errors.New("pods is forbidden"), now)
+		degraded := meta.FindStatusCondition(status.Conditions, appsv1.ApplicationDegraded)
+		Expect(degraded.Status).To(Equal(metav1.ConditionTrue))
+		Expect(degraded.Reason).To(Equal(ReasonReconcileError))
+		Expect(degraded.Message).To(Equal("pods is forbidden"))
+		Expect(meta.FindStatusCondition(status.Conditions, appsv1.ApplicationProgressing).Reason).To(Equal(ReasonScaling))
+	})
+
+	It("should keep the transition time of unchanged conditions", func() {
+		pods := []*corev1.Pod{readyPod("app-"+hash+"-0", now.Add(-time.Minute))}
+		first, _ := computeStatusAt(application, pods, nil, now)
+		withStatus := application.DeepCopy()
+		withStatus.Status = first
+		withStatus.Status.Conditions[0].LastTransitionTime = metav1.NewTime(now.Add(-time.Hour))
+
+		second, _ := computeStatusAt(withStatus, pods, nil, now)
+		Expect(second.Conditions[0].LastTransitionTime.Time).To(BeTemporally("~", now.Add(-time.Hour), time.Second))
+	})
+
+	It("should report pods of previous templates as progressing", func() {
+		oldPod := readyPod("app-abc-0", now.Add(-time.Minute))
+		oldPod.Labels[appsv1.TemplateHashLabel] = "abc"
+		pods := []*corev1.Pod{oldPod, readyPod("app-"+hash+"-0", now.Add(-time.Minute))}
+		status, _ := computeStatusAt(application, pods, nil, now)
+		Expect(status.Replicas).To(Equal(int32(2)))
+		Expect(status.UpdatedReplicas).To(Equal(int32(1)))
+		progressing := meta.FindStatusCondition(status.Conditions, appsv1.ApplicationProgressing)
+		Expect(progressing.Reason).To(Equal(ReasonUpdating))
+		Expect(progressing.Message).To(Equal("1 of 2 replicas are updated, 1 old replicas are left"))
+	})
+})
@@ -0,0 +1,203 @@
+/*
+Copyright 2025 Foen.Ye.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	appsv1 "github.com/foenye/cloud-native-tour/operators/application-operator/api/v1"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var applicationlog = logf.Log.WithName("application-resource")
+
+// SetupApplicationWebhookWithManager registers the webhook for Application in the manager.
+func SetupApplicationWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&appsv1.Application{}).
+		WithValidator(&ApplicationCustomValidator{}).
+		WithDefaulter(&ApplicationCustomDefaulter{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-apps-foen-ye-v1-application,mutating=true,failurePolicy=fail,sideEffects=None,groups=apps.foen.ye,resources=applications,verbs=create;update,versions=v1,name=mapplication-v1.kb.io,admissionReviewVersions=v1
+
+// ApplicationCustomDefaulter struct is responsible for setting default values on the custom resource of the
+// Kind Application when those are created or updated.
+//
+// Replicas default to 1, the TerminationPolicy to Delete, and the template is
+// labeled with the selector of the Application, so that the pods created from
+// it match the selector.
+type ApplicationCustomDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &ApplicationCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind Application.
+func (d *ApplicationCustomDefaulter) Default(_ context.Context, obj runtime.Object) error {
+	application, ok := obj.(*appsv1.Application)
+	if !ok {
+		return fmt.Errorf("expected an Application object but got %T", obj)
+	}
+	applicationlog.Info("Defaulting for Application", "name", application.GetName())
+
+	if application.Spec.Replicas == nil {
+		application.Spec.Replicas = ptr.To[int32](1)
+	}
+	if len(application.Spec.TerminationPolicy) == 0 {
+		application.Spec.TerminationPolicy = appsv1.DeleteTerminationPolicy
+	}
+	if application.Spec.Template.Labels == nil {
+		application.Spec.Template.Labels = map[string]string{}
+	}
+	if _, exists := application.Spec.Template.Labels[appsv1.ApplicationNameLabel]; !exists {
+		application.Spec.Template.Labels[appsv1.ApplicationNameLabel] = application.Name
+	}
+	return nil
+}
+
+// selectorPath is the path of the template label matching the selector of an Application.
+var selectorPath = field.NewPath("spec", "template", "metadata", "labels").Key(appsv1.ApplicationNameLabel)
+
+// +kubebuilder:webhook:path=/validate-apps-foen-ye-v1-application,mutating=false,failurePolicy=fail,sideEffects=None,groups=apps.foen.ye,resources=applications,verbs=create;update,versions=v1,name=vapplication-v1.kb.io,admissionReviewVersions=v1
+
+// ApplicationCustomValidator struct is responsible for validating the Application resource
+// when it is created, updated, or deleted.
+type ApplicationCustomValidator struct{}
+
+var _ webhook.CustomValidator = &ApplicationCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Application.
+func (v *ApplicationCustomValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	application, ok := obj.(*appsv1.Application)
+	if !ok {
+		return nil, fmt.Errorf("expected a Application object but got %T", obj)
+	}
+	applicationlog.Info("Validation for Application upon creation", "name", application.GetName())
+
+	allErrs := validateApplication(application)
+	// The selector of an Application selects its pods by its name.
+	if selector, exists := application.Spec.Template.Labels[appsv1.ApplicationNameLabel]; exists && selector != application.Name {
+		allErrs = append(allErrs, field.Invalid(selectorPath, selector,
+			fmt.Sprintf("must be %q, the name of the Application its selector matches", application.Name)))
+	}
+	return nil, toAggregate(application, allErrs)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Application.
+func (v *ApplicationCustomValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	application, ok := newObj.(*appsv1.Application)
+	if !ok {
+		return nil, fmt.Errorf("expected a Application object for the newObj but got %T", newObj)
+	}
+	oldApplication, ok := oldObj.(*appsv1.Application)
+	if !ok {
+		return nil, fmt.Errorf("expected a Application object for the oldObj but got %T", oldObj)
+	}
+	applicationlog.Info("Validation for Application upon update", "name", application.GetName())
+
+	allErrs := validateApplication(application)
+	oldSelector, oldExists := oldApplication.Spec.Template.Labels[appsv1.ApplicationNameLabel]
+	if selector := application.Spec.Template.Labels[appsv1.ApplicationNameLabel]; oldExists && selector != oldSelector {
+		allErrs = append(allErrs, field.Invalid(selectorPath, selector, "field is immutable"))
+	}
+	return nil, toAggregate(application, allErrs)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Application.
+func (v *ApplicationCustomValidator) ValidateDelete(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	application, ok := obj.(*appsv1.Application)
+	if !ok {
+		return nil, fmt.Errorf("expected a Application object but got %T", obj)
+	}
+	applicationlog.Info("Validation for Application upon deletion", "name", application.GetName())
+
+	return nil, nil
+}
+
+// validateApplication validates the fields of application which hold whether
+// it is created or updated.
+func validateApplication(application *appsv1.Application) field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if replicas := application.Spec.Replicas; replicas != nil && *replicas < 0 {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("replicas"), *replicas, "must be greater than or equal to 0"))
+	}
+
+	templatePath := specPath.Child("template")
+	if len(application.Spec.Template.Spec.Containers) == 0 {
+		allErrs = append(allErrs, field.Required(templatePath.Child("spec", "containers"), "must have at least one container"))
+	}
+
+	strategyPath := specPath.Child("strategy")
+	strategy := application.Spec.Strategy
+	if rollingUpdate := strategy.RollingUpdate; rollingUpdate != nil {
+		rollingUpdatePath := strategyPath.Child("rollingUpdate")
+		if strategy.Type == appsv1.RecreateApplicationStrategyType {
+			allErrs = append(allErrs, field.Forbidden(rollingUpdatePath, "may not be specified when strategy type is 'Recreate'"))
+		}
+		maxSurgePath, maxUnavailablePath := rollingUpdatePath.Child("maxSurge"), rollingUpdatePath.Child("maxUnavailable")
+		maxSurge, surgeErrs := validateRollingUpdateBound(rollingUpdate.MaxSurge, maxSurgePath)
+		maxUnavailable, unavailableErrs := validateRollingUpdateBound(rollingUpdate.MaxUnavailable, maxUnavailablePath)
+		allErrs = append(append(allErrs, surgeErrs...), unavailableErrs...)
+		// Unset bounds default to 25%, the update could not make progress
+		// with both set to 0.
+		if maxSurge == 0 && maxUnavailable == 0 && rollingUpdate.MaxSurge != nil && rollingUpdate.MaxUnavailable != nil &&
+			len(surgeErrs)+len(unavailableErrs) == 0 {
+			allErrs = append(allErrs, field.Invalid(maxUnavailablePath, rollingUpdate.MaxUnavailable.String(),
+				"may not be 0 when maxSurge is 0"))
+		}
+	}
+	return allErrs
+}
+
+// validateRollingUpdateBound validates a maxSurge or maxUnavailable, which
+// must be a non-negative number or percentage, returning it scaled to 100
+// replicas.
+func validateRollingUpdateBound(bound *intstr.IntOrString, fldPath *field.Path) (int, field.ErrorList) {
+	if bound == nil {
+		return 0, nil
+	}
+	value, err := intstr.GetScaledValueFromIntOrPercent(bound, 100, true)
+	if err != nil {
+		return 0, field.ErrorList{field.Invalid(fldPath, bound.String(), "must be an integer or a percentage, e.g. '25%'")}
+	}
+	if value < 0 {
+		return 0, field.ErrorList{field.Invalid(fldPath, bound.String(), "must be greater than or equal to 0")}
+	}
+	return value, nil
+}
+
+// toAggregate returns allErrs as the Invalid error of application, or nil
+// when there are none.
+func toAggregate(application *appsv1.Application, allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(appsv1.GroupVersion.WithKind("Application").GroupKind(), application.Name, allErrs)
+}
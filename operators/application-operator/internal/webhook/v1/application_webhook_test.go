@@ -0,0 +1,172 @@
+/*
+Copyright 2025 Foen.Ye.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "github.com/foenye/cloud-native-tour/operators/application-operator/api/v1"
+)
+
+var _ = Describe("Application Webhook", func() {
+	var (
+		obj       *appsv1.Application
+		oldObj    *appsv1.Application
+		validator ApplicationCustomValidator
+		defaulter ApplicationCustomDefaulter
+	)
+
+	BeforeEach(func() {
+		obj = &appsv1.Application{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-application", Namespace: "default"},
+			Spec: appsv1.ApplicationSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "nginx", Image: "nginx:1.29.0"}},
+					},
+				},
+			},
+		}
+		oldObj = obj.DeepCopy()
+		validator = ApplicationCustomValidator{}
+		Expect(validator).NotTo(BeNil(), "Expected validator to be initialized")
+		defaulter = ApplicationCustomDefaulter{}
+		Expect(defaulter).NotTo(BeNil(), "Expected defaulter to be initialized")
+		Expect(oldObj).NotTo(BeNil(), "Expected oldObj to be initialized")
+		Expect(obj).NotTo(BeNil(), "Expected obj to be initialized")
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.DeleteAllOf(ctx, &appsv1.Application{}, client.InNamespace("default"))).To(Succeed())
+	})
+
+	// expectInvalid expects err to be an Invalid error about field.
+	expectInvalid := func(err error, field string) {
+		GinkgoHelper()
+		Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected an Invalid error, got %v", err)
+		Expect(err.Error()).To(ContainSubstring(field))
+	}
+
+	Context("When creating Application under Defaulting Webhook", func() {
+		It("Should default the replicas to 1 and label the template with the selector", func() {
+			By("calling the Default method to apply defaults")
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.Replicas).To(Equal(ptr.To[int32](1)))
+			Expect(obj.Spec.TerminationPolicy).To(Equal(appsv1.DeleteTerminationPolicy))
+			Expect(obj.Spec.Template.Labels).To(HaveKeyWithValue(appsv1.ApplicationNameLabel, "test-application"))
+		})
+
+		It("Should keep the replicas and labels which are set", func() {
+			obj.Spec.Replicas = ptr.To[int32](0)
+			obj.Spec.TerminationPolicy = appsv1.OrphanTerminationPolicy
+			obj.Spec.Template.Labels = map[string]string{"app": "nginx"}
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.Replicas).To(Equal(ptr.To[int32](0)))
+			Expect(obj.Spec.TerminationPolicy).To(Equal(appsv1.OrphanTerminationPolicy))
+			Expect(obj.Spec.Template.Labels).To(Equal(map[string]string{
+				"app":                       "nginx",
+				appsv1.ApplicationNameLabel: "test-application",
+			}))
+		})
+
+		It("Should default Applications created through the API server", func() {
+			Expect(k8sClient.Create(ctx, obj)).To(Succeed())
+			created := &appsv1.Application{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), created)).To(Succeed())
+			Expect(created.Spec.Replicas).To(Equal(ptr.To[int32](1)))
+			Expect(created.Spec.Template.Labels).To(HaveKeyWithValue(appsv1.ApplicationNameLabel, "test-application"))
+		})
+	})
+
+	Context("When creating or updating Application under Validating Webhook", func() {
+		It("Should admit creation if all required fields are present", func() {
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeNil())
+		})
+
+		It("Should deny creation if the replicas are negative", func() {
+			obj.Spec.Replicas = ptr.To[int32](-1)
+			_, err := validator.ValidateCreate(ctx, obj)
+			expectInvalid(err, "spec.replicas")
+
+			By("creating it through the API server")
+			expectInvalid(k8sClient.Create(ctx, obj), "spec.replicas")
+		})
+
+		It("Should deny creation if the template has no container", func() {
+			obj.Spec.Template.Spec.Containers = nil
+			_, err := validator.ValidateCreate(ctx, obj)
+			expectInvalid(err, "spec.template.spec.containers")
+
+			By("creating it through the API server")
+			expectInvalid(k8sClient.Create(ctx, obj), "spec.template.spec.containers")
+		})
+
+		It("Should deny a selector label which does not match the Application", func() {
+			obj.Spec.Template.Labels = map[string]string{appsv1.ApplicationNameLabel: "other-application"}
+			_, err := validator.ValidateCreate(ctx, obj)
+			expectInvalid(err, appsv1.ApplicationNameLabel)
+		})
+
+		It("Should deny invalid rolling update bounds", func() {
+			zero, invalid := intstr.FromInt32(0), intstr.FromString("a lot")
+			obj.Spec.Strategy.RollingUpdate = &appsv1.RollingUpdateApplication{MaxSurge: &zero, MaxUnavailable: &zero}
+			_, err := validator.ValidateCreate(ctx, obj)
+			expectInvalid(err, "spec.strategy.rollingUpdate.maxUnavailable")
+
+			obj.Spec.Strategy.RollingUpdate = &appsv1.RollingUpdateApplication{MaxSurge: &invalid}
+			_, err = validator.ValidateCreate(ctx, obj)
+			expectInvalid(err, "spec.strategy.rollingUpdate.maxSurge")
+
+			obj.Spec.Strategy = appsv1.ApplicationStrategy{
+				Type:          appsv1.RecreateApplicationStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateApplication{},
+			}
+			_, err = validator.ValidateCreate(ctx, obj)
+			expectInvalid(err, "spec.strategy.rollingUpdate")
+		})
+
+		It("Should deny changing the selector", func() {
+			Expect(defaulter.Default(ctx, oldObj)).To(Succeed())
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			obj.Spec.Template.Labels[appsv1.ApplicationNameLabel] = "other-application"
+			_, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			expectInvalid(err, "field is immutable")
+
+			By("updating it through the API server")
+			Expect(k8sClient.Create(ctx, oldObj)).To(Succeed())
+			updated := &appsv1.Application{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(oldObj), updated)).To(Succeed())
+			updated.Spec.Template.Labels[appsv1.ApplicationNameLabel] = "other-application"
+			expectInvalid(k8sClient.Update(ctx, updated), "field is immutable")
+		})
+
+		It("Should admit scaling to zero", func() {
+			Expect(defaulter.Default(ctx, oldObj)).To(Succeed())
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			obj.Spec.Replicas = ptr.To[int32](0)
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).To(BeNil())
+		})
+	})
+})